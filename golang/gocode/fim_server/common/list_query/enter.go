@@ -1,23 +1,82 @@
 package list_query
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fim_server/common/models"
 	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Option struct {
-	PageInfo models.PageInfo
-	Where    *gorm.DB // 高级查询
-	Debug    bool
-	Joins    string
-	Likes    []string             // 模糊匹配的字段
-	Preload  []string             // 预加载字段
-	Table    func() (string, any) // 子查询
-	Groups   []string             // 分组
+	PageInfo    models.PageInfo
+	Where       *gorm.DB // 高级查询
+	Debug       bool
+	Joins       string
+	Likes       []string             // 模糊匹配的字段
+	Preload     []string             // 预加载字段
+	Table       func() (string, any) // 子查询
+	Groups      []string             // 分组
+	SortColumns []string             // 允许排序的字段 PageInfo.Sort只能用这里面的字段
+	DefaultSort string               // 没传sort的时候的排序 只能是代码里面写死的 不要把前端的参数放进来
+	SkipCount   bool                 // 不查总数 count返回-1
+	CountLimit  int64                // 数到这么多就不数了 大表上查总数太慢 前端显示成 1000+
+	Filters     FilterSchema         // 允许筛选的字段 PageInfo.Filter只能用这里面的字段
 }
 
-func ListQuery[T any](db *gorm.DB, model T, option Option) (list []T, count int64, err error) {
+// columnRegex 字段名 可以带表名
+var columnRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// ParseSort 解析前端传的排序 created_at desc,id 字段必须在白名单里面
+func ParseSort(sort string, columns []string) ([]clause.OrderByColumn, error) {
+	var list []clause.OrderByColumn
+	for _, item := range strings.Split(sort, ",") {
+		fields := strings.Fields(item)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("排序格式错误 %s", item)
+		}
+		column := fields[0]
+		allow := false
+		for _, c := range columns {
+			if c == column {
+				allow = true
+				break
+			}
+		}
+		if !allow || !columnRegex.MatchString(column) {
+			return nil, fmt.Errorf("不支持的排序字段 %s", column)
+		}
+		desc := false
+		if len(fields) == 2 {
+			switch strings.ToLower(fields[1]) {
+			case "asc":
+			case "desc":
+				desc = true
+			default:
+				return nil, fmt.Errorf("排序方式只能是asc或desc %s", fields[1])
+			}
+		}
+		list = append(list, clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	}
+	return list, nil
+}
+
+// buildQuery 条件部分 分页和游标分页共用
+func buildQuery[T any](db *gorm.DB, model T, option Option) (*gorm.DB, error) {
+	filters, err := ParseFilter(option.PageInfo.Filter, option.Filters)
+	if err != nil {
+		return nil, err
+	}
 
 	if option.Debug {
 		db = db.Debug()
@@ -53,14 +112,48 @@ func ListQuery[T any](db *gorm.DB, model T, option Option) (list []T, count int6
 		query = query.Where(option.Where)
 	}
 
+	if len(filters) > 0 {
+		query = query.Clauses(clause.Where{Exprs: filters})
+	}
+
 	if len(option.Groups) > 0 {
 		for _, group := range option.Groups {
 			query = query.Group(group)
 		}
 	}
+	return query, nil
+}
+
+// countQuery 求总数 CountLimit大于0的时候只数到这么多
+func countQuery[T any](query *gorm.DB, model T, option Option) (count int64, err error) {
+	if option.SkipCount {
+		return -1, nil
+	}
+	if option.CountLimit > 0 {
+		sub := query.Session(&gorm.Session{}).Model(model).Select("1").Limit(int(option.CountLimit))
+		err = query.Session(&gorm.Session{NewDB: true}).Table("(?) as t", sub).Count(&count).Error
+		return
+	}
+	err = query.Session(&gorm.Session{}).Model(model).Count(&count).Error
+	return
+}
+
+func ListQuery[T any](db *gorm.DB, model T, option Option) (list []T, count int64, err error) {
+	orders, err := ParseSort(option.PageInfo.Sort, option.SortColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query, err := buildQuery(db, model, option)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	// 求总数
-	query.Model(model).Count(&count)
+	count, err = countQuery(query, model, option)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	// 预加载
 	for _, s := range option.Preload {
@@ -79,10 +172,128 @@ func ListQuery[T any](db *gorm.DB, model T, option Option) (list []T, count int6
 
 	offset := (option.PageInfo.Page - 1) * option.PageInfo.Limit
 
-	if option.PageInfo.Sort != "" {
-		query.Order(option.PageInfo.Sort)
+	for _, order := range orders {
+		query = query.Order(order)
+	}
+	if len(orders) == 0 && option.DefaultSort != "" {
+		query = query.Order(option.DefaultSort)
 	}
 
 	err = query.Limit(option.PageInfo.Limit).Offset(offset).Find(&list).Error
 	return
 }
+
+// CursorOption 游标分页 翻页的时候有新数据插进来也不会重复或者漏掉
+type CursorOption struct {
+	Column string // 排序字段 要有索引 不是唯一的时候再按id排
+	Desc   bool   // 倒序 聊天记录往上翻就是倒序
+	Cursor string // 上一页返回的next 第一页不传
+	Limit  int
+}
+
+// cursorValue 游标里面存的是上一页最后一条的排序字段和id
+type cursorValue struct {
+	Value any  `json:"v"`
+	ID    uint `json:"id"`
+}
+
+func encodeCursor(c cursorValue) string {
+	byteData, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(byteData)
+}
+
+func decodeCursor(s string) (c cursorValue, err error) {
+	byteData, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errors.New("游标错误")
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(byteData)))
+	decoder.UseNumber()
+	err = decoder.Decode(&c)
+	if err != nil {
+		return c, errors.New("游标错误")
+	}
+	if n, ok := c.Value.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			c.Value = i
+		} else {
+			c.Value, _ = n.Float64()
+		}
+	}
+	return c, nil
+}
+
+// CursorQuery 游标分页 next为空说明没有下一页了
+func CursorQuery[T any](db *gorm.DB, model T, option Option, cursor CursorOption) (list []T, next string, err error) {
+	if cursor.Column == "" {
+		cursor.Column = "id"
+	}
+	if !columnRegex.MatchString(cursor.Column) {
+		return nil, "", fmt.Errorf("游标字段错误 %s", cursor.Column)
+	}
+	if cursor.Limit <= 0 {
+		cursor.Limit = 10
+	}
+
+	// 拿到排序字段对应结构体上的哪个字段 生成下一页游标要用
+	stmt := &gorm.Statement{DB: db}
+	err = stmt.Parse(&model)
+	if err != nil {
+		return nil, "", err
+	}
+	columnName := cursor.Column[strings.LastIndex(cursor.Column, ".")+1:]
+	field := stmt.Schema.LookUpField(columnName)
+	idField := stmt.Schema.LookUpField("id")
+	if field == nil || idField == nil {
+		return nil, "", fmt.Errorf("游标字段不存在 %s", cursor.Column)
+	}
+	idColumn := "id"
+	if i := strings.LastIndex(cursor.Column, "."); i != -1 {
+		idColumn = cursor.Column[:i] + ".id"
+	}
+
+	query, err := buildQuery(db, model, option)
+	if err != nil {
+		return nil, "", err
+	}
+	if cursor.Cursor != "" {
+		c, err := decodeCursor(cursor.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		op := ">"
+		if cursor.Desc {
+			op = "<"
+		}
+		if columnName == "id" {
+			query = query.Where(fmt.Sprintf("%s %s ?", idColumn, op), c.ID)
+		} else {
+			query = query.Where(fmt.Sprintf("(%s %s ? or (%s = ? and %s %s ?))", cursor.Column, op, cursor.Column, idColumn, op),
+				c.Value, c.Value, c.ID)
+		}
+	}
+
+	for _, s := range option.Preload {
+		query = query.Preload(s)
+	}
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: cursor.Column}, Desc: cursor.Desc})
+	if columnName != "id" {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: idColumn}, Desc: cursor.Desc})
+	}
+
+	// 多查一条 看还有没有下一页
+	err = query.Limit(cursor.Limit + 1).Find(&list).Error
+	if err != nil {
+		return nil, "", err
+	}
+	if len(list) <= cursor.Limit {
+		return list, "", nil
+	}
+	list = list[:cursor.Limit]
+	last := reflect.ValueOf(&list[len(list)-1]).Elem()
+	value, _ := field.ValueOf(context.Background(), last)
+	id, _ := idField.ValueOf(context.Background(), last)
+	c := cursorValue{Value: value}
+	c.ID, _ = id.(uint)
+	return list, encodeCursor(c), nil
+}
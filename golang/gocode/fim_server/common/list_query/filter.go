@@ -0,0 +1,194 @@
+package list_query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+type FieldType int8
+
+const (
+	StringField FieldType = iota + 1
+	IntField
+	BoolField
+	TimeField
+)
+
+// Field 允许筛选的字段
+type Field struct {
+	Column string    // 数据库字段 可以带表名 为空就和筛选的字段名一样
+	Type   FieldType // 值的类型
+	Ops    []string  // 允许的操作符 为空就按类型默认
+}
+
+// FilterSchema 每个接口自己定义哪些字段可以筛选 key是前端传的字段名
+type FilterSchema map[string]Field
+
+// opList 操作符 长的放前面 >= 要先于 > 匹配
+// = 后面可以用 | 分隔多个值 就是in
+// ~ 是包含 模糊匹配
+var opList = []string{">=", "<=", "!=", "=", ">", "<", "~"}
+
+var defaultOps = map[FieldType][]string{
+	StringField: {"=", "!=", "~"},
+	IntField:    {"=", "!=", ">", ">=", "<", "<="},
+	BoolField:   {"=", "!="},
+	TimeField:   {">", ">=", "<", "<="},
+}
+
+var timeLayoutList = []string{"2006-01-02 15:04:05", "2006-01-02"}
+
+// ParseFilter 解析前端传的筛选条件 created_at>=2024-01-01,role=2,nickname~feng
+// 值里面有逗号的用 \, 转义
+func ParseFilter(filter string, schema FilterSchema) ([]clause.Expression, error) {
+	var list []clause.Expression
+	for _, item := range splitFilter(filter) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, op, value, ok := cutOp(item)
+		if !ok {
+			return nil, fmt.Errorf("筛选条件格式错误 %s", item)
+		}
+		field, ok := schema[name]
+		if !ok {
+			return nil, fmt.Errorf("不支持筛选的字段 %s", name)
+		}
+		ops := field.Ops
+		if len(ops) == 0 {
+			ops = defaultOps[field.Type]
+		}
+		allow := false
+		for _, o := range ops {
+			if o == op {
+				allow = true
+				break
+			}
+		}
+		if !allow {
+			return nil, fmt.Errorf("字段 %s 不支持 %s 筛选 只能用 %s", name, op, strings.Join(ops, " "))
+		}
+		column := field.Column
+		if column == "" {
+			column = name
+		}
+		if !columnRegex.MatchString(column) {
+			return nil, fmt.Errorf("筛选字段错误 %s", column)
+		}
+		expr, err := buildExpr(clause.Column{Name: column}, name, field.Type, op, value)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, expr)
+	}
+	return list, nil
+}
+
+// splitFilter 按没有转义的逗号分开
+func splitFilter(filter string) (list []string) {
+	var b strings.Builder
+	for i := 0; i < len(filter); i++ {
+		if filter[i] == '\\' && i+1 < len(filter) && filter[i+1] == ',' {
+			b.WriteByte(',')
+			i++
+			continue
+		}
+		if filter[i] == ',' {
+			list = append(list, b.String())
+			b.Reset()
+			continue
+		}
+		b.WriteByte(filter[i])
+	}
+	return append(list, b.String())
+}
+
+// cutOp 拆出字段名 操作符 值 字段名只能是字母数字下划线
+func cutOp(item string) (name, op, value string, ok bool) {
+	i := 0
+	for i < len(item) && (item[i] == '_' || item[i] >= 'a' && item[i] <= 'z' || item[i] >= 'A' && item[i] <= 'Z' || item[i] >= '0' && item[i] <= '9') {
+		i++
+	}
+	if i == 0 {
+		return
+	}
+	name = item[:i]
+	rest := strings.TrimLeft(item[i:], " ")
+	for _, o := range opList {
+		if strings.HasPrefix(rest, o) {
+			return name, o, strings.TrimSpace(rest[len(o):]), true
+		}
+	}
+	return
+}
+
+func parseValue(name string, t FieldType, value string) (any, error) {
+	switch t {
+	case IntField:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("字段 %s 的值必须是整数", name)
+		}
+		return v, nil
+	case BoolField:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("字段 %s 的值必须是true或false", name)
+		}
+		return v, nil
+	case TimeField:
+		for _, layout := range timeLayoutList {
+			v, err := time.ParseInLocation(layout, value, time.Local)
+			if err == nil {
+				return v.Format("2006-01-02 15:04:05"), nil
+			}
+		}
+		return nil, fmt.Errorf("字段 %s 的值必须是时间 比如 2024-01-01 或者 2024-01-01 12:00:00", name)
+	}
+	return value, nil
+}
+
+// likeEscaper 用户输入里面的 % _ 不能当通配符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func buildExpr(column clause.Column, name string, t FieldType, op, value string) (clause.Expression, error) {
+	if value == "" {
+		return nil, fmt.Errorf("字段 %s 的值不能为空", name)
+	}
+	if op == "~" {
+		return clause.Like{Column: column, Value: "%" + likeEscaper.Replace(value) + "%"}, nil
+	}
+	if op == "=" && strings.Contains(value, "|") {
+		var values []any
+		for _, s := range strings.Split(value, "|") {
+			v, err := parseValue(name, t, strings.TrimSpace(s))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return clause.IN{Column: column, Values: values}, nil
+	}
+	v, err := parseValue(name, t, value)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "=":
+		return clause.Eq{Column: column, Value: v}, nil
+	case "!=":
+		return clause.Neq{Column: column, Value: v}, nil
+	case ">":
+		return clause.Gt{Column: column, Value: v}, nil
+	case ">=":
+		return clause.Gte{Column: column, Value: v}, nil
+	case "<":
+		return clause.Lt{Column: column, Value: v}, nil
+	}
+	return clause.Lte{Column: column, Value: v}, nil
+}
@@ -0,0 +1,69 @@
+package list_query
+
+import (
+	"fim_server/common/models"
+	"strings"
+	"testing"
+)
+
+var testSchema = FilterSchema{
+	"created_at": {Type: TimeField},
+	"role":       {Type: IntField},
+	"nickname":   {Type: StringField},
+	"ban":        {Type: BoolField},
+	"ip":         {Column: "login_log_models.ip", Type: StringField, Ops: []string{"="}},
+}
+
+func TestListQueryFilter(t *testing.T) {
+	db, log := dryRunDB(t)
+	_, _, err := ListQuery(db, testModel{}, Option{
+		PageInfo:  models.PageInfo{Filter: `created_at>=2024-01-01,role=1|2,nickname~f%e_ng,ban=false,ip=1.1.1.1`},
+		Filters:   testSchema,
+		SkipCount: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql := log.sqlList[0]
+	for _, want := range []string{
+		"`created_at` >= '2024-01-01 00:00:00'",
+		"`role` IN (1,2)",
+		"`nickname` LIKE '%f\\%e\\_ng%'",
+		"`ban` = false",
+		"`login_log_models`.`ip` = '1.1.1.1'",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("缺少条件 %s\n%s", want, sql)
+		}
+	}
+}
+
+func TestParseFilterError(t *testing.T) {
+	for filter, msg := range map[string]string{
+		"pwd=1":                 "不支持筛选的字段 pwd",
+		"role~1":                "字段 role 不支持 ~ 筛选",
+		"ip~1.1":                "字段 ip 不支持 ~ 筛选",
+		"role=abc":              "字段 role 的值必须是整数",
+		"created_at>=yesterday": "字段 created_at 的值必须是时间",
+		"ban=maybe":             "字段 ban 的值必须是true或false",
+		"nickname":              "筛选条件格式错误",
+		"=1":                    "筛选条件格式错误",
+		"nickname=":             "字段 nickname 的值不能为空",
+		"role=1;drop table x":   "字段 role 的值必须是整数",
+		"role) or (1=1":         "筛选条件格式错误",
+		"nickname=a,role>>1":    "字段 role 的值必须是整数",
+		"created_at=2024-01-01": "字段 created_at 不支持 = 筛选",
+	} {
+		_, err := ParseFilter(filter, testSchema)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%s 期望 %s 实际 %v", filter, msg, err)
+		}
+	}
+}
+
+func TestSplitFilter(t *testing.T) {
+	exprs, err := ParseFilter(`nickname=a\,b`, testSchema)
+	if err != nil || len(exprs) != 1 {
+		t.Fatalf("转义的逗号不应该拆开 %v %v", exprs, err)
+	}
+}
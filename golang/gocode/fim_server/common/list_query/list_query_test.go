@@ -0,0 +1,131 @@
+package list_query
+
+import (
+	"context"
+	"fim_server/common/models"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type testModel struct {
+	models.Model
+	Nickname string
+}
+
+// sqlLogger 记下最后执行的sql 不连数据库也能看生成的语句
+type sqlLogger struct {
+	logger.Interface
+	sqlList []string
+}
+
+func (l *sqlLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	l.sqlList = append(l.sqlList, sql)
+}
+
+func dryRunDB(t *testing.T) (*gorm.DB, *sqlLogger) {
+	log := &sqlLogger{Interface: logger.Discard}
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "root:root@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               log,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, log
+}
+
+func TestParseSort(t *testing.T) {
+	columns := []string{"id", "created_at"}
+	orders, err := ParseSort("created_at desc, id", columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 || orders[0].Column.Name != "created_at" || !orders[0].Desc || orders[1].Desc {
+		t.Errorf("排序解析错误 %+v", orders)
+	}
+
+	for _, sort := range []string{
+		"nickname",
+		"id; drop table user_models",
+		"(select 1)",
+		"id desc desc",
+		"id sideways",
+	} {
+		_, err = ParseSort(sort, columns)
+		if err == nil {
+			t.Errorf("%s 应该报错", sort)
+		}
+	}
+}
+
+func TestListQuerySort(t *testing.T) {
+	db, log := dryRunDB(t)
+	_, _, err := ListQuery(db, testModel{}, Option{
+		PageInfo:    models.PageInfo{Sort: "id desc;delete from test_models"},
+		SortColumns: []string{"id"},
+	})
+	if err == nil {
+		t.Fatal("不在白名单里面的排序应该报错")
+	}
+
+	_, count, err := ListQuery(db, testModel{}, Option{
+		PageInfo:    models.PageInfo{Sort: "created_at desc"},
+		SortColumns: []string{"created_at"},
+		SkipCount:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != -1 || len(log.sqlList) != 1 {
+		t.Errorf("跳过总数不应该查count %d %v", count, log.sqlList)
+	}
+	if !strings.Contains(log.sqlList[0], "ORDER BY `created_at` DESC") {
+		t.Errorf("排序错误 %s", log.sqlList[0])
+	}
+
+	log.sqlList = nil
+	ListQuery(db, testModel{}, Option{CountLimit: 1000})
+	if !strings.Contains(log.sqlList[0], "LIMIT 1000) as t") {
+		t.Errorf("估算总数错误 %s", log.sqlList[0])
+	}
+}
+
+func TestCursorQuery(t *testing.T) {
+	db, log := dryRunDB(t)
+	next := encodeCursor(cursorValue{Value: "2024-01-01 00:00:00", ID: 10})
+	_, _, err := CursorQuery(db, testModel{}, Option{}, CursorOption{Column: "created_at", Desc: true, Cursor: next, Limit: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql := log.sqlList[len(log.sqlList)-1]
+	if !strings.Contains(sql, "(created_at < '2024-01-01 00:00:00' or (created_at = '2024-01-01 00:00:00' and id < 10))") ||
+		!strings.Contains(sql, "ORDER BY `created_at` DESC,`id` DESC LIMIT 21") {
+		t.Errorf("游标分页sql错误 %s", sql)
+	}
+
+	_, _, err = CursorQuery(db, testModel{}, Option{}, CursorOption{Cursor: "xx!"})
+	if err == nil {
+		t.Error("游标错误应该报错")
+	}
+	_, _, err = CursorQuery(db, testModel{}, Option{}, CursorOption{Column: "id;1"})
+	if err == nil {
+		t.Error("游标字段错误应该报错")
+	}
+}
+
+func TestCursorDecode(t *testing.T) {
+	c, err := decodeCursor(encodeCursor(cursorValue{Value: uint(1 << 60), ID: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Value != int64(1<<60) || c.ID != 3 {
+		t.Errorf("游标解析错误 %+v", c)
+	}
+}
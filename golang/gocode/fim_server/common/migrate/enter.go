@@ -0,0 +1,227 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 一次表结构变更 Version按时间生成 只能往后加
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+	// NoTx 不放在事务里面执行 mysql的ddl会隐式提交 事务只对数据回填有用
+	// 一次要改很多数据 不想锁太久的也可以不用事务
+	NoTx bool
+}
+
+// SchemaMigrationModel 已经执行过的变更
+type SchemaMigrationModel struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"size:128" json:"name"`
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+func (SchemaMigrationModel) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 每个变更的执行情况
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool // 数据库里面有 代码里面没有
+}
+
+var migrationList []Migration
+
+// Register 注册变更 在变更文件的init里面调用
+func Register(m Migration) {
+	for _, item := range migrationList {
+		if item.Version == m.Version {
+			panic(fmt.Sprintf("变更版本重复 %d %s %s", m.Version, item.Name, m.Name))
+		}
+	}
+	migrationList = append(migrationList, m)
+	sort.Slice(migrationList, func(i, j int) bool {
+		return migrationList[i].Version < migrationList[j].Version
+	})
+}
+
+// List 按版本排好序的全部变更
+func List() []Migration {
+	return migrationList
+}
+
+func applied(db *gorm.DB) (map[int64]SchemaMigrationModel, error) {
+	err := db.AutoMigrate(&SchemaMigrationModel{})
+	if err != nil {
+		return nil, err
+	}
+	var list []SchemaMigrationModel
+	err = db.Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	appliedMap := map[int64]SchemaMigrationModel{}
+	for _, item := range list {
+		appliedMap[item.Version] = item
+	}
+	return appliedMap, nil
+}
+
+// run 执行一次变更 变更和版本记录在同一个事务里面
+func run(db *gorm.DB, m Migration, fn func(tx *gorm.DB) error, record func(tx *gorm.DB) error) error {
+	if fn == nil {
+		return fmt.Errorf("%d_%s 没有实现", m.Version, m.Name)
+	}
+	if m.NoTx {
+		err := fn(db)
+		if err != nil {
+			return err
+		}
+		return record(db)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := fn(tx)
+		if err != nil {
+			return err
+		}
+		return record(tx)
+	})
+}
+
+// Up 执行所有还没有执行的变更
+func Up(db *gorm.DB) (list []Migration, err error) {
+	appliedMap, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range migrationList {
+		if _, ok := appliedMap[m.Version]; ok {
+			continue
+		}
+		err = run(db, m, m.Up, func(tx *gorm.DB) error {
+			return tx.Create(&SchemaMigrationModel{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return list, fmt.Errorf("%d_%s 执行失败 %w", m.Version, m.Name, err)
+		}
+		list = append(list, m)
+	}
+	return list, nil
+}
+
+// Down 回滚最近执行的n个变更
+func Down(db *gorm.DB, n int) (list []Migration, err error) {
+	if n <= 0 {
+		return nil, errors.New("回滚的个数必须大于0")
+	}
+	appliedMap, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(migrationList) - 1; i >= 0 && len(list) < n; i-- {
+		m := migrationList[i]
+		if _, ok := appliedMap[m.Version]; !ok {
+			continue
+		}
+		err = run(db, m, m.Down, func(tx *gorm.DB) error {
+			return tx.Delete(&SchemaMigrationModel{}, m.Version).Error
+		})
+		if err != nil {
+			return list, fmt.Errorf("%d_%s 回滚失败 %w", m.Version, m.Name, err)
+		}
+		list = append(list, m)
+	}
+	return list, nil
+}
+
+// GetStatus 所有变更的执行情况 按版本排序
+func GetStatus(db *gorm.DB) ([]Status, error) {
+	appliedMap, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	var list []Status
+	for _, m := range migrationList {
+		item, ok := appliedMap[m.Version]
+		list = append(list, Status{
+			Version:   m.Version,
+			Name:      m.Name,
+			Applied:   ok,
+			AppliedAt: item.AppliedAt,
+		})
+		delete(appliedMap, m.Version)
+	}
+	for _, item := range appliedMap {
+		list = append(list, Status{
+			Version:   item.Version,
+			Name:      item.Name,
+			Applied:   true,
+			AppliedAt: item.AppliedAt,
+			Missing:   true,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list, nil
+}
+
+// nameRegex 变更名称 会用在文件名里面
+var nameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+const template = `package %s
+
+import (
+	"fim_server/common/migrate"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: %d,
+		Name:    "%s",
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`
+
+// Create 在dir下面生成一个空的变更文件 版本号是当前时间
+func Create(dir string, pkg string, name string, now time.Time) (string, error) {
+	if !nameRegex.MatchString(name) {
+		return "", errors.New("变更名称只能是小写字母、数字和下划线 并且以字母开头")
+	}
+	version, _ := strconv.ParseInt(now.Format("20060102150405"), 10, 64)
+	for _, m := range migrationList {
+		if m.Version == version {
+			return "", fmt.Errorf("变更版本 %d 已经存在", version)
+		}
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%d_%s.go", version, name))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, template, pkg, version, name)
+	return path, err
+}
@@ -0,0 +1,62 @@
+package migrate
+
+import (
+	"go/format"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegister(t *testing.T) {
+	defer func(list []Migration) { migrationList = list }(migrationList)
+	migrationList = nil
+
+	Register(Migration{Version: 3, Name: "c"})
+	Register(Migration{Version: 1, Name: "a"})
+	Register(Migration{Version: 2, Name: "b"})
+	var names []string
+	for _, m := range List() {
+		names = append(names, m.Name)
+	}
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Errorf("变更顺序错误 %v", names)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("版本重复应该panic")
+		}
+	}()
+	Register(Migration{Version: 2, Name: "d"})
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local)
+	path, err := Create(dir, "migrations", "add_user_index", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, "20261019100000_add_user_index.go") {
+		t.Errorf("文件名错误 %s", path)
+	}
+	byteData, _ := os.ReadFile(path)
+	formatted, err := format.Source(byteData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(formatted) != string(byteData) {
+		t.Error("生成的文件没有gofmt")
+	}
+
+	// 同一个文件不能覆盖
+	_, err = Create(dir, "migrations", "add_user_index", now)
+	if err == nil {
+		t.Error("文件已经存在应该报错")
+	}
+	_, err = Create(dir, "migrations", "Add-Index", now)
+	if err == nil {
+		t.Error("名称不合法应该报错")
+	}
+}
@@ -5,6 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fim_server/utils/safe"
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -25,6 +31,12 @@ const (
 	TipMsgType
 	FriendOnlineMsgType
 	ImageTextMsgType
+	EncryptedMsgType
+	LocationMsgType
+	CardMsgType
+	StickerMsgType
+	PollMsgType
+	MergeForwardMsgType
 )
 
 type Msg struct {
@@ -43,42 +55,154 @@ type Msg struct {
 	TipMsg          *TipMsg          `json:"tipMsg,omitempty"`          // 提示消息 一般是不入库的
 	FriendOnlineMsg *FriendOnlineMsg `json:"friendOnlineMsg,omitempty"` // 好友上线提醒 不入库的
 	ImageTextMsg    *ImageTextMsg    `json:"imageTextMsg,omitempty"`    // 图文消息
+	EncryptedMsg    *EncryptedMsg    `json:"encryptedMsg,omitempty"`    // 端到端加密消息 服务端不解析
+	LocationMsg     *LocationMsg     `json:"locationMsg,omitempty"`     // 位置
+	CardMsg         *CardMsg         `json:"cardMsg,omitempty"`         // 名片 分享用户或者群
+	StickerMsg      *StickerMsg      `json:"stickerMsg,omitempty"`      // 表情
+	PollMsg         *PollMsg         `json:"pollMsg,omitempty"`         // 投票 群聊才有
+	MergeForwardMsg *MergeForwardMsg `json:"mergeForwardMsg,omitempty"` // 合并转发的聊天记录
 }
 
 func (msg Msg) MsgPreview() string {
 	switch msg.Type {
-	case 1:
+	case TextMsgType:
 		var runes = []rune(msg.TextMsg.Content)
 		if len(runes) > 30 {
 			return string(runes[:30])
 		}
 		return msg.TextMsg.Content
-	case 2:
+	case ImageMsgType:
 		return "[图片消息] - " + msg.ImageMsg.Title
-	case 3:
+	case VideoMsgType:
 		return "[视频消息] - " + msg.VideoMsg.Title
-	case 4:
+	case FileMsgType:
 		return "[文件消息] - " + msg.FileMsg.Title
-	case 5:
+	case VoiceMsgType:
 		return "[语音消息]"
-	case 6:
+	case VoiceCallMsgType:
 		return "[语言通话]"
-	case 7:
+	case VideoCallMsgType:
 		return "[视频通话]"
-	case 8:
+	case WithdrawMsgType:
 		return "[撤回消息] - " + msg.WithdrawMsg.Content
-	case 9:
+	case ReplyMsgType:
 		return "[回复消息] - " + msg.ReplyMsg.Content
-	case 10:
+	case QuoteMsgType:
 		return "[引用消息] - " + msg.QuoteMsg.Content
-	case 11:
+	case AtMsgType:
 		return "[@消息] - " + msg.AtMsg.Content
-	case 14:
+	case TipMsgType:
+		return "[提示] - " + msg.TipMsg.Content
+	case ImageTextMsgType:
 		return "[图文消息]"
+	case EncryptedMsgType:
+		return "[加密消息]"
+	case LocationMsgType:
+		return "[位置] - " + msg.LocationMsg.Title
+	case CardMsgType:
+		if msg.CardMsg.Type == GroupCard {
+			return "[群名片] - " + msg.CardMsg.Name
+		}
+		return "[个人名片] - " + msg.CardMsg.Name
+	case StickerMsgType:
+		return "[表情] - " + msg.StickerMsg.Title
+	case PollMsgType:
+		return "[投票] - " + msg.PollMsg.Question
+	case MergeForwardMsgType:
+		return "[聊天记录] - " + msg.MergeForwardMsg.Title
 	}
 	return "[未知消息]"
 }
 
+// SearchText 消息里面可以搜索的文本 撤回的和没有文本的消息是空
+func (msg Msg) SearchText() string {
+	switch msg.Type {
+	case TextMsgType:
+		if msg.TextMsg != nil {
+			return msg.TextMsg.Content
+		}
+	case ReplyMsgType:
+		if msg.ReplyMsg != nil {
+			return msg.ReplyMsg.Content
+		}
+	case QuoteMsgType:
+		if msg.QuoteMsg != nil {
+			return msg.QuoteMsg.Content
+		}
+	case AtMsgType:
+		if msg.AtMsg != nil {
+			return msg.AtMsg.Content
+		}
+	case ImageTextMsgType:
+		if msg.ImageTextMsg != nil {
+			return safe.StripHTML(msg.ImageTextMsg.Content)
+		}
+	case EncryptedMsgType:
+		// 加密消息服务端看不到内容 不能搜
+		return ""
+	case LocationMsgType:
+		if msg.LocationMsg != nil {
+			return strings.TrimSpace(msg.LocationMsg.Title + " " + msg.LocationMsg.Address)
+		}
+	case PollMsgType:
+		if msg.PollMsg != nil {
+			return strings.Join(append([]string{msg.PollMsg.Question}, msg.PollMsg.Options...), " ")
+		}
+	case MergeForwardMsgType:
+		if msg.MergeForwardMsg != nil {
+			list := []string{msg.MergeForwardMsg.Title}
+			for _, item := range msg.MergeForwardMsg.List {
+				if text := item.Msg.SearchText(); text != "" {
+					list = append(list, text)
+				}
+			}
+			return strings.Join(list, " ")
+		}
+	}
+	return ""
+}
+
+// UpdatedMsg 保存的时候拿到新的消息内容 Update传的是map Create Save Updates传的是结构体
+// 批量创建传的是切片 用当前这一条的消息 没有改消息内容返回false
+func UpdatedMsg(dest any, current Msg) (Msg, bool) {
+	value := reflect.ValueOf(dest)
+	for value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return Msg{}, false
+		}
+		for _, key := range []string{"msg", "Msg"} {
+			v := value.MapIndex(reflect.ValueOf(key))
+			if !v.IsValid() {
+				continue
+			}
+			switch msg := v.Interface().(type) {
+			case Msg:
+				return msg, true
+			case *Msg:
+				if msg != nil {
+					return *msg, true
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		return current, true
+	case reflect.Struct:
+		field := value.FieldByName("Msg")
+		if !field.IsValid() {
+			return Msg{}, false
+		}
+		msg, ok := field.Interface().(Msg)
+		if ok && msg.Type != 0 {
+			return msg, true
+		}
+	}
+	return Msg{}, false
+}
+
 func (msg Msg) Validate() error {
 	switch msg.Type {
 	case TextMsgType:
@@ -106,7 +230,36 @@ func (msg Msg) Validate() error {
 			return errors.New("图文消息不能为空")
 		}
 		return msg.ImageTextMsg.Validate()
-
+	case EncryptedMsgType:
+		if msg.EncryptedMsg == nil {
+			return errors.New("加密消息不能为空")
+		}
+		return msg.EncryptedMsg.Validate()
+	case LocationMsgType:
+		if msg.LocationMsg == nil {
+			return errors.New("位置消息不能为空")
+		}
+		return msg.LocationMsg.Validate()
+	case CardMsgType:
+		if msg.CardMsg == nil {
+			return errors.New("名片消息不能为空")
+		}
+		return msg.CardMsg.Validate()
+	case StickerMsgType:
+		if msg.StickerMsg == nil {
+			return errors.New("表情消息不能为空")
+		}
+		return msg.StickerMsg.Validate()
+	case PollMsgType:
+		if msg.PollMsg == nil {
+			return errors.New("投票消息不能为空")
+		}
+		return msg.PollMsg.Validate()
+	case MergeForwardMsgType:
+		if msg.MergeForwardMsg == nil {
+			return errors.New("聊天记录不能为空")
+		}
+		return msg.MergeForwardMsg.Validate()
 	}
 	return nil
 }
@@ -259,13 +412,253 @@ func (t ImageTextMsg) Validate() error {
 	if t.Content == "" {
 		return errors.New("请输入图文消息")
 	}
-	// 防xss注入
-	//img i 标签只能支持这两种 onerror
-	// <img src="xxx"/> 这是文本 <i class="iconfont xxx">
-	// <script src="xxx"></script> <img src="" onerror="alert(1)"> 这是文本 <i class="iconfont xxx">
-	if safe.SanitizeHTML(t.Content) != t.Content {
-		return errors.New("图文消息非法")
+	// 防xss注入 图文消息只允许白名单里面的标签和属性
+	// <img src="xxx"/> 这是文本 <i class="iconfont xxx"></i>
+	violations := safe.ImageTextPolicy.Check(t.Content)
+	if len(violations) > 0 {
+		return fmt.Errorf("图文消息非法 %s", strings.Join(violations, "; "))
+	}
+
+	return nil
+}
+
+// EncryptedMsg 端到端加密消息 发送方给对方的每个设备和自己的其他设备各加密一份
+// 服务端只存和转发 不解密 不审核 不搜索
+type EncryptedMsg struct {
+	SenderDeviceID string              `json:"senderDeviceID"` // 发送方的设备
+	Envelopes      []EncryptedEnvelope `json:"envelopes"`
+}
+
+// EncryptedEnvelope 给某一个设备的密文
+type EncryptedEnvelope struct {
+	DeviceID string `json:"deviceID"` // 接收的设备
+	Type     int8   `json:"type"`     // 1 带预密钥的首条消息 2 普通消息
+	Body     string `json:"body"`     // 密文 base64
+}
+
+// maxEnvelopes 一条消息最多给多少个设备加密
+const maxEnvelopes = 32
+
+func (t EncryptedMsg) Validate() error {
+	if t.SenderDeviceID == "" {
+		return errors.New("请输入发送方的设备")
+	}
+	if len(t.Envelopes) == 0 {
+		return errors.New("加密消息不能为空")
+	}
+	if len(t.Envelopes) > maxEnvelopes {
+		return fmt.Errorf("加密消息最多发给%d个设备", maxEnvelopes)
+	}
+	for _, envelope := range t.Envelopes {
+		if envelope.DeviceID == "" {
+			return errors.New("请输入接收的设备")
+		}
+		if envelope.Type != 1 && envelope.Type != 2 {
+			return errors.New("加密消息类型错误")
+		}
+		if envelope.Body == "" {
+			return errors.New("密文不能为空")
+		}
 	}
+	return nil
+}
+
+type LocationMsg struct {
+	Lat     float64 `json:"lat"` // 纬度
+	Lng     float64 `json:"lng"` // 经度
+	Title   string  `json:"title"`
+	Address string  `json:"address"` // 详细地址 可以不传
+}
 
+func (t LocationMsg) Validate() error {
+	if t.Lat < -90 || t.Lat > 90 || t.Lng < -180 || t.Lng > 180 {
+		return errors.New("经纬度错误")
+	}
+	if t.Title == "" {
+		return errors.New("请输入位置消息的title")
+	}
+	if len([]rune(t.Title)) > 64 || len([]rune(t.Address)) > 128 {
+		return errors.New("位置名称或者地址太长了")
+	}
 	return nil
 }
+
+// 名片类型
+const (
+	UserCard  int8 = 1 // 分享用户
+	GroupCard int8 = 2 // 分享群
+)
+
+// CardMsg 名片 客户端只传类型和id 名字和头像服务端查出来填上
+type CardMsg struct {
+	Type   int8   `json:"type"` // 1 用户 2 群
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Avatar string `json:"avatar"`
+}
+
+func (t CardMsg) Validate() error {
+	if t.Type != UserCard && t.Type != GroupCard {
+		return errors.New("名片类型错误")
+	}
+	if t.ID == 0 {
+		return errors.New("请选择要分享的用户或者群")
+	}
+	return nil
+}
+
+// StickerMsg 表情 客户端只传表情包和表情的id 标题和地址服务端从表情目录里面查出来填上
+type StickerMsg struct {
+	PackID    uint   `json:"packID"`
+	StickerID uint   `json:"stickerID"`
+	Title     string `json:"title"`
+	Src       string `json:"src"`
+}
+
+func (t StickerMsg) Validate() error {
+	if t.PackID == 0 || t.StickerID == 0 {
+		return errors.New("请选择表情")
+	}
+	return nil
+}
+
+// PollMsg 投票 选项按下标投 投票结果单独查
+type PollMsg struct {
+	Question string     `json:"question"`
+	Options  []string   `json:"options"`
+	Multi    bool       `json:"multi"`    // 是否多选
+	Deadline *time.Time `json:"deadline"` // 截止时间 不传就是不截止
+}
+
+func (t PollMsg) Validate() error {
+	if t.Question == "" {
+		return errors.New("请输入投票的问题")
+	}
+	if len([]rune(t.Question)) > 128 {
+		return errors.New("投票的问题太长了")
+	}
+	if len(t.Options) < 2 || len(t.Options) > 10 {
+		return errors.New("投票的选项要有2到10个")
+	}
+	optionMap := map[string]bool{}
+	for _, option := range t.Options {
+		if option == "" || len([]rune(option)) > 64 {
+			return errors.New("投票的选项不能为空 也不能超过64个字")
+		}
+		if optionMap[option] {
+			return fmt.Errorf("投票的选项重复了 %s", option)
+		}
+		optionMap[option] = true
+	}
+	if t.Deadline != nil && t.Deadline.Before(time.Now()) {
+		return errors.New("截止时间不能早于现在")
+	}
+	return nil
+}
+
+// Closed 投票是否已经截止
+func (t PollMsg) Closed() bool {
+	return t.Deadline != nil && t.Deadline.Before(time.Now())
+}
+
+// MaxMergeForward 合并转发最多多少条消息
+const MaxMergeForward = 100
+
+// MergeForwardMsg 合并转发 按原来的顺序存一份消息的副本 原消息撤回了这里也不变
+type MergeForwardMsg struct {
+	Title string             `json:"title"` // 群聊的聊天记录 张三和李四的聊天记录
+	List  []MergeForwardItem `json:"list"`
+}
+
+type MergeForwardItem struct {
+	SendUserID uint   `json:"sendUserID"`
+	Nickname   string `json:"nickname"` // 发送时候的昵称 群里是群昵称
+	Avatar     string `json:"avatar"`
+	Msg        Msg    `json:"msg"`
+	CreatedAt  string `json:"createdAt"` // 原消息的发送时间
+}
+
+func (t MergeForwardMsg) Validate() error {
+	if t.Title == "" || len([]rune(t.Title)) > 64 {
+		return errors.New("聊天记录的标题错误")
+	}
+	if len(t.List) == 0 || len(t.List) > MaxMergeForward {
+		return fmt.Errorf("聊天记录要有1到%d条消息", MaxMergeForward)
+	}
+	return nil
+}
+
+// Clone 深拷贝一份 转发的时候用 文件还是原来的地址 不用重新上传
+func (msg Msg) Clone() (Msg, error) {
+	var res Msg
+	byteData, err := json.Marshal(msg)
+	if err != nil {
+		return res, err
+	}
+	err = json.Unmarshal(byteData, &res)
+	return res, err
+}
+
+// FileSrcList 消息里面引用的文件地址 只看图片 视频 文件 语音 图文的图片 合并转发里面的也算
+// 文本里面写的地址不算
+func (msg Msg) FileSrcList() (list []string) {
+	switch msg.Type {
+	case ImageMsgType:
+		if msg.ImageMsg != nil {
+			list = append(list, msg.ImageMsg.Src)
+		}
+	case VideoMsgType:
+		if msg.VideoMsg != nil {
+			list = append(list, msg.VideoMsg.Src)
+		}
+	case FileMsgType:
+		if msg.FileMsg != nil {
+			list = append(list, msg.FileMsg.Src)
+		}
+	case VoiceMsgType:
+		if msg.VoiceMsg != nil {
+			list = append(list, msg.VoiceMsg.Src)
+		}
+	case ImageTextMsgType:
+		if msg.ImageTextMsg != nil {
+			list = append(list, safe.ImgSrcList(msg.ImageTextMsg.Content)...)
+		}
+	case MergeForwardMsgType:
+		if msg.MergeForwardMsg != nil {
+			for _, item := range msg.MergeForwardMsg.List {
+				list = append(list, item.Msg.FileSrcList()...)
+			}
+		}
+	}
+	return
+}
+
+// FileHashList 消息里面引用的文件服务的文件 地址是 /uploads/ab/abcdef... 最后一段就是hash
+// 地址前面可能带了域名和网关的前缀 不是文件服务的地址不算 重复的只算一次
+func (msg Msg) FileHashList() (list []string) {
+	for _, src := range msg.FileSrcList() {
+		u, err := url.Parse(src)
+		if err != nil || !strings.Contains(u.Path, "/uploads/") {
+			continue
+		}
+		hash := path.Base(u.Path)
+		if !isFileHash(hash) || slices.Contains(list, hash) {
+			continue
+		}
+		list = append(list, hash)
+	}
+	return
+}
+
+// isFileHash 小写的sha256
+func isFileHash(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
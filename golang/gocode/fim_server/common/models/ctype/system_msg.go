@@ -19,3 +19,18 @@ func (c SystemMsg) Value() (driver.Value, error) {
 	b, err := json.Marshal(c)
 	return string(b), err
 }
+
+// Preview 被系统拦截的消息的预览
+func (c SystemMsg) Preview() string {
+	switch c.Type {
+	case 1:
+		return "[系统消息]- 该消息涉黄，已被系统拦截"
+	case 2:
+		return "[系统消息]- 该消息涉恐，已被系统拦截"
+	case 3:
+		return "[系统消息]- 该消息涉政，已被系统拦截"
+	case 4:
+		return "[系统消息]- 该消息不正当言论，已被系统拦截"
+	}
+	return "[系统消息]"
+}
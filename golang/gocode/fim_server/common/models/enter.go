@@ -1,14 +1,43 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TimeFormat 入库的时间格式 字符串比较和时间比较的结果一样
+const TimeFormat = "2006-01-02 15:04:05"
+
 type Model struct {
 	ID        uint   `json:"id"`
 	CreatedAt string `json:"createdAt"`
 	UpdatedAt string `json:"updatedAt"`
 }
 
+// BeforeCreate 时间是字符串 gorm不会自动填
+func (m *Model) BeforeCreate(tx *gorm.DB) error {
+	now := time.Now().Format(TimeFormat)
+	if m.CreatedAt == "" {
+		m.CreatedAt = now
+	}
+	if m.UpdatedAt == "" {
+		m.UpdatedAt = now
+	}
+	return nil
+}
+
+// BeforeUpdate 更新的时候刷新更新时间
+func (m *Model) BeforeUpdate(tx *gorm.DB) error {
+	tx.Statement.SetColumn("updated_at", time.Now().Format(TimeFormat))
+	return nil
+}
+
 type PageInfo struct {
 	Page  int    `form:"page"`
 	Limit int    `form:"limit"`
 	Sort  string `form:"sort"`
 	Key   string `form:"key"`
+	// Filter 筛选条件 created_at>=2024-01-01,role=2,nickname~feng 字段要在接口定义的FilterSchema里面
+	Filter string `form:"filter"`
 }
@@ -0,0 +1,45 @@
+package moderation
+
+import (
+	"fim_server/common/models/ctype"
+	"fim_server/fim_admin/admin_models"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+// Audit 记录一条审核记录 放行的消息不记录
+func Audit(db *gorm.DB, source string, sendUserID uint, targetID uint, msgType ctype.MsgType, res Result) {
+	if res.Action == Pass {
+		return
+	}
+	var words []string
+	var t int8
+	for _, word := range res.Words {
+		words = append(words, word.Word)
+		if t == 0 || word.Type < t {
+			t = word.Type
+		}
+	}
+	if res.SystemMsg != nil {
+		t = res.SystemMsg.Type
+	}
+	wordStr := strings.Join(words, ",")
+	if len([]rune(wordStr)) > 64 {
+		wordStr = string([]rune(wordStr)[:64])
+	}
+	err := db.Create(&admin_models.ModerationLogModel{
+		Source:     source,
+		SendUserID: sendUserID,
+		TargetID:   targetID,
+		MsgType:    msgType,
+		Content:    res.Content,
+		Words:      wordStr,
+		Type:       t,
+		Action:     int8(res.Action),
+	}).Error
+	if err != nil {
+		logx.Error(err)
+	}
+}
@@ -0,0 +1,260 @@
+package moderation
+
+import (
+	"bufio"
+	"fim_server/common/models/ctype"
+	"fim_server/utils/acmatch"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type Action int8
+
+const (
+	Pass  Action = iota // 放行
+	Mask                // 打码之后发送
+	Block               // 拦截
+)
+
+// Word 词表里的一个词
+type Word struct {
+	Word   string
+	Type   int8 // 违规类型 1 涉黄 2 涉恐 3 涉政 4 不正当言论
+	Action Action
+}
+
+// Result 审核结果
+type Result struct {
+	Action    Action
+	SystemMsg *ctype.SystemMsg // 拦截的时候才有
+	Words     []Word           // 命中的词
+	Content   string           // 命中时的原始内容 用于审核记录
+}
+
+type wordList struct {
+	matcher *acmatch.Matcher
+	words   []Word
+}
+
+// Moderator 敏感词审核 词表文件修改之后自动重新加载
+type Moderator struct {
+	path    string
+	modTime time.Time
+	list    atomic.Pointer[wordList]
+}
+
+// NewModerator 加载词表 interval大于0的时候定时检查词表文件有没有修改
+func NewModerator(path string, interval time.Duration) *Moderator {
+	m := &Moderator{path: path}
+	m.list.Store(&wordList{matcher: acmatch.New(nil)})
+	if err := m.Reload(); err != nil {
+		logx.Errorf("敏感词词表加载失败 %s", err.Error())
+	}
+	if interval > 0 {
+		go m.watch(interval)
+	}
+	return m
+}
+
+// NewModeratorWithWords 直接用词构建 不读文件
+func NewModeratorWithWords(words []Word) *Moderator {
+	m := &Moderator{}
+	m.store(words)
+	return m
+}
+
+func (m *Moderator) watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		info, err := os.Stat(m.path)
+		if err != nil || info.ModTime().Equal(m.modTime) {
+			continue
+		}
+		if err = m.Reload(); err != nil {
+			logx.Errorf("敏感词词表加载失败 %s", err.Error())
+			continue
+		}
+		logx.Infof("敏感词词表重新加载 %d个词", len(m.list.Load().words))
+	}
+}
+
+// Reload 重新读取词表文件
+func (m *Moderator) Reload() error {
+	file, err := os.Open(m.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	var words []Word
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 3 {
+			return fmt.Errorf("第%d行格式错误", line)
+		}
+		t, err := strconv.Atoi(fields[0])
+		if err != nil || t < 1 || t > 4 {
+			return fmt.Errorf("第%d行违规类型错误", line)
+		}
+		var action Action
+		switch fields[1] {
+		case "mask":
+			action = Mask
+		case "block":
+			action = Block
+		default:
+			return fmt.Errorf("第%d行处理方式错误", line)
+		}
+		words = append(words, Word{
+			Word:   strings.Join(fields[2:], " "),
+			Type:   int8(t),
+			Action: action,
+		})
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	m.store(words)
+	m.modTime = info.ModTime()
+	return nil
+}
+
+func (m *Moderator) store(words []Word) {
+	var list []string
+	for _, word := range words {
+		list = append(list, word.Word)
+	}
+	m.list.Store(&wordList{matcher: acmatch.New(list), words: words})
+}
+
+// Check 审核消息 打码的情况会直接改msg里面的内容
+func (m *Moderator) Check(msg *ctype.Msg) (res Result) {
+	list := m.list.Load()
+	seen := map[int]bool{}
+	for _, content := range contentList(msg) {
+		text := []rune(*content.text)
+		matchText := text
+		if content.html {
+			matchText = stripTags(text)
+		}
+		var masked bool
+		matchList := list.matcher.Find(matchText)
+		if len(matchList) > 0 {
+			res.Content += *content.text
+		}
+		for _, match := range matchList {
+			word := list.words[match.Index]
+			if !seen[match.Index] {
+				seen[match.Index] = true
+				res.Words = append(res.Words, word)
+			}
+			if word.Action > res.Action {
+				res.Action = word.Action
+			}
+			if word.Action == Block {
+				// 违规类型取最严重的 数字越小越严重
+				if res.SystemMsg == nil || word.Type < res.SystemMsg.Type {
+					res.SystemMsg = &ctype.SystemMsg{Type: word.Type}
+				}
+				continue
+			}
+			for i := match.Start; i < match.End; i++ {
+				text[i] = '*'
+			}
+			masked = true
+		}
+		if masked && res.Action == Mask {
+			*content.text = string(text)
+		}
+	}
+	return
+}
+
+type content struct {
+	text *string
+	html bool
+}
+
+// contentList 消息里面需要审核的文本
+func contentList(msg *ctype.Msg) (list []content) {
+	switch msg.Type {
+	case ctype.TextMsgType:
+		if msg.TextMsg != nil {
+			list = append(list, content{text: &msg.TextMsg.Content})
+		}
+	case ctype.ReplyMsgType:
+		if msg.ReplyMsg != nil {
+			list = append(list, content{text: &msg.ReplyMsg.Content})
+		}
+	case ctype.QuoteMsgType:
+		if msg.QuoteMsg != nil {
+			list = append(list, content{text: &msg.QuoteMsg.Content})
+		}
+	case ctype.AtMsgType:
+		if msg.AtMsg != nil {
+			list = append(list, content{text: &msg.AtMsg.Content})
+		}
+	case ctype.ImageTextMsgType:
+		if msg.ImageTextMsg != nil {
+			list = append(list, content{text: &msg.ImageTextMsg.Content, html: true})
+		}
+	case ctype.EncryptedMsgType:
+		// 端到端加密的消息服务端看不到内容 不审核
+	case ctype.LocationMsgType:
+		if msg.LocationMsg != nil {
+			list = append(list, content{text: &msg.LocationMsg.Title}, content{text: &msg.LocationMsg.Address})
+		}
+	case ctype.PollMsgType:
+		if msg.PollMsg != nil {
+			list = append(list, content{text: &msg.PollMsg.Question})
+			for i := range msg.PollMsg.Options {
+				list = append(list, content{text: &msg.PollMsg.Options[i]})
+			}
+		}
+	case ctype.MergeForwardMsgType:
+		if msg.MergeForwardMsg != nil {
+			list = append(list, content{text: &msg.MergeForwardMsg.Title})
+			for i := range msg.MergeForwardMsg.List {
+				list = append(list, contentList(&msg.MergeForwardMsg.List[i].Msg)...)
+			}
+		}
+	}
+	return
+}
+
+// stripTags 把标签里面的字符换成0 下标和原文一一对应，词也不会跨标签匹配
+func stripTags(text []rune) []rune {
+	out := make([]rune, len(text))
+	inTag := false
+	for i, r := range text {
+		switch {
+		case r == '<':
+			inTag = true
+			out[i] = 0
+		case r == '>' && inTag:
+			inTag = false
+			out[i] = 0
+		case inTag:
+			out[i] = 0
+		default:
+			out[i] = r
+		}
+	}
+	return out
+}
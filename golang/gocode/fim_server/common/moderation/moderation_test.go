@@ -0,0 +1,107 @@
+package moderation
+
+import (
+	"fim_server/common/models/ctype"
+	"testing"
+)
+
+var words = []Word{
+	{Word: "裸聊", Type: 1, Action: Block},
+	{Word: "炸弹", Type: 2, Action: Block},
+	{Word: "傻逼", Type: 4, Action: Mask},
+}
+
+func TestCheckMask(t *testing.T) {
+	m := NewModeratorWithWords(words)
+	msg := ctype.Msg{Type: ctype.TextMsgType, TextMsg: &ctype.TextMsg{Content: "你是傻逼吗"}}
+	res := m.Check(&msg)
+	if res.Action != Mask || res.SystemMsg != nil {
+		t.Fatalf("期望打码 %+v", res)
+	}
+	if msg.TextMsg.Content != "你是**吗" {
+		t.Errorf("打码错误 %s", msg.TextMsg.Content)
+	}
+	if res.Content != "你是傻逼吗" {
+		t.Errorf("原始内容错误 %s", res.Content)
+	}
+}
+
+func TestCheckBlock(t *testing.T) {
+	m := NewModeratorWithWords(words)
+	msg := ctype.Msg{Type: ctype.AtMsgType, AtMsg: &ctype.AtMsg{Content: "傻逼 一起做炸弹 裸聊"}}
+	res := m.Check(&msg)
+	if res.Action != Block || res.SystemMsg == nil || res.SystemMsg.Type != 1 {
+		t.Fatalf("期望拦截 涉黄 %+v", res)
+	}
+	if msg.AtMsg.Content != "傻逼 一起做炸弹 裸聊" {
+		t.Errorf("拦截的消息不应该打码 %s", msg.AtMsg.Content)
+	}
+}
+
+func TestCheckImageText(t *testing.T) {
+	m := NewModeratorWithWords(words)
+	msg := ctype.Msg{Type: ctype.ImageTextMsgType, ImageTextMsg: &ctype.ImageTextMsg{Content: `<img src="傻逼.png"/>傻<br/>逼 傻逼`}}
+	res := m.Check(&msg)
+	if res.Action != Mask {
+		t.Fatalf("期望打码 %+v", res)
+	}
+	if msg.ImageTextMsg.Content != `<img src="傻逼.png"/>傻<br/>逼 **` {
+		t.Errorf("标签里面的内容不应该打码 %s", msg.ImageTextMsg.Content)
+	}
+}
+
+func TestCheckPass(t *testing.T) {
+	m := NewModeratorWithWords(words)
+	msg := ctype.Msg{Type: ctype.ImageMsgType, ImageMsg: &ctype.ImageMsg{Title: "傻逼", Src: "/a.png"}}
+	if res := m.Check(&msg); res.Action != Pass {
+		t.Errorf("图片消息不审核 %+v", res)
+	}
+}
+
+func TestCheckEncrypted(t *testing.T) {
+	m := NewModeratorWithWords(words)
+	msg := ctype.Msg{Type: ctype.EncryptedMsgType, EncryptedMsg: &ctype.EncryptedMsg{
+		SenderDeviceID: "phone",
+		Envelopes:      []ctype.EncryptedEnvelope{{DeviceID: "pc", Type: 2, Body: "裸聊"}},
+	}}
+	res := m.Check(&msg)
+	if res.Action != Pass {
+		t.Fatalf("加密消息不审核 %+v", res)
+	}
+	if msg.EncryptedMsg.Envelopes[0].Body != "裸聊" {
+		t.Errorf("密文不能改 %s", msg.EncryptedMsg.Envelopes[0].Body)
+	}
+}
+
+func TestCheckPoll(t *testing.T) {
+	m := NewModeratorWithWords(words)
+	msg := ctype.Msg{Type: ctype.PollMsgType, PollMsg: &ctype.PollMsg{
+		Question: "晚上吃什么",
+		Options:  []string{"火锅", "傻逼才选这个"},
+	}}
+	res := m.Check(&msg)
+	if res.Action != Mask {
+		t.Fatalf("期望打码 %+v", res)
+	}
+	if msg.PollMsg.Options[1] != "**才选这个" {
+		t.Errorf("选项打码错误 %s", msg.PollMsg.Options[1])
+	}
+}
+
+func TestCheckMergeForward(t *testing.T) {
+	m := NewModeratorWithWords(words)
+	msg := ctype.Msg{Type: ctype.MergeForwardMsgType, MergeForwardMsg: &ctype.MergeForwardMsg{
+		Title: "群聊的聊天记录",
+		List: []ctype.MergeForwardItem{
+			{Msg: ctype.Msg{Type: ctype.TextMsgType, TextMsg: &ctype.TextMsg{Content: "你好"}}},
+			{Msg: ctype.Msg{Type: ctype.TextMsgType, TextMsg: &ctype.TextMsg{Content: "傻逼"}}},
+		},
+	}}
+	res := m.Check(&msg)
+	if res.Action != Mask {
+		t.Fatalf("期望打码 %+v", res)
+	}
+	if msg.MergeForwardMsg.List[1].Msg.TextMsg.Content != "**" {
+		t.Errorf("聊天记录里面的消息也要打码 %s", msg.MergeForwardMsg.List[1].Msg.TextMsg.Content)
+	}
+}
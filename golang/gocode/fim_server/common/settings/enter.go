@@ -0,0 +1,133 @@
+package settings
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fim_server/core"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Key 系统设置在redis缓存和etcd里面的key
+const Key = "settings_info"
+
+// Info 系统设置 数据库是准的 改了之后写到etcd 其他服务监听etcd拿到最新的
+type Info struct {
+	Site          Site             `json:"site"`
+	RegisterOpen  bool             `json:"registerOpen"`  // 是否开放注册 关了之后第三方登录也不能注册新用户
+	FileLimit     map[string]int64 `json:"fileLimit"`     // 每类文件的大小上限 单位 字节 key是 image file video voice 没有的按服务自己的配置
+	OpenLoginList []OpenLogin      `json:"openLoginList"` // 开启的第三方登录
+	Moderation    Moderation       `json:"moderation"`
+}
+
+type Site struct {
+	Name     string `json:"name"`
+	Logo     string `json:"logo"`
+	Abstract string `json:"abstract,optional"`
+}
+
+type OpenLogin struct {
+	Flag string `json:"flag"` // 登录方式 qq
+	Name string `json:"name"`
+	Icon string `json:"icon"`
+	Href string `json:"href"` // 跳转地址
+}
+
+// Moderation 消息审核的开关
+type Moderation struct {
+	Chat  bool `json:"chat"`  // 私聊消息
+	Group bool `json:"group"` // 群聊消息
+}
+
+// Scan 取出来的时候的数据
+func (c *Info) Scan(val interface{}) error {
+	return json.Unmarshal(val.([]byte), c)
+}
+
+// Value 入库的数据
+func (c Info) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+// OpenLoginEnabled 某个第三方登录有没有开启
+func (c *Info) OpenLoginEnabled(flag string) bool {
+	for _, item := range c.OpenLoginList {
+		if item.Flag == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish 把最新的设置写到etcd 监听的服务会收到通知
+func Publish(etcdAddr string, info Info) error {
+	byteData, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	client := core.InitEtcd(etcdAddr)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = client.Put(ctx, Key, string(byteData))
+	return err
+}
+
+// Watcher 监听etcd里面的系统设置 改了之后不用重启服务
+type Watcher struct {
+	info atomic.Pointer[Info]
+}
+
+// NewWatcher 先读一次当前的设置 然后在后台监听变化
+func NewWatcher(etcdAddr string) *Watcher {
+	w := &Watcher{}
+	client := core.InitEtcd(etcdAddr)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	res, err := client.Get(ctx, Key)
+	cancel()
+	if err != nil {
+		logx.Errorf("系统设置读取失败 %s", err.Error())
+	} else if len(res.Kvs) > 0 {
+		w.apply(res.Kvs[0].Value)
+	}
+	go w.watch(client)
+	return w
+}
+
+func (w *Watcher) watch(client *clientv3.Client) {
+	for {
+		for res := range client.Watch(context.Background(), Key) {
+			for _, event := range res.Events {
+				if event.Type == clientv3.EventTypePut {
+					w.apply(event.Kv.Value)
+					logx.Info("系统设置已更新")
+				}
+			}
+		}
+		// watch断开之后重新监听
+		time.Sleep(time.Second)
+	}
+}
+
+func (w *Watcher) apply(byteData []byte) {
+	var info Info
+	err := json.Unmarshal(byteData, &info)
+	if err != nil {
+		logx.Errorf("系统设置解析失败 %s", err.Error())
+		return
+	}
+	w.info.Store(&info)
+}
+
+// Load 当前的设置 settings服务还没有发布过就是nil 调用方用自己的配置
+func (w *Watcher) Load() *Info {
+	if w == nil {
+		return nil
+	}
+	return w.info.Load()
+}
@@ -0,0 +1,53 @@
+package settings
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWatcherApply(t *testing.T) {
+	w := &Watcher{}
+	if w.Load() != nil {
+		t.Fatal("没有发布过应该是nil")
+	}
+	info := Info{
+		Site:          Site{Name: "fim"},
+		RegisterOpen:  true,
+		FileLimit:     map[string]int64{"file": 100, "video": 500},
+		OpenLoginList: []OpenLogin{{Flag: "qq", Name: "QQ登录"}},
+	}
+	byteData, _ := json.Marshal(info)
+	w.apply(byteData)
+	got := w.Load()
+	if got == nil || got.Site.Name != "fim" || !got.RegisterOpen {
+		t.Fatalf("设置解析错误 %+v", got)
+	}
+	if got.FileLimit["video"] != 500 {
+		t.Errorf("文件限制错误 %v", got.FileLimit)
+	}
+	if !got.OpenLoginEnabled("qq") || got.OpenLoginEnabled("wechat") {
+		t.Error("第三方登录开关错误")
+	}
+
+	// 解析失败保留之前的设置
+	w.apply([]byte("{"))
+	if w.Load() != got {
+		t.Error("解析失败不应该覆盖之前的设置")
+	}
+}
+
+func TestInfoValue(t *testing.T) {
+	info := Info{Site: Site{Name: "fim"}, Moderation: Moderation{Chat: true}}
+	val, err := info.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scanned Info
+	err = scanned.Scan([]byte(val.(string)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scanned.Site.Name != "fim" || !scanned.Moderation.Chat {
+		t.Errorf("入库出库不一致 %+v", scanned)
+	}
+}
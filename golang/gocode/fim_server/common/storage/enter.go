@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFound     = errors.New("文件不存在")
+	ErrNotSupported = errors.New("存储不支持该操作")
+)
+
+// Object 存储里面的一个文件
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+	ETag        string
+}
+
+// Storage 文件存储 key用/分隔 比如 ab/abcdef...
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 本地存储返回的是*os.File 可以Seek
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	Stat(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+	// List 前缀下面所有的文件 按key排好序
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// PresignedURL 带签名的临时下载地址 本地存储返回ErrNotSupported 由服务自己输出文件
+	PresignedURL(ctx context.Context, key string, expire time.Duration) (string, error)
+}
+
+// Conf 存储的配置 Type选择用哪个驱动
+type Conf struct {
+	Type  string // local s3
+	Local LocalConf
+	S3    S3Conf
+}
+
+// New 根据配置创建存储
+func New(c Conf) (Storage, error) {
+	switch c.Type {
+	case "local":
+		return NewLocal(c.Local.Dir)
+	case "s3":
+		return NewS3(c.S3)
+	}
+	return nil, fmt.Errorf("不支持的存储类型 %s", c.Type)
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type LocalConf struct {
+	Dir string // 保存的目录 相对fim_server
+}
+
+// Local 本地文件系统 只能跑一个实例
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) (*Local, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &Local{dir: dir}, nil
+}
+
+// path key先按绝对路径清理一遍 ../ 跳不出存储目录
+func (l *Local) path(key string) (string, error) {
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if key == "" || key == "." {
+		return "", ErrNotFound
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	filePath, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+	// 先写临时文件再改名 不会读到写了一半的文件
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, r)
+	tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	filePath, err := l.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		file.Close()
+		return nil, nil, ErrNotFound
+	}
+	return file, localObject(key, info), nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (*Object, error) {
+	filePath, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil || info.IsDir() {
+		return nil, ErrNotFound
+	}
+	return localObject(key, info), nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	filePath, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List 从前缀所在的目录往下找 写了一半的临时文件不算
+func (l *Local) List(ctx context.Context, prefix string) ([]Object, error) {
+	root := l.dir
+	if dir := path.Dir(prefix + "x"); dir != "." {
+		var err error
+		root, err = l.path(dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var list []Object
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.dir, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// 找的时候被删了
+			return nil
+		}
+		list = append(list, *localObject(key, info))
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+	return list, err
+}
+
+func (l *Local) PresignedURL(ctx context.Context, key string, expire time.Duration) (string, error) {
+	return "", ErrNotSupported
+}
+
+// localObject 本地文件没有保存类型 按后缀猜
+func localObject(key string, info os.FileInfo) *Object {
+	return &Object{
+		Key:         key,
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		ModTime:     info.ModTime(),
+	}
+}
@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type S3Conf struct {
+	Endpoint       string // http://127.0.0.1:9000
+	PublicEndpoint string // 给客户端的预签名地址用的域名 为空就用Endpoint
+	Region         string
+	Bucket         string
+	AccessKey      string
+	SecretKey      string
+	PathStyle      bool // minio这类用 endpoint/bucket/key 阿里云aws用 bucket.endpoint/key
+}
+
+// S3 兼容s3协议的对象存储 aws minio 阿里云oss 腾讯云cos都可以
+type S3 struct {
+	conf     S3Conf
+	endpoint *url.URL
+	public   *url.URL
+	signer   signer
+	client   *http.Client
+}
+
+func NewS3(c S3Conf) (*S3, error) {
+	endpoint, err := url.Parse(c.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3 endpoint错误 %s", c.Endpoint)
+	}
+	public := endpoint
+	if c.PublicEndpoint != "" {
+		public, err = url.Parse(c.PublicEndpoint)
+		if err != nil || public.Host == "" {
+			return nil, fmt.Errorf("s3 public endpoint错误 %s", c.PublicEndpoint)
+		}
+	}
+	if c.Bucket == "" {
+		return nil, errors.New("s3 bucket不能为空")
+	}
+	return &S3{
+		conf:     c,
+		endpoint: endpoint,
+		public:   public,
+		signer: signer{
+			accessKey: c.AccessKey,
+			secretKey: c.SecretKey,
+			region:    c.Region,
+			service:   "s3",
+		},
+		client: &http.Client{},
+	}, nil
+}
+
+// objectURL 文件的地址
+func (s *S3) objectURL(base *url.URL, key string) *url.URL {
+	u := *base
+	key = strings.TrimPrefix(key, "/")
+	if s.conf.PathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.conf.Bucket + "/" + key
+	} else {
+		u.Host = s.conf.Bucket + "." + u.Host
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	}
+	return &u
+}
+
+func (s *S3) do(ctx context.Context, method, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	return s.request(ctx, method, s.objectURL(s.endpoint, key), body, size, header)
+}
+
+func (s *S3) request(ctx context.Context, method string, u *url.URL, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	payloadHash := emptyPayload
+	if body != nil {
+		// 大文件不算hash 用UNSIGNED-PAYLOAD
+		payloadHash = unsignedPayload
+	}
+	s.signer.sign(req, payloadHash, time.Now())
+	return s.client.Do(req)
+}
+
+// s3Error 读出s3返回的错误信息
+func s3Error(res *http.Response) error {
+	if res.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	byteData, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("s3请求失败 %d %s", res.StatusCode, string(byteData))
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	res, err := s.do(ctx, http.MethodPut, key, r, size, header)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return s3Error(res)
+	}
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	res, err := s.do(ctx, http.MethodGet, key, nil, 0, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, nil, s3Error(res)
+	}
+	return res.Body, s3Object(key, res), nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*Object, error) {
+	res, err := s.do(ctx, http.MethodHead, key, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, s3Error(res)
+	}
+	return s3Object(key, res), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	res, err := s.do(ctx, http.MethodDelete, key, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	// 删不存在的文件s3也是返回204
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return s3Error(res)
+	}
+	return nil
+}
+
+// listResult ListObjectsV2返回的内容 只用到这几个字段
+type listResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+		ETag         string
+	}
+}
+
+// List 用ListObjectsV2 一次最多返回1000个 没返回完的接着往后取
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	var list []Object
+	token := ""
+	for {
+		u := s.objectURL(s.endpoint, "")
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		u.RawQuery = canonicalQuery(query)
+		res, err := s.request(ctx, http.MethodGet, u, nil, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK {
+			err = s3Error(res)
+			res.Body.Close()
+			return nil, err
+		}
+		var result listResult
+		err = xml.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range result.Contents {
+			list = append(list, Object{
+				Key:     item.Key,
+				Size:    item.Size,
+				ModTime: item.LastModified,
+				ETag:    strings.Trim(item.ETag, `"`),
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return list, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3) PresignedURL(ctx context.Context, key string, expire time.Duration) (string, error) {
+	return s.signer.presign(http.MethodGet, s.objectURL(s.public, key), expire, time.Now()), nil
+}
+
+func s3Object(key string, res *http.Response) *Object {
+	modTime, _ := http.ParseTime(res.Header.Get("Last-Modified"))
+	return &Object{
+		Key:         key,
+		Size:        res.ContentLength,
+		ContentType: res.Header.Get("Content-Type"),
+		ModTime:     modTime,
+		ETag:        strings.Trim(res.Header.Get("ETag"), `"`),
+	}
+}
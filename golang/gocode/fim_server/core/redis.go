@@ -18,7 +18,6 @@ func InitRedis(addr, pwd string, db int) (client *redis.Client) {
 	_, err := client.Ping().Result()
 	if err != nil {
 		panic(err)
-		return
 	}
 	return
 }
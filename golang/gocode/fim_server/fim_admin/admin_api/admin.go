@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fim_server/common/etcd"
+	"flag"
+	"fmt"
+
+	"fim_server/fim_admin/admin_api/internal/config"
+	"fim_server/fim_admin/admin_api/internal/handler"
+	"fim_server/fim_admin/admin_api/internal/svc"
+
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/rest"
+)
+
+var configFile = flag.String("f", "fim_admin/admin_api/etc/admin.yaml", "the config file")
+
+func main() {
+	flag.Parse()
+
+	var c config.Config
+	conf.MustLoad(*configFile, &c)
+
+	server := rest.MustNewServer(c.RestConf)
+	defer server.Stop()
+
+	ctx := svc.NewServiceContext(c)
+	handler.RegisterHandlers(server, ctx)
+
+	etcd.DeliveryAddress(c.Etcd, c.Name+"_api", fmt.Sprintf("%s:%d", c.Host, c.Port))
+
+	fmt.Printf("Starting server at %s:%d...\n", c.Host, c.Port)
+	server.Start()
+}
@@ -0,0 +1,20 @@
+package config
+
+import "github.com/zeromicro/go-zero/rest"
+
+type Config struct {
+	rest.RestConf
+	Mysql struct {
+		DataSource string
+	}
+	Redis struct {
+		Addr string
+		Pwd  string
+		DB   int
+	}
+	Auth struct {
+		AccessExpire int // 和auth服务的token有效期保持一致 吊销记录要保留这么久
+	}
+	BcryptCost int // 重置密码时hash的cost
+	Etcd       string
+}
@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"context"
+	"fim_server/common/list_query"
+	"fim_server/common/models"
+	"fim_server/fim_admin/admin_models"
+
+	"fim_server/fim_admin/admin_api/internal/svc"
+	"fim_server/fim_admin/admin_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ModerationLogListLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewModerationLogListLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ModerationLogListLogic {
+	return &ModerationLogListLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ModerationLogListLogic) ModerationLogList(req *types.ModerationLogListRequest) (resp *types.ModerationLogListResponse, err error) {
+	query := l.svcCtx.DB.Where("")
+	if req.Source != "" {
+		query.Where("source = ?", req.Source)
+	}
+	if req.Status != -1 {
+		query.Where("status = ?", req.Status)
+	}
+	logs, count, err := list_query.ListQuery(l.svcCtx.DB, admin_models.ModerationLogModel{}, list_query.Option{
+		PageInfo: models.PageInfo{
+			Page:   req.Page,
+			Limit:  req.Limit,
+			Sort:   req.Sort,
+			Filter: req.Filter,
+		},
+		Filters: list_query.FilterSchema{
+			"created_at":   {Type: list_query.TimeField},
+			"send_user_id": {Type: list_query.IntField, Ops: []string{"="}},
+			"target_id":    {Type: list_query.IntField, Ops: []string{"="}},
+			"msg_type":     {Type: list_query.IntField, Ops: []string{"="}},
+			"type":         {Type: list_query.IntField, Ops: []string{"="}},
+			"action":       {Type: list_query.IntField, Ops: []string{"="}},
+		},
+		SortColumns: []string{"id", "created_at"},
+		DefaultSort: "id desc",
+		Where:       query,
+	})
+	if err != nil {
+		logx.Error(err)
+		return nil, err
+	}
+
+	resp = &types.ModerationLogListResponse{Count: count, List: make([]types.ModerationLogInfo, 0)}
+	for _, log := range logs {
+		resp.List = append(resp.List, types.ModerationLogInfo{
+			ID:         log.ID,
+			CreatedAt:  log.CreatedAt,
+			Source:     log.Source,
+			SendUserID: log.SendUserID,
+			TargetID:   log.TargetID,
+			MsgType:    int8(log.MsgType),
+			Content:    log.Content,
+			Words:      log.Words,
+			Type:       log.Type,
+			Action:     log.Action,
+			Status:     log.Status,
+			Remark:     log.Remark,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,46 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_admin/admin_models"
+
+	"fim_server/fim_admin/admin_api/internal/svc"
+	"fim_server/fim_admin/admin_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ModerationLogReviewLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewModerationLogReviewLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ModerationLogReviewLogic {
+	return &ModerationLogReviewLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ModerationLogReviewLogic) ModerationLogReview(req *types.ModerationLogReviewRequest) (resp *types.ModerationLogReviewResponse, err error) {
+	if req.Status != 1 && req.Status != 2 {
+		return nil, errors.New("审核状态错误")
+	}
+	var log admin_models.ModerationLogModel
+	err = l.svcCtx.DB.Take(&log, req.ID).Error
+	if err != nil {
+		return nil, errors.New("审核记录不存在")
+	}
+	err = l.svcCtx.DB.Model(&log).Updates(map[string]any{
+		"status": req.Status,
+		"remark": req.Remark,
+	}).Error
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("审核失败")
+	}
+	return
+}
@@ -0,0 +1,63 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_user/user_models"
+	"fim_server/utils/jwts"
+	"github.com/go-redis/redis"
+	"time"
+
+	"fim_server/fim_admin/admin_api/internal/svc"
+	"fim_server/fim_admin/admin_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type UserBanLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUserBanLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UserBanLogic {
+	return &UserBanLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UserBanLogic) UserBan(req *types.UserBanRequest) (resp *types.UserBanResponse, err error) {
+	if req.UserID == req.AdminID {
+		return nil, errors.New("不能封禁自己")
+	}
+
+	var user user_models.UserModel
+	err = l.svcCtx.DB.Take(&user, req.UserID).Error
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+
+	err = l.svcCtx.DB.Model(&user).Updates(map[string]any{
+		"ban":        req.Ban,
+		"ban_reason": req.Reason,
+	}).Error
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("封禁失败")
+	}
+
+	if req.Ban {
+		// 封号之后已经登录的token也要失效
+		revokeSession(l.svcCtx.Redis, l.svcCtx.Config.Auth.AccessExpire, req.UserID)
+		// 已经连着的websocket不走token校验 通知聊天服务断开
+		l.svcCtx.Redis.Publish(user_models.KickChannel, req.UserID)
+	}
+	return
+}
+
+// revokeSession 吊销用户当前所有的token 记录保留到最后一个token过期
+func revokeSession(client *redis.Client, accessExpire int, userID uint) {
+	client.Set(jwts.RevokeKey(userID), time.Now().Unix(), time.Duration(accessExpire)*time.Hour)
+}
@@ -0,0 +1,75 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_user/user_models"
+	"fim_server/utils/maps"
+	"time"
+
+	"fim_server/fim_admin/admin_api/internal/svc"
+	"fim_server/fim_admin/admin_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type UserCurtailLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUserCurtailLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UserCurtailLogic {
+	return &UserCurtailLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UserCurtailLogic) UserCurtail(req *types.UserCurtailRequest) (resp *types.UserCurtailResponse, err error) {
+	userConfMaps := maps.RefToMap(*req, "user_conf")
+	if len(userConfMaps) == 0 {
+		return nil, errors.New("请选择要修改的限制")
+	}
+
+	var userConf user_models.UserConfModel
+	err = l.svcCtx.DB.Take(&userConf, "user_id = ?", req.UserID).Error
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+
+	// 到期时间存在库里 不传过期时间就是永久限制 redis里面只是缓存
+	var expire *time.Time
+	if req.Expire > 0 {
+		t := time.Now().Add(time.Duration(req.Expire) * time.Minute)
+		expire = &t
+	}
+	updates := map[string]any{}
+	for curtailType, val := range userConfMaps {
+		updates[curtailType] = val
+		if val.(bool) {
+			updates[user_models.CurtailExpireColumn(curtailType)] = expire
+		} else {
+			updates[user_models.CurtailExpireColumn(curtailType)] = nil
+		}
+	}
+	err = l.svcCtx.DB.Model(&userConf).Updates(updates).Error
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("用户限制修改失败")
+	}
+
+	for curtailType, val := range userConfMaps {
+		if val.(bool) {
+			err = user_models.SetCurtailCache(l.svcCtx.Redis, curtailType, req.UserID, expire)
+		} else {
+			err = l.svcCtx.Redis.Del(user_models.CurtailKey(curtailType, req.UserID)).Err()
+		}
+		if err != nil {
+			logx.Error(err)
+			return nil, errors.New("用户限制缓存写入失败 请重试")
+		}
+	}
+	return
+}
@@ -0,0 +1,89 @@
+package logic
+
+import (
+	"context"
+	"fim_server/common/list_query"
+	"fim_server/common/models"
+	"fim_server/fim_user/user_models"
+
+	"fim_server/fim_admin/admin_api/internal/svc"
+	"fim_server/fim_admin/admin_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type UserListLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUserListLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UserListLogic {
+	return &UserListLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UserListLogic) UserList(req *types.UserListRequest) (resp *types.UserListResponse, err error) {
+	users, count, err := list_query.ListQuery(l.svcCtx.DB, user_models.UserModel{}, list_query.Option{
+		PageInfo: models.PageInfo{
+			Page:   req.Page,
+			Limit:  req.Limit,
+			Key:    req.Key,
+			Sort:   req.Sort,
+			Filter: req.Filter,
+		},
+		Filters: list_query.FilterSchema{
+			"created_at":      {Type: list_query.TimeField},
+			"role":            {Type: list_query.IntField, Ops: []string{"="}},
+			"nickname":        {Type: list_query.StringField},
+			"ban":             {Type: list_query.BoolField},
+			"register_source": {Type: list_query.StringField, Ops: []string{"="}},
+			"ip":              {Type: list_query.StringField},
+		},
+		SortColumns: []string{"id", "created_at", "nickname"},
+		DefaultSort: "id desc",
+		Likes:       []string{"id", "nickname"},
+		Preload:     []string{"UserConfModel"},
+	})
+	if err != nil {
+		logx.Error(err)
+		return nil, err
+	}
+
+	var confList []user_models.UserConfModel
+	for _, user := range users {
+		if user.UserConfModel != nil {
+			confList = append(confList, *user.UserConfModel)
+		}
+	}
+	curtailMap := user_models.CurtailMap(l.svcCtx.Redis, l.svcCtx.DB, confList)
+
+	resp = &types.UserListResponse{Count: count, List: make([]types.UserListInfo, 0)}
+	for _, user := range users {
+		info := types.UserListInfo{
+			UserID:         user.ID,
+			Nickname:       user.Nickname,
+			Avatar:         user.Avatar,
+			Role:           user.Role,
+			IP:             user.IP,
+			Addr:           user.Addr,
+			RegisterSource: user.RegisterSource,
+			CreatedAt:      user.CreatedAt,
+			Ban:            user.Ban,
+			BanReason:      user.BanReason,
+		}
+		if user.UserConfModel != nil {
+			info.Online = user.UserConfModel.Online
+			curtail := curtailMap[user.ID]
+			info.CurtailChat = curtail[user_models.CurtailChatType]
+			info.CurtailAddUser = curtail[user_models.CurtailAddUserType]
+			info.CurtailCreateGroup = curtail[user_models.CurtailCreateGroupType]
+			info.CurtailInGroupChat = curtail[user_models.CurtailInGroupChatType]
+		}
+		resp.List = append(resp.List, info)
+	}
+	return resp, nil
+}
@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"context"
+	"fim_server/common/list_query"
+	"fim_server/common/models"
+	"fim_server/fim_auth/auth_models"
+
+	"fim_server/fim_admin/admin_api/internal/svc"
+	"fim_server/fim_admin/admin_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type UserLoginLogLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUserLoginLogLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UserLoginLogLogic {
+	return &UserLoginLogLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UserLoginLogLogic) UserLoginLog(req *types.UserLoginLogRequest) (resp *types.UserLoginLogResponse, err error) {
+	logs, count, err := list_query.ListQuery(l.svcCtx.DB, auth_models.LoginLogModel{UserID: req.UserID}, list_query.Option{
+		PageInfo: models.PageInfo{
+			Page:   req.Page,
+			Limit:  req.Limit,
+			Filter: req.Filter,
+		},
+		Filters: list_query.FilterSchema{
+			"created_at": {Type: list_query.TimeField},
+			"ip":         {Type: list_query.StringField},
+			"source":     {Type: list_query.StringField, Ops: []string{"="}},
+			"status":     {Type: list_query.BoolField},
+		},
+		DefaultSort: "id desc",
+	})
+	if err != nil {
+		logx.Error(err)
+		return nil, err
+	}
+
+	resp = &types.UserLoginLogResponse{Count: count, List: make([]types.LoginLogInfo, 0), IPList: make([]string, 0)}
+	for _, log := range logs {
+		resp.List = append(resp.List, types.LoginLogInfo{
+			IP:        log.IP,
+			Source:    log.Source,
+			Status:    log.Status,
+			Msg:       log.Msg,
+			CreatedAt: log.CreatedAt,
+		})
+	}
+
+	// 登录成功过的ip
+	l.svcCtx.DB.Model(&auth_models.LoginLogModel{}).
+		Where("user_id = ? and status = ? and ip <> ''", req.UserID, true).
+		Distinct("ip").Pluck("ip", &resp.IPList)
+	return resp, nil
+}
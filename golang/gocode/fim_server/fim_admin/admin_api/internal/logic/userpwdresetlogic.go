@@ -0,0 +1,46 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_user/user_models"
+	"fim_server/utils/pwd"
+	"fim_server/utils/random"
+
+	"fim_server/fim_admin/admin_api/internal/svc"
+	"fim_server/fim_admin/admin_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type UserPwdResetLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUserPwdResetLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UserPwdResetLogic {
+	return &UserPwdResetLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UserPwdResetLogic) UserPwdReset(req *types.UserPwdResetRequest) (resp *types.UserPwdResetResponse, err error) {
+	var user user_models.UserModel
+	err = l.svcCtx.DB.Take(&user, req.UserID).Error
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+
+	newPwd := random.SecureStr(12)
+	err = l.svcCtx.DB.Model(&user).Update("pwd", pwd.HashPwdWithCost(newPwd, l.svcCtx.Config.BcryptCost)).Error
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("重置密码失败")
+	}
+
+	revokeSession(l.svcCtx.Redis, l.svcCtx.Config.Auth.AccessExpire, req.UserID)
+	return &types.UserPwdResetResponse{Pwd: newPwd}, nil
+}
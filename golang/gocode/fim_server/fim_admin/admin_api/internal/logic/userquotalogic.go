@@ -0,0 +1,50 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_file/file_models"
+	"fim_server/fim_user/user_models"
+	"gorm.io/gorm/clause"
+
+	"fim_server/fim_admin/admin_api/internal/svc"
+	"fim_server/fim_admin/admin_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type UserQuotaLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUserQuotaLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UserQuotaLogic {
+	return &UserQuotaLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UserQuotaLogic) UserQuota(req *types.UserQuotaRequest) (resp *types.UserQuotaResponse, err error) {
+	var user user_models.UserModel
+	err = l.svcCtx.DB.Take(&user, req.UserID).Error
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+
+	if req.Quota < 0 {
+		err = l.svcCtx.DB.Where("user_id = ?", req.UserID).Delete(&file_models.FileQuotaModel{}).Error
+	} else {
+		err = l.svcCtx.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quota", "updated_at"}),
+		}).Create(&file_models.FileQuotaModel{UserID: req.UserID, Quota: req.Quota}).Error
+	}
+	if err != nil {
+		l.Error(err)
+		return nil, errors.New("配额设置失败")
+	}
+	return
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"errors"
+	"fim_server/common/response"
+	"net/http"
+)
+
+type AdminMiddleware struct {
+}
+
+func NewAdminMiddleware() *AdminMiddleware {
+	return &AdminMiddleware{}
+}
+
+// Handle 只有管理员才能访问 Role由网关认证之后带过来
+func (m *AdminMiddleware) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Role") != "1" {
+			response.Response(r, w, nil, errors.New("权限不足"))
+			return
+		}
+		next(w, r)
+	}
+}
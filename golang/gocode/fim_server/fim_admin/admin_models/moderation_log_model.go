@@ -0,0 +1,21 @@
+package admin_models
+
+import (
+	"fim_server/common/models"
+	"fim_server/common/models/ctype"
+)
+
+// ModerationLogModel 消息审核记录表 被拦截或者打码的消息都会记一条
+type ModerationLogModel struct {
+	models.Model
+	Source     string        `gorm:"size:16" json:"source"`    // 来源 chat 私聊 group 群聊
+	SendUserID uint          `gorm:"index" json:"sendUserID"`  // 发送者
+	TargetID   uint          `json:"targetID"`                 // 私聊是接收者id 群聊是群id
+	MsgType    ctype.MsgType `json:"msgType"`                  // 消息类型
+	Content    string        `gorm:"type:text" json:"content"` // 原始内容
+	Words      string        `gorm:"size:256" json:"words"`    // 命中的词 逗号分隔
+	Type       int8          `json:"type"`                     // 违规类型 和SystemMsg.Type一样 1 涉黄 2 涉恐 3 涉政 4 不正当言论
+	Action     int8          `json:"action"`                   // 处理方式 1 打码 2 拦截
+	Status     int8          `json:"status"`                   // 审核状态 0 未审核 1 确认违规 2 误判
+	Remark     string        `gorm:"size:128" json:"remark"`   // 审核备注
+}
@@ -19,16 +19,33 @@ type Config struct {
 		Pwd  string
 		DB   int
 	}
-	OpenLoginList []struct {
-		Name string
-		Icon string
-		Href string
-	}
 	QQ struct {
 		AppID    string
 		AppKey   string
 		Redirect string
 	}
+	Register struct {
+		BcryptCost    int // 密码hash的cost 老的hash在下次登录成功之后升级
+		CaptchaExpire int // 图片验证码过期时间 单位秒
+		Nickname      struct {
+			MinLength   int
+			MaxLength   int
+			BannedWords []string // 昵称违禁词
+		}
+		Password struct {
+			MinLength     int
+			MaxLength     int
+			RequireUpper  bool
+			RequireLower  bool
+			RequireDigit  bool
+			RequireSymbol bool
+		}
+	}
+	TwoFactor struct {
+		Issuer          string // 认证器app里显示的名称
+		ChallengeExpire int    // 两步登录的临时凭证过期时间 单位秒
+		MaxAttempts     int    // 临时凭证最多可以尝试的次数
+	}
 	UserRpc   zrpc.RpcClientConf
 	Etcd      string
 	WhiteList []string // 白名单
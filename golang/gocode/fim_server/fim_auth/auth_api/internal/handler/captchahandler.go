@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"fim_server/common/response"
+	"fim_server/fim_auth/auth_api/internal/logic"
+	"fim_server/fim_auth/auth_api/internal/svc"
+	"net/http"
+)
+
+func captchaHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		l := logic.NewCaptchaLogic(r.Context(), svcCtx)
+		resp, err := l.Captcha()
+		response.Response(r, w, resp, err)
+
+	}
+}
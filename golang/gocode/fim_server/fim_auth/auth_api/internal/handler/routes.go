@@ -19,11 +19,21 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/auth/authentication",
 				Handler: authenticationHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/auth/captcha",
+				Handler: captchaHandler(serverCtx),
+			},
 			{
 				Method:  http.MethodPost,
 				Path:    "/api/auth/login",
 				Handler: loginHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/auth/login/totp",
+				Handler: loginTotpHandler(serverCtx),
+			},
 			{
 				Method:  http.MethodPost,
 				Path:    "/api/auth/logout",
@@ -39,6 +49,21 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/auth/register",
 				Handler: registerHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/auth/totp/confirm",
+				Handler: totpConfirmHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/auth/totp/disable",
+				Handler: totpDisableHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/auth/totp/enroll",
+				Handler: totpEnrollHandler(serverCtx),
+			},
 		},
 	)
 }
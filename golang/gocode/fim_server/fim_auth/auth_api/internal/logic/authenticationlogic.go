@@ -51,6 +51,14 @@ func (l *AuthenticationLogic) Authentication(req *types.AuthenticationRequest) (
 		err = errors.New("认证失败")
 		return
 	}
+
+	// 封号或者重置密码之后，之前签发的token全部失效
+	revokeAt, err := l.svcCtx.Redis.Get(jwts.RevokeKey(claims.UserID)).Int64()
+	if err == nil && claims.IsRevoked(revokeAt) {
+		logx.Errorf("%d 的token已被吊销", claims.UserID)
+		err = errors.New("认证失败")
+		return
+	}
 	return &types.AuthenticationReponse{
 		UserID: claims.UserID,
 		Role:   int(claims.Role),
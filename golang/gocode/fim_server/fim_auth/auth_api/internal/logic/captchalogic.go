@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fim_server/utils/captcha"
+	"fim_server/utils/random"
+	"fmt"
+	"strings"
+	"time"
+
+	"fim_server/fim_auth/auth_api/internal/svc"
+	"fim_server/fim_auth/auth_api/internal/types"
+
+	"github.com/go-redis/redis"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type CaptchaLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewCaptchaLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CaptchaLogic {
+	return &CaptchaLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *CaptchaLogic) Captcha() (resp *types.CaptchaResponse, err error) {
+	code := captcha.RandCode(4)
+	byteData, err := captcha.Draw(code)
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("验证码生成失败")
+	}
+
+	captchaID := random.SecureStr(32)
+	expire := time.Duration(l.svcCtx.Config.Register.CaptchaExpire) * time.Second
+	err = l.svcCtx.Redis.Set(captchaKey(captchaID), code, expire).Err()
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("验证码生成失败")
+	}
+
+	return &types.CaptchaResponse{
+		CaptchaID: captchaID,
+		Image:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(byteData),
+	}, nil
+}
+
+func captchaKey(captchaID string) string {
+	return fmt.Sprintf("captcha_%s", captchaID)
+}
+
+// getDelScript 取出来就删掉 两个请求同时来只有一个能拿到
+var getDelScript = redis.NewScript(`
+local val = redis.call("GET", KEYS[1])
+if val then
+	redis.call("DEL", KEYS[1])
+end
+return val
+`)
+
+// verifyCaptcha 校验图片验证码 不管成功与否，验证码都只能用一次
+func verifyCaptcha(client *redis.Client, captchaID, code string) bool {
+	if captchaID == "" || code == "" {
+		return false
+	}
+	val, err := getDelScript.Run(client, []string{captchaKey(captchaID)}).String()
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(val, code)
+}
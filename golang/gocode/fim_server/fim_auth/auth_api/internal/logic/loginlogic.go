@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fim_server/fim_auth/auth_models"
-	"fim_server/utils/jwts"
 	"fim_server/utils/pwd"
 	"fmt"
 
@@ -39,23 +38,19 @@ func (l *LoginLogic) Login(req *types.LoginRequest) (resp *types.LoginResponse,
 	}
 
 	if !pwd.CheckPwd(user.Pwd, req.Password) {
+		loginLog(l.svcCtx, user.ID, req.IP, "pwd", "密码错误")
 		err = errors.New("用户名或密码错误")
 		return
 	}
 
-	token, err := jwts.GenToken(jwts.JwtPayLoad{
-		Nickname: user.Nickname,
-		Role:     user.Role,
-		UserID:   user.ID,
-	}, l.svcCtx.Config.Auth.AccessSecret, l.svcCtx.Config.Auth.AccessExpire)
-	if err != nil {
-		logx.Error(err)
-		err = errors.New("服务内部错误")
-		return
+	// 老的密码hash的cost偏低，登录成功之后顺便升级
+	cost := l.svcCtx.Config.Register.BcryptCost
+	if pwd.NeedRehash(user.Pwd, cost) {
+		err = l.svcCtx.DB.Model(&user).Update("pwd", pwd.HashPwdWithCost(req.Password, cost)).Error
+		if err != nil {
+			logx.Error(err)
+		}
 	}
 
-	return &types.LoginResponse{
-		Token: token,
-	}, nil
-
+	return loginToken(l.svcCtx, user, req.IP, "pwd")
 }
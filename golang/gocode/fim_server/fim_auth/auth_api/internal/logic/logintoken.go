@@ -0,0 +1,75 @@
+package logic
+
+import (
+	"errors"
+	"fim_server/fim_auth/auth_api/internal/svc"
+	"fim_server/fim_auth/auth_api/internal/types"
+	"fim_server/fim_auth/auth_models"
+	"fim_server/utils/jwts"
+	"fim_server/utils/random"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// loginToken 密码或第三方登录成功之后调用 开启了两步验证的用户先发临时凭证，否则直接发token
+func loginToken(svcCtx *svc.ServiceContext, user auth_models.UserModel, ip string, source string) (*types.LoginResponse, error) {
+	if user.Ban {
+		loginLog(svcCtx, user.ID, ip, source, "账号已被封禁")
+		return nil, errors.New("账号已被封禁")
+	}
+
+	var userTotp auth_models.UserTotpModel
+	err := svcCtx.DB.Take(&userTotp, "user_id = ? and enable = ?", user.ID, true).Error
+	if err != nil {
+		return genToken(svcCtx, user, ip, source)
+	}
+
+	challengeToken := random.SecureStr(32)
+	expire := time.Duration(svcCtx.Config.TwoFactor.ChallengeExpire) * time.Second
+	err = svcCtx.Redis.Set(challengeKey(challengeToken), user.ID, expire).Err()
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("服务内部错误")
+	}
+	return &types.LoginResponse{
+		TwoFactor:      true,
+		ChallengeToken: challengeToken,
+	}, nil
+}
+
+// genToken 签发token 记录登录成功
+func genToken(svcCtx *svc.ServiceContext, user auth_models.UserModel, ip string, source string) (*types.LoginResponse, error) {
+	token, err := jwts.GenToken(jwts.JwtPayLoad{
+		Nickname: user.Nickname,
+		Role:     user.Role,
+		UserID:   user.ID,
+	}, svcCtx.Config.Auth.AccessSecret, svcCtx.Config.Auth.AccessExpire)
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("服务内部错误")
+	}
+
+	loginLog(svcCtx, user.ID, ip, source, "")
+	if ip != "" && ip != user.IP {
+		svcCtx.DB.Model(&user).Update("ip", ip)
+	}
+	return &types.LoginResponse{Token: token}, nil
+}
+
+// loginLog 记录登录 msg为空就是登录成功
+func loginLog(svcCtx *svc.ServiceContext, userID uint, ip string, source string, msg string) {
+	// X-Forwarded-For 可能有多个地址，第一个是客户端
+	ip = strings.TrimSpace(strings.Split(ip, ",")[0])
+	err := svcCtx.DB.Create(&auth_models.LoginLogModel{
+		UserID: userID,
+		IP:     ip,
+		Source: source,
+		Status: msg == "",
+		Msg:    msg,
+	}).Error
+	if err != nil {
+		logx.Error(err)
+	}
+}
@@ -0,0 +1,74 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_auth/auth_models"
+	"fmt"
+	"time"
+
+	"fim_server/fim_auth/auth_api/internal/svc"
+	"fim_server/fim_auth/auth_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type LoginTotpLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewLoginTotpLogic(ctx context.Context, svcCtx *svc.ServiceContext) *LoginTotpLogic {
+	return &LoginTotpLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *LoginTotpLogic) LoginTotp(req *types.LoginTotpRequest) (resp *types.LoginResponse, err error) {
+	key := challengeKey(req.ChallengeToken)
+	userID, err := l.svcCtx.Redis.Get(key).Uint64()
+	if err != nil {
+		return nil, errors.New("登录已过期，请重新登录")
+	}
+
+	// 限制同一个临时凭证的尝试次数
+	attemptsKey := fmt.Sprintf("totp_attempts_%s", req.ChallengeToken)
+	attempts, _ := l.svcCtx.Redis.Incr(attemptsKey).Result()
+	l.svcCtx.Redis.Expire(attemptsKey, time.Duration(l.svcCtx.Config.TwoFactor.ChallengeExpire)*time.Second)
+	if attempts > int64(l.svcCtx.Config.TwoFactor.MaxAttempts) {
+		l.svcCtx.Redis.Del(key, attemptsKey)
+		return nil, errors.New("尝试次数过多，请重新登录")
+	}
+
+	var userTotp auth_models.UserTotpModel
+	err = l.svcCtx.DB.Take(&userTotp, "user_id = ? and enable = ?", userID, true).Error
+	if err != nil {
+		return nil, errors.New("两步验证未开启")
+	}
+
+	var ok bool
+	switch {
+	case req.Code != "":
+		ok = checkTotpCode(l.svcCtx, userTotp, req.Code)
+	case req.RecoveryCode != "":
+		ok = useRecoveryCode(l.svcCtx, userTotp.UserID, req.RecoveryCode)
+	}
+	if !ok {
+		loginLog(l.svcCtx, userTotp.UserID, req.IP, "totp", "两步验证码错误")
+		return nil, errors.New("验证码错误")
+	}
+	l.svcCtx.Redis.Del(key, attemptsKey)
+
+	var user auth_models.UserModel
+	err = l.svcCtx.DB.Take(&user, userID).Error
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if user.Ban {
+		return nil, errors.New("账号已被封禁")
+	}
+	return genToken(l.svcCtx, user, req.IP, "totp")
+}
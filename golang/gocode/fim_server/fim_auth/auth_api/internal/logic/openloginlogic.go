@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fim_server/fim_auth/auth_models"
 	"fim_server/fim_user/user_rpc/types/user_rpc"
-	"fim_server/utils/jwts"
 	"fim_server/utils/open_login"
 	"fmt"
 
@@ -30,6 +29,11 @@ func NewOpen_loginLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Open_l
 }
 
 func (l *Open_loginLogic) Open_login(req *types.OpenLoginRequest) (resp *types.LoginResponse, err error) {
+	settingsInfo := l.svcCtx.Settings.Load()
+	if settingsInfo != nil && !settingsInfo.OpenLoginEnabled(req.Flag) {
+		return nil, errors.New("该登录方式未开启")
+	}
+
 	switch req.Flag {
 	case "qq":
 		info, err := open_login.NewQQLogin(req.Code, open_login.QQConfig{
@@ -48,6 +52,9 @@ func (l *Open_loginLogic) Open_login(req *types.OpenLoginRequest) (resp *types.L
 		var user auth_models.UserModel
 		err = l.svcCtx.DB.Take(&user, "open_id = ?", info.OpenID).Error
 		if err != nil {
+			if settingsInfo != nil && !settingsInfo.RegisterOpen {
+				return nil, errors.New("暂未开放注册")
+			}
 			//	注册逻辑
 			fmt.Println("注册服务")
 			res, err := l.svcCtx.UserRpc.UserCreate(context.Background(), &user_rpc.UserCreateRequest{
@@ -71,17 +78,7 @@ func (l *Open_loginLogic) Open_login(req *types.OpenLoginRequest) (resp *types.L
 		}
 
 		//	登录逻辑
-		token, err1 := jwts.GenToken(jwts.JwtPayLoad{
-			UserID:   user.ID,
-			Nickname: user.Nickname,
-			Role:     user.Role,
-		}, l.svcCtx.Config.Auth.AccessSecret, l.svcCtx.Config.Auth.AccessExpire)
-		if err1 != nil {
-			logx.Error(err1)
-			err = errors.New("服务内部错误")
-			return nil, err1
-		}
-		return &types.LoginResponse{Token: token}, nil
+		return loginToken(l.svcCtx, user, req.IP, req.Flag)
 	}
 
 	return
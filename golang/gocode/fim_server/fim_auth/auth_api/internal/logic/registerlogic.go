@@ -6,6 +6,9 @@ import (
 	"fim_server/fim_auth/auth_api/internal/svc"
 	"fim_server/fim_auth/auth_api/internal/types"
 	"fim_server/fim_user/user_rpc/types/user_rpc"
+	"fim_server/utils/pwd"
+	"fmt"
+	"strings"
 
 	"github.com/zeromicro/go-zero/core/logx"
 )
@@ -25,11 +28,41 @@ func NewRegisterLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Register
 }
 
 func (l *RegisterLogic) Register(req *types.RegisterRequest) (resp *types.RegisterResponse, err error) {
+	if info := l.svcCtx.Settings.Load(); info != nil && !info.RegisterOpen {
+		err = errors.New("暂未开放注册")
+		return
+	}
+
+	if !verifyCaptcha(l.svcCtx.Redis, req.CaptchaID, req.CaptchaCode) {
+		err = errors.New("验证码错误")
+		return
+	}
+
+	req.Nickname = strings.TrimSpace(req.Nickname)
+	err = l.checkNickname(req.Nickname)
+	if err != nil {
+		return
+	}
+
 	if req.Pwd != req.RePwd {
 		err = errors.New("两次密码不一致")
 		return
 	}
 
+	conf := l.svcCtx.Config.Register.Password
+	policy := pwd.Policy{
+		MinLength:     conf.MinLength,
+		MaxLength:     conf.MaxLength,
+		RequireUpper:  conf.RequireUpper,
+		RequireLower:  conf.RequireLower,
+		RequireDigit:  conf.RequireDigit,
+		RequireSymbol: conf.RequireSymbol,
+	}
+	err = policy.Check(req.Pwd)
+	if err != nil {
+		return
+	}
+
 	response, err := l.svcCtx.UserRpc.UserCreate(context.Background(), &user_rpc.UserCreateRequest{
 		NickName:       req.Nickname,
 		Password:       req.RePwd,
@@ -43,3 +76,22 @@ func (l *RegisterLogic) Register(req *types.RegisterRequest) (resp *types.Regist
 
 	return &types.RegisterResponse{UserID: uint(response.UserId)}, nil
 }
+
+// checkNickname 昵称长度和违禁词校验
+func (l *RegisterLogic) checkNickname(nickname string) error {
+	conf := l.svcCtx.Config.Register.Nickname
+	length := len([]rune(nickname))
+	if length < conf.MinLength {
+		return fmt.Errorf("昵称长度不能少于%d个字符", conf.MinLength)
+	}
+	if length > conf.MaxLength {
+		return fmt.Errorf("昵称长度不能超过%d个字符", conf.MaxLength)
+	}
+	lower := strings.ToLower(nickname)
+	for _, word := range conf.BannedWords {
+		if word != "" && strings.Contains(lower, strings.ToLower(word)) {
+			return errors.New("昵称包含违禁词")
+		}
+	}
+	return nil
+}
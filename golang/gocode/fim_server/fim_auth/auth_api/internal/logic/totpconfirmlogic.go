@@ -0,0 +1,55 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_auth/auth_models"
+
+	"fim_server/fim_auth/auth_api/internal/svc"
+	"fim_server/fim_auth/auth_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type TotpConfirmLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewTotpConfirmLogic(ctx context.Context, svcCtx *svc.ServiceContext) *TotpConfirmLogic {
+	return &TotpConfirmLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *TotpConfirmLogic) TotpConfirm(req *types.TotpConfirmRequest) (resp *types.TotpConfirmResponse, err error) {
+	var userTotp auth_models.UserTotpModel
+	err = l.svcCtx.DB.Take(&userTotp, "user_id = ?", req.UserID).Error
+	if err != nil {
+		return nil, errors.New("请先开启两步验证")
+	}
+	if userTotp.Enable {
+		return nil, errors.New("两步验证已经开启了")
+	}
+
+	if !checkTotpCode(l.svcCtx, userTotp, req.Code) {
+		return nil, errors.New("验证码错误")
+	}
+
+	codes, err := newRecoveryCodes(l.svcCtx, req.UserID)
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("服务内部错误")
+	}
+
+	err = l.svcCtx.DB.Model(&userTotp).Update("enable", true).Error
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("服务内部错误")
+	}
+
+	return &types.TotpConfirmResponse{RecoveryCodes: codes}, nil
+}
@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_auth/auth_models"
+
+	"fim_server/fim_auth/auth_api/internal/svc"
+	"fim_server/fim_auth/auth_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type TotpDisableLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewTotpDisableLogic(ctx context.Context, svcCtx *svc.ServiceContext) *TotpDisableLogic {
+	return &TotpDisableLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *TotpDisableLogic) TotpDisable(req *types.TotpDisableRequest) (resp string, err error) {
+	var userTotp auth_models.UserTotpModel
+	err = l.svcCtx.DB.Take(&userTotp, "user_id = ? and enable = ?", req.UserID, true).Error
+	if err != nil {
+		return "", errors.New("两步验证未开启")
+	}
+
+	if !checkTotpCode(l.svcCtx, userTotp, req.Code) {
+		return "", errors.New("验证码错误")
+	}
+
+	l.svcCtx.DB.Where("user_id = ?", req.UserID).Delete(&auth_models.UserRecoveryCodeModel{})
+	l.svcCtx.DB.Delete(&userTotp)
+	return "两步验证已关闭", nil
+}
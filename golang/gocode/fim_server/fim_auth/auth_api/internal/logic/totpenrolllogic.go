@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_auth/auth_models"
+	"fim_server/utils/totp"
+	"fmt"
+
+	"fim_server/fim_auth/auth_api/internal/svc"
+	"fim_server/fim_auth/auth_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type TotpEnrollLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewTotpEnrollLogic(ctx context.Context, svcCtx *svc.ServiceContext) *TotpEnrollLogic {
+	return &TotpEnrollLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *TotpEnrollLogic) TotpEnroll(req *types.TotpEnrollRequest) (resp *types.TotpEnrollResponse, err error) {
+	var userTotp auth_models.UserTotpModel
+	err = l.svcCtx.DB.Take(&userTotp, "user_id = ?", req.UserID).Error
+	if err == nil && userTotp.Enable {
+		return nil, errors.New("两步验证已经开启了")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("服务内部错误")
+	}
+
+	// 还没确认的密钥直接覆盖
+	if userTotp.ID == 0 {
+		userTotp = auth_models.UserTotpModel{UserID: req.UserID, Secret: secret}
+		err = l.svcCtx.DB.Create(&userTotp).Error
+	} else {
+		err = l.svcCtx.DB.Model(&userTotp).Update("secret", secret).Error
+	}
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("服务内部错误")
+	}
+
+	return &types.TotpEnrollResponse{
+		Secret: secret,
+		Uri:    totp.URI(l.svcCtx.Config.TwoFactor.Issuer, fmt.Sprintf("%d", req.UserID), secret),
+	}, nil
+}
@@ -0,0 +1,66 @@
+package logic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fim_server/fim_auth/auth_api/internal/svc"
+	"fim_server/fim_auth/auth_models"
+	"fim_server/utils/random"
+	"fim_server/utils/totp"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func challengeKey(challengeToken string) string {
+	return fmt.Sprintf("totp_challenge_%s", challengeToken)
+}
+
+// checkTotpCode 校验验证码，同一个时间步的验证码只能用一次
+func checkTotpCode(svcCtx *svc.ServiceContext, userTotp auth_models.UserTotpModel, code string) bool {
+	step, ok := totp.Validate(userTotp.Secret, code, time.Now())
+	if !ok {
+		return false
+	}
+	// 检查和标记是同一步 两个请求同时用一个验证码只有一个能成功
+	// 过了容错的时间窗口这个时间步的验证码本来就不能用了 key跟着过期
+	key := fmt.Sprintf("totp_used_%d_%d", userTotp.UserID, step)
+	ok, err := svcCtx.Redis.SetNX(key, 1, time.Duration(2*totp.Skew+1)*totp.Period*time.Second).Result()
+	return err == nil && ok
+}
+
+// newRecoveryCodes 重新生成恢复码 之前的全部作废
+func newRecoveryCodes(svcCtx *svc.ServiceContext, userID uint) ([]string, error) {
+	var codes []string
+	var list []auth_models.UserRecoveryCodeModel
+	for i := 0; i < 10; i++ {
+		code := strings.ToLower(random.SecureStr(4) + "-" + random.SecureStr(4))
+		codes = append(codes, code)
+		list = append(list, auth_models.UserRecoveryCodeModel{
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(code),
+		})
+	}
+	err := svcCtx.DB.Where("user_id = ?", userID).Delete(&auth_models.UserRecoveryCodeModel{}).Error
+	if err != nil {
+		return nil, err
+	}
+	err = svcCtx.DB.Create(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// useRecoveryCode 使用恢复码
+func useRecoveryCode(svcCtx *svc.ServiceContext, userID uint, code string) bool {
+	res := svcCtx.DB.Model(&auth_models.UserRecoveryCodeModel{}).
+		Where("user_id = ? and code_hash = ? and used = ?", userID, hashRecoveryCode(code), false).
+		Update("used", true)
+	return res.Error == nil && res.RowsAffected == 1
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,6 +1,7 @@
 package svc
 
 import (
+	"fim_server/common/settings"
 	"fim_server/core"
 	"fim_server/fim_auth/auth_api/internal/config"
 	"fim_server/fim_user/user_rpc/types/user_rpc"
@@ -11,10 +12,11 @@ import (
 )
 
 type ServiceContext struct {
-	Config  config.Config
-	DB      *gorm.DB
-	Redis   *redis.Client
-	UserRpc user_rpc.UsersClient
+	Config   config.Config
+	DB       *gorm.DB
+	Redis    *redis.Client
+	UserRpc  user_rpc.UsersClient
+	Settings *settings.Watcher // 系统设置 后台改了之后自动更新
 }
 
 func NewServiceContext(c config.Config) *ServiceContext {
@@ -22,9 +24,10 @@ func NewServiceContext(c config.Config) *ServiceContext {
 	redisClient := core.InitRedis(c.Redis.Addr, c.Redis.Pwd, c.Redis.DB)
 
 	return &ServiceContext{
-		Config:  c,
-		DB:      mysqlDb,
-		Redis:   redisClient,
-		UserRpc: users.NewUsers(zrpc.MustNewClient(c.UserRpc)),
+		Config:   c,
+		DB:       mysqlDb,
+		Redis:    redisClient,
+		UserRpc:  users.NewUsers(zrpc.MustNewClient(c.UserRpc)),
+		Settings: settings.NewWatcher(c.Etcd),
 	}
 }
@@ -13,13 +13,28 @@ type AuthenticationRequest struct {
 	ValidPath string `header:"ValidPath,optional"`
 }
 
+type CaptchaResponse struct {
+	CaptchaID string `json:"captchaID"`
+	Image     string `json:"image"` // base64的png图片
+}
+
 type LoginRequest struct {
 	UserName string `json:"userName"`
 	Password string `json:"password"`
+	IP       string `header:"X-Forwarded-For,optional"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token          string `json:"token"`
+	TwoFactor      bool   `json:"twoFactor"`      // 是否需要两步验证
+	ChallengeToken string `json:"challengeToken"` // 两步验证的临时凭证
+}
+
+type LoginTotpRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code,optional"`         // 认证器app上的验证码
+	RecoveryCode   string `json:"recoveryCode,optional"` // 恢复码 和验证码二选一
+	IP             string `header:"X-Forwarded-For,optional"`
 }
 
 type OpenLoginInfoResponse struct {
@@ -31,14 +46,40 @@ type OpenLoginInfoResponse struct {
 type OpenLoginRequest struct {
 	Code string `json:"code"`
 	Flag string `json:"flag"` // 登录标志，标志是什么登录
+	IP   string `header:"X-Forwarded-For,optional"`
 }
 
 type RegisterRequest struct {
-	Nickname string `json:"nickname"`
-	Pwd      string `json:"pwd"`
-	RePwd    string `json:"rePwd"`
+	Nickname    string `json:"nickname"`
+	Pwd         string `json:"pwd"`
+	RePwd       string `json:"rePwd"`
+	CaptchaID   string `json:"captchaID"`   // 图片验证码id
+	CaptchaCode string `json:"captchaCode"` // 图片验证码
 }
 
 type RegisterResponse struct {
 	UserID uint `json:"userID"`
 }
+
+type TotpConfirmRequest struct {
+	UserID uint   `header:"User-ID"`
+	Code   string `json:"code"`
+}
+
+type TotpConfirmResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"` // 恢复码 只返回这一次
+}
+
+type TotpDisableRequest struct {
+	UserID uint   `header:"User-ID"`
+	Code   string `json:"code"`
+}
+
+type TotpEnrollRequest struct {
+	UserID uint `header:"User-ID"`
+}
+
+type TotpEnrollResponse struct {
+	Secret string `json:"secret"`
+	Uri    string `json:"uri"` // otpauth地址 用来生成二维码
+}
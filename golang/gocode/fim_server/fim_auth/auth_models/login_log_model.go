@@ -0,0 +1,13 @@
+package auth_models
+
+import "fim_server/common/models"
+
+// LoginLogModel 登录记录表
+type LoginLogModel struct {
+	models.Model
+	UserID uint   `gorm:"index" json:"userID"`
+	IP     string `gorm:"size:32" json:"ip"`
+	Source string `gorm:"size:16" json:"source"` // 登录方式 pwd qq totp
+	Status bool   `json:"status"`                // 是否登录成功
+	Msg    string `gorm:"size:64" json:"msg"`    // 失败原因
+}
@@ -14,4 +14,6 @@ type UserModel struct {
 	Role           int8   `json:"role"`                          // 角色 1 管理员  2 普通用户
 	OpenID         string `gorm:"size:64" json:"-"`              // 第三方平台登录的凭证
 	RegisterSource string `gorm:"size:16" json:"registerSource"` // 注册来源
+	Ban            bool   `json:"ban"`                           // 是否被封禁
+	BanReason      string `gorm:"size:128" json:"banReason"`     // 封禁原因
 }
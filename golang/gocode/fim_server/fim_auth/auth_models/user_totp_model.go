@@ -0,0 +1,19 @@
+package auth_models
+
+import "fim_server/common/models"
+
+// UserTotpModel 用户两步验证表
+type UserTotpModel struct {
+	models.Model
+	UserID uint   `gorm:"uniqueIndex" json:"userID"`
+	Secret string `gorm:"size:64" json:"-"` // base32编码的totp密钥
+	Enable bool   `json:"enable"`           // 确认过第一个验证码之后才算开启
+}
+
+// UserRecoveryCodeModel 两步验证的恢复码表 一个恢复码只能用一次
+type UserRecoveryCodeModel struct {
+	models.Model
+	UserID   uint   `gorm:"index" json:"userID"`
+	CodeHash string `gorm:"size:64" json:"-"` // 恢复码的sha256
+	Used     bool   `json:"used"`
+}
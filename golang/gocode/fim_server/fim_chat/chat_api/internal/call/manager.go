@@ -0,0 +1,259 @@
+package call
+
+import (
+	"encoding/json"
+	"errors"
+	"fim_server/fim_chat/chat_api/internal/hub"
+	"fim_server/utils/random"
+	"sync"
+	"time"
+)
+
+// 信令动作 客户端发过来和推给客户端的都是这些
+const (
+	StartAction   = "start"   // 发起通话 推给接收方所有的设备
+	RingAction    = "ring"    // 接收方的设备响铃了 转给发起方
+	AcceptAction  = "accept"  // 接收方接听 这个设备和发起方绑定
+	RejectAction  = "reject"  // 接收方拒接
+	OfferAction   = "offer"   // sdp offer
+	AnswerAction  = "answer"  // sdp answer
+	IceAction     = "ice"     // ice候选地址
+	HangupAction  = "hangup"  // 挂断
+	TimeoutAction = "timeout" // 响铃超时没人接
+	BusyAction    = "busy"    // 对方正在通话中
+	EndAction     = "end"     // 其他原因结束 比如断线
+)
+
+// 通话类型
+const (
+	VoiceCall = "voice"
+	VideoCall = "video"
+)
+
+// 结束原因 和VoiceCallMsg里面的一样
+const (
+	CallerEnd  int8 = iota // 发起方挂断
+	CalleeEnd              // 接收方挂断
+	NetworkEnd             // 网络原因挂断
+	NotConnect             // 未打通
+)
+
+type State int8
+
+const (
+	Ringing   State = iota + 1 // 响铃中
+	Connected                  // 已接通
+)
+
+// Call 一次通话 发起方和接通的那个设备绑定 信令只在这两个设备之间转
+type Call struct {
+	ID        string
+	Type      string
+	CallerID  uint
+	CalleeID  uint
+	Caller    *hub.Client
+	Callee    *hub.Client // 接听之后才有
+	State     State
+	StartTime time.Time // 接通的时间 没接通和结束时间一样
+	EndTime   time.Time
+	EndReason int8
+	timer     *time.Timer
+}
+
+// Event 推给客户端的信令
+type Event struct {
+	Action    string          `json:"action"`
+	CallID    string          `json:"callID"`
+	CallType  string          `json:"callType"`
+	UserID    uint            `json:"userID"` // 谁做的这个动作
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	EndReason int8            `json:"endReason"` // 结束的时候才有意义
+}
+
+// Manager 正在进行的通话 放在内存里面 和websocket连接在同一个服务上
+type Manager struct {
+	hub         *hub.Hub
+	ringTimeout time.Duration
+
+	mu      sync.Mutex
+	callMap map[string]*Call
+	userMap map[uint]*Call // 用户正在进行的通话 忙线检测用
+	ended   chan Call
+}
+
+func NewManager(h *hub.Hub, ringTimeout time.Duration) *Manager {
+	return &Manager{
+		hub:         h,
+		ringTimeout: ringTimeout,
+		callMap:     map[string]*Call{},
+		userMap:     map[uint]*Call{},
+		ended:       make(chan Call, 64),
+	}
+}
+
+// Ended 结束的通话 要存通话记录
+func (m *Manager) Ended() <-chan Call {
+	return m.ended
+}
+
+func (m *Manager) frame(c *Call, action string, userID uint, payload json.RawMessage) hub.Frame {
+	return hub.Frame{Type: hub.CallType, Data: Event{
+		Action:    action,
+		CallID:    c.ID,
+		CallType:  c.Type,
+		UserID:    userID,
+		Payload:   payload,
+		EndReason: c.EndReason,
+	}}
+}
+
+// Start 发起通话 先把通话id告诉发起方 对方忙线或者不在线就直接结束 存一条未打通的记录
+func (m *Manager) Start(caller *hub.Client, calleeID uint, callType string) error {
+	if callType != VoiceCall && callType != VideoCall {
+		return errors.New("通话类型错误")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.userMap[caller.UserID] != nil {
+		return errors.New("你正在通话中")
+	}
+	c := &Call{
+		ID:       random.SecureStr(16),
+		Type:     callType,
+		CallerID: caller.UserID,
+		CalleeID: calleeID,
+		Caller:   caller,
+		State:    Ringing,
+	}
+	caller.Push(m.frame(c, StartAction, caller.UserID, nil))
+	if m.userMap[calleeID] != nil {
+		m.finish(c, NotConnect, BusyAction, calleeID)
+		return nil
+	}
+	if !m.hub.Online(calleeID) {
+		m.finish(c, NotConnect, EndAction, calleeID)
+		return nil
+	}
+	m.callMap[c.ID] = c
+	m.userMap[caller.UserID] = c
+	m.userMap[calleeID] = c
+	c.timer = time.AfterFunc(m.ringTimeout, func() {
+		m.timeout(c.ID)
+	})
+	m.hub.Push(calleeID, m.frame(c, StartAction, caller.UserID, nil))
+	return nil
+}
+
+func (m *Manager) timeout(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.callMap[id]
+	if c == nil || c.State != Ringing {
+		return
+	}
+	m.finish(c, NotConnect, TimeoutAction, 0)
+}
+
+// Handle 处理start之外的信令
+func (m *Manager) Handle(client *hub.Client, action string, id string, payload json.RawMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.callMap[id]
+	if c == nil || (client.UserID != c.CallerID && client.UserID != c.CalleeID) {
+		return errors.New("通话不存在")
+	}
+	isCaller := client == c.Caller
+	isCallee := client.UserID == c.CalleeID && (c.Callee == nil || c.Callee == client)
+	if !isCaller && !isCallee {
+		return errors.New("通话已经在其他设备上")
+	}
+
+	switch action {
+	case RingAction:
+		if !isCallee || c.State != Ringing {
+			return errors.New("当前状态不能响铃")
+		}
+		c.Caller.Push(m.frame(c, RingAction, client.UserID, nil))
+	case AcceptAction:
+		if !isCallee || c.State != Ringing {
+			return errors.New("当前状态不能接听")
+		}
+		c.timer.Stop()
+		c.Callee = client
+		c.State = Connected
+		c.StartTime = time.Now()
+		frame := m.frame(c, AcceptAction, client.UserID, nil)
+		c.Caller.Push(frame)
+		// 其他设备停止响铃
+		m.hub.PushExcept(c.CalleeID, client, frame)
+	case RejectAction:
+		if !isCallee || c.State != Ringing {
+			return errors.New("当前状态不能拒接")
+		}
+		m.finish(c, CalleeEnd, RejectAction, client.UserID)
+	case OfferAction, AnswerAction, IceAction:
+		if c.State != Connected {
+			return errors.New("通话还没有接通")
+		}
+		peer := c.Callee
+		if !isCaller {
+			peer = c.Caller
+		}
+		peer.Push(m.frame(c, action, client.UserID, payload))
+	case HangupAction:
+		reason := CalleeEnd
+		if isCaller {
+			reason = CallerEnd
+		}
+		m.finish(c, reason, HangupAction, client.UserID)
+	default:
+		return errors.New("不支持的信令")
+	}
+	return nil
+}
+
+// Disconnect 连接断开 这个设备上正在进行的通话按网络原因结束
+func (m *Manager) Disconnect(client *hub.Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.userMap[client.UserID]
+	if c == nil || (c.Caller != client && c.Callee != client) {
+		return
+	}
+	m.finish(c, NetworkEnd, EndAction, client.UserID)
+}
+
+// finish 结束通话 通知双方 要在锁里面调用
+func (m *Manager) finish(c *Call, reason int8, action string, userID uint) {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	delete(m.callMap, c.ID)
+	if m.userMap[c.CallerID] == c {
+		delete(m.userMap, c.CallerID)
+	}
+	if m.userMap[c.CalleeID] == c {
+		delete(m.userMap, c.CalleeID)
+	}
+	c.EndReason = reason
+	c.EndTime = time.Now()
+	if c.State != Connected {
+		c.StartTime = c.EndTime
+	}
+
+	frame := m.frame(c, action, userID, nil)
+	c.Caller.Push(frame)
+	if c.Callee != nil {
+		c.Callee.Push(frame)
+	} else if action != BusyAction {
+		// 还在响铃 接收方所有的设备都要停
+		m.hub.Push(c.CalleeID, frame)
+	}
+	select {
+	case m.ended <- *c:
+	default:
+		go func(c Call) {
+			m.ended <- c
+		}(*c)
+	}
+}
@@ -0,0 +1,235 @@
+package call
+
+import (
+	"encoding/json"
+	"fim_server/fim_chat/chat_api/internal/hub"
+	"net"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// testDevice 一个在线的设备 client是服务端的连接 ws是客户端这一头
+type testDevice struct {
+	client *hub.Client
+	ws     *websocket.Conn
+}
+
+func newDevice(t *testing.T, h *hub.Hub, userID uint) testDevice {
+	ready := make(chan *hub.Client, 1)
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		client := hub.NewClient(userID, conn)
+		ready <- client
+		client.WriteLoop()
+	}))
+	t.Cleanup(server.Close)
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := <-ready
+	t.Cleanup(client.Close)
+	// 不补发离线消息 推的直接发
+	client.SyncDone()
+	h.Register(client)
+	return testDevice{client: client, ws: ws}
+}
+
+// actions 收到的所有通话信令 等一小会儿没有新的就返回
+func (d testDevice) actions(t *testing.T) []string {
+	list := []string{}
+	for {
+		d.ws.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		var frame struct {
+			Type string `json:"type"`
+			Data Event  `json:"data"`
+		}
+		err := websocket.JSON.Receive(d.ws, &frame)
+		if err != nil {
+			if e, ok := err.(net.Error); !ok || !e.Timeout() {
+				t.Errorf("读取失败 %v", err)
+			}
+			return list
+		}
+		if frame.Type != hub.CallType {
+			t.Errorf("不是通话信令 %s", frame.Type)
+			continue
+		}
+		list = append(list, frame.Data.Action)
+	}
+}
+
+// callID 用户正在进行的通话
+func callID(m *Manager, userID uint) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c := m.userMap[userID]; c != nil {
+		return c.ID
+	}
+	return ""
+}
+
+func TestManager(t *testing.T) {
+	// 1 发起方 2 接收方有两个设备 3 其他人
+	type devices struct {
+		caller, callee, callee2, other testDevice
+	}
+	cases := []struct {
+		name    string
+		timeout time.Duration // 响铃超时 没写就是一分钟
+		run     func(t *testing.T, m *Manager, d devices)
+		want    map[string][]string
+		reason  int8
+	}{
+		{
+			name: "忙线",
+			run: func(t *testing.T, m *Manager, d devices) {
+				m.Start(d.callee.client, 3, VoiceCall)
+				m.Start(d.caller.client, 2, VoiceCall)
+			},
+			want: map[string][]string{
+				"caller":  {StartAction, BusyAction},
+				"callee":  {StartAction},
+				"callee2": {},
+				"other":   {StartAction},
+			},
+			reason: NotConnect,
+		},
+		{
+			name: "不在线",
+			run: func(t *testing.T, m *Manager, d devices) {
+				m.Start(d.caller.client, 4, VideoCall)
+			},
+			want: map[string][]string{
+				"caller":  {StartAction, EndAction},
+				"callee":  {},
+				"callee2": {},
+				"other":   {},
+			},
+			reason: NotConnect,
+		},
+		{
+			name:    "响铃超时",
+			timeout: 50 * time.Millisecond,
+			run: func(t *testing.T, m *Manager, d devices) {
+				m.Start(d.caller.client, 2, VoiceCall)
+				time.Sleep(80 * time.Millisecond)
+			},
+			want: map[string][]string{
+				"caller":  {StartAction, TimeoutAction},
+				"callee":  {StartAction, TimeoutAction},
+				"callee2": {StartAction, TimeoutAction},
+				"other":   {},
+			},
+			reason: NotConnect,
+		},
+		{
+			name: "拒接",
+			run: func(t *testing.T, m *Manager, d devices) {
+				m.Start(d.caller.client, 2, VoiceCall)
+				m.Handle(d.callee.client, RingAction, callID(m, 1), nil)
+				m.Handle(d.callee.client, RejectAction, callID(m, 1), nil)
+			},
+			want: map[string][]string{
+				"caller":  {StartAction, RingAction, RejectAction},
+				"callee":  {StartAction, RejectAction},
+				"callee2": {StartAction, RejectAction},
+				"other":   {},
+			},
+			reason: CalleeEnd,
+		},
+		{
+			name: "在另一个设备上接听",
+			run: func(t *testing.T, m *Manager, d devices) {
+				m.Start(d.caller.client, 2, VideoCall)
+				id := callID(m, 1)
+				if err := m.Handle(d.callee2.client, AcceptAction, id, nil); err != nil {
+					t.Fatal(err)
+				}
+				if err := m.Handle(d.callee.client, OfferAction, id, json.RawMessage(`{}`)); err == nil {
+					t.Error("没接听的设备不能发信令")
+				}
+				if err := m.Handle(d.callee.client, AcceptAction, id, nil); err == nil {
+					t.Error("已经接通了 不能再接听")
+				}
+				m.Handle(d.caller.client, OfferAction, id, json.RawMessage(`{"sdp":"x"}`))
+				m.Handle(d.callee2.client, AnswerAction, id, json.RawMessage(`{"sdp":"y"}`))
+				m.Handle(d.callee2.client, HangupAction, id, nil)
+			},
+			want: map[string][]string{
+				"caller":  {StartAction, AcceptAction, AnswerAction, HangupAction},
+				"callee":  {StartAction, AcceptAction},
+				"callee2": {StartAction, OfferAction, HangupAction},
+				"other":   {},
+			},
+			reason: CalleeEnd,
+		},
+		{
+			name: "断线",
+			run: func(t *testing.T, m *Manager, d devices) {
+				m.Start(d.caller.client, 2, VoiceCall)
+				m.Handle(d.callee.client, AcceptAction, callID(m, 1), nil)
+				// 没有接听的设备断开不影响通话
+				m.Disconnect(d.callee2.client)
+				if callID(m, 1) == "" {
+					t.Fatal("其他设备断开不能结束通话")
+				}
+				m.Disconnect(d.caller.client)
+			},
+			want: map[string][]string{
+				"caller":  {StartAction, AcceptAction, EndAction},
+				"callee":  {StartAction, EndAction},
+				"callee2": {StartAction, AcceptAction},
+				"other":   {},
+			},
+			reason: NetworkEnd,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := hub.NewHub()
+			timeout := c.timeout
+			if timeout == 0 {
+				timeout = time.Minute
+			}
+			m := NewManager(h, timeout)
+			d := devices{
+				caller:  newDevice(t, h, 1),
+				callee:  newDevice(t, h, 2),
+				callee2: newDevice(t, h, 2),
+				other:   newDevice(t, h, 3),
+			}
+			c.run(t, m, d)
+
+			got := map[string][]string{
+				"caller":  d.caller.actions(t),
+				"callee":  d.callee.actions(t),
+				"callee2": d.callee2.actions(t),
+				"other":   d.other.actions(t),
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("信令错误\n期望 %v\n实际 %v", c.want, got)
+			}
+
+			select {
+			case call := <-m.Ended():
+				if call.EndReason != c.reason || call.CallerID != 1 {
+					t.Errorf("通话记录错误 %+v", call)
+				}
+				if call.StartTime.After(call.EndTime) {
+					t.Errorf("开始时间在结束时间之后 %+v", call)
+				}
+			case <-time.After(time.Second):
+				t.Error("没有结束的通话")
+			}
+			if callID(m, 1) != "" {
+				t.Error("结束之后发起方还在通话中")
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package config
+
+import "github.com/zeromicro/go-zero/rest"
+
+type Config struct {
+	rest.RestConf
+	Mysql struct {
+		DataSource string
+	}
+	Redis struct {
+		Addr string
+		Pwd  string
+		DB   int
+	}
+	Moderation struct {
+		WordFile string // 敏感词词表 改了之后自动重新加载
+	}
+	Inbox struct {
+		KeepDays int `json:",default=30"` // 收件箱的事件留多少天 设备离线更久就只能重新拉
+	}
+	Call struct {
+		RingTimeout int `json:",default=60"` // 响铃多少秒没人接就结束
+	}
+	Group struct {
+		MaxPin int `json:",default=10"` // 一个群最多置顶多少条消息
+	}
+	Etcd string
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"fim_server/common/response"
+	"fim_server/fim_chat/chat_api/internal/logic"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func chatKeyCountHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.ChatKeyCountRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			response.Response(r, w, nil, err)
+			return
+		}
+
+		l := logic.NewChatKeyCountLogic(r.Context(), svcCtx)
+		resp, err := l.ChatKeyCount(&req)
+		response.Response(r, w, resp, err)
+
+	}
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"fim_server/common/response"
+	"fim_server/fim_chat/chat_api/internal/logic"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"golang.org/x/net/websocket"
+)
+
+// maxPayload 一帧最大的大小 消息内容都是文本 文件走文件服务
+const maxPayload = 1 << 20
+
+func chatWebsocketHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.ChatWebsocketRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			response.Response(r, w, nil, err)
+			return
+		}
+
+		// 经过网关认证过了 不用再校验Origin
+		server := websocket.Server{Handler: func(conn *websocket.Conn) {
+			conn.MaxPayloadBytes = maxPayload
+			l := logic.NewChatWebsocketLogic(r.Context(), svcCtx)
+			l.ChatWebsocket(&req, conn)
+		}}
+		server.ServeHTTP(w, r)
+	}
+}
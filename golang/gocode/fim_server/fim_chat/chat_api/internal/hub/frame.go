@@ -0,0 +1,66 @@
+package hub
+
+import (
+	"encoding/json"
+	"fim_server/common/models/ctype"
+)
+
+// 协议
+// 连接的时候带上设备收到的最后一个seq 服务端把之后的事件按顺序补过来 补完发synced
+// 事件帧都带seq 客户端收到的seq不是上一个加一 说明漏了 发sync带上自己的seq重新补
+// seq小于等于自己的直接丢掉 落后太多或者事件已经过期了会收到reset 重新拉会话和聊天记录
+
+// 客户端发过来的帧
+const (
+	SendType      = "send"       // 发消息
+	DeliveredType = "delivered"  // 确认收到 确认到某个会话的哪一条
+	ReadType      = "read"       // 确认已读
+	RecallType    = "recall"     // 撤回自己发的消息
+	SyncType      = "sync"       // 发现漏了事件 从seq往后重新补
+	CallType      = "call"       // 通话信令 推给客户端的也是这个
+	GroupSendType = "group_send" // 发群消息
+)
+
+// 推给客户端的帧
+const (
+	// 事件帧的type就是收件箱的事件类型 msg recall receipt group key reaction group_msg poll pin
+
+	SentType   = "sent"   // 发送结果 带上客户端自己的clientMsgID 消息本身在msg事件里面
+	SyncedType = "synced" // 补完了 data是当前的seq
+	ResetType  = "reset"  // 补不了 重新拉数据 data是当前的seq
+	ErrorType  = "error"
+)
+
+// Request 客户端发过来的一帧 按type用不同的字段
+type Request struct {
+	Type        string    `json:"type"`
+	ClientMsgID string    `json:"clientMsgID"` // 客户端生成的消息id 发送结果原样带回去
+	RevUserID   uint      `json:"revUserID"`   // send 发给谁
+	Msg         ctype.Msg `json:"msg"`         // send 消息内容
+	TargetID    uint      `json:"targetID"`    // delivered read 对方的用户id
+	MsgID       uint      `json:"msgID"`       // delivered read 确认到哪一条 recall 撤回哪一条
+	Seq         uint64    `json:"seq"`         // sync 客户端收到的最后一个seq
+	GroupID     uint      `json:"groupID"`     // group_send 发到哪个群
+
+	Action   string          `json:"action"`   // call 信令动作
+	CallID   string          `json:"callID"`   // call 发起之后服务端给的通话id
+	CallType string          `json:"callType"` // call start的时候传 voice 语音 video 视频
+	Payload  json.RawMessage `json:"payload"`  // call offer answer ice 的sdp和候选地址 原样转给对方
+}
+
+// Frame 推给客户端的一帧
+type Frame struct {
+	Type        string `json:"type"`
+	ClientMsgID string `json:"clientMsgID,omitempty"`
+	Seq         uint64 `json:"seq,omitempty"` // 收件箱的事件才有
+	Data        any    `json:"data,omitempty"`
+	Msg         string `json:"msg,omitempty"` // 出错的提示
+}
+
+// Receipt 送达和已读的位置 推给消息的发送人
+type Receipt struct {
+	UserID         uint `json:"userID"`   // 谁收到或者读了
+	TargetID       uint `json:"targetID"` // 谁发的消息
+	DeliveredMsgID uint `json:"deliveredMsgID"`
+	ReadMsgID      uint `json:"readMsgID"`
+}
@@ -0,0 +1,197 @@
+package hub
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"golang.org/x/net/websocket"
+)
+
+// sendBuffer 每个连接待发送的帧 满了说明客户端收不过来 直接断开让它重连补发
+const sendBuffer = 256
+
+const writeTimeout = 10 * time.Second
+
+// Client 一个websocket连接 一个用户可以在多个设备上同时在线
+type Client struct {
+	UserID uint
+	conn   *websocket.Conn
+	send   chan []byte
+
+	mu      sync.Mutex
+	closed  bool
+	syncing bool     // 还在补发离线消息 这时候的新消息先存着 补发完再发 保证顺序
+	pending [][]byte // 补发期间的新消息
+}
+
+func NewClient(userID uint, conn *websocket.Conn) *Client {
+	return &Client{
+		UserID:  userID,
+		conn:    conn,
+		send:    make(chan []byte, sendBuffer),
+		syncing: true,
+	}
+}
+
+// WriteLoop 往客户端写 连接关掉之后退出
+func (c *Client) WriteLoop() {
+	for byteData := range c.send {
+		c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		err := websocket.Message.Send(c.conn, string(byteData))
+		if err != nil {
+			logx.Errorf("%d 消息发送失败 %s", c.UserID, err.Error())
+			c.Close()
+			return
+		}
+	}
+}
+
+func (c *Client) enqueue(byteData []byte) {
+	select {
+	case c.send <- byteData:
+	default:
+		logx.Errorf("%d 待发送的消息太多 断开连接", c.UserID)
+		c.closed = true
+		close(c.send)
+		c.conn.Close()
+	}
+}
+
+// Send 直接发 补发离线消息和回复客户端的请求用这个
+func (c *Client) Send(frame Frame) {
+	byteData, _ := json.Marshal(frame)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.enqueue(byteData)
+}
+
+// Push 推新消息 还在补发的时候先存着
+func (c *Client) Push(frame Frame) {
+	byteData, _ := json.Marshal(frame)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	if c.syncing {
+		c.pending = append(c.pending, byteData)
+		return
+	}
+	c.enqueue(byteData)
+}
+
+// StartSync 开始补发 新消息先存着
+func (c *Client) StartSync() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.syncing = true
+}
+
+// SyncDone 离线消息补发完了 把补发期间的新消息发出去
+func (c *Client) SyncDone() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.syncing = false
+	for _, byteData := range c.pending {
+		if c.closed {
+			break
+		}
+		c.enqueue(byteData)
+	}
+	c.pending = nil
+}
+
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.send)
+	c.conn.Close()
+}
+
+// Hub 这个服务上所有的连接 按用户id分组
+type Hub struct {
+	mu        sync.RWMutex
+	clientMap map[uint]map[*Client]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{clientMap: map[uint]map[*Client]struct{}{}}
+}
+
+// Register 加一个连接 first是这个用户的第一个连接 也就是刚上线
+func (h *Hub) Register(c *Client) (first bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clients, ok := h.clientMap[c.UserID]
+	if !ok {
+		clients = map[*Client]struct{}{}
+		h.clientMap[c.UserID] = clients
+	}
+	clients[c] = struct{}{}
+	return len(clients) == 1
+}
+
+// Unregister 去掉一个连接 last是这个用户最后一个连接 也就是下线了
+func (h *Hub) Unregister(c *Client) (last bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clients, ok := h.clientMap[c.UserID]
+	if !ok {
+		return false
+	}
+	delete(clients, c)
+	if len(clients) > 0 {
+		return false
+	}
+	delete(h.clientMap, c.UserID)
+	return true
+}
+
+// Online 用户在不在这个服务上
+func (h *Hub) Online(userID uint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clientMap[userID]) > 0
+}
+
+// Push 推给用户所有的连接
+func (h *Hub) Push(userID uint, frame Frame) {
+	h.PushExcept(userID, nil, frame)
+}
+
+// PushExcept 推给用户除了except之外的连接 一个设备接了电话 其他设备停止响铃
+func (h *Hub) PushExcept(userID uint, except *Client, frame Frame) {
+	h.mu.RLock()
+	var list []*Client
+	for c := range h.clientMap[userID] {
+		if c != except {
+			list = append(list, c)
+		}
+	}
+	h.mu.RUnlock()
+	for _, c := range list {
+		c.Push(frame)
+	}
+}
+
+// Kick 断开用户所有的连接 封号之后用 token已经吊销了 重连也连不上
+func (h *Hub) Kick(userID uint) {
+	h.mu.RLock()
+	var list []*Client
+	for c := range h.clientMap[userID] {
+		list = append(list, c)
+	}
+	h.mu.RUnlock()
+	for _, c := range list {
+		c.Close()
+	}
+}
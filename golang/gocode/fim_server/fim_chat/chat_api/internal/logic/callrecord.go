@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"fim_server/common/models/ctype"
+	"fim_server/fim_chat/chat_api/internal/call"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_models"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// RecordCalls 通话结束之后存一条通话记录 当成发起方发给接收方的消息
+func RecordCalls(svcCtx *svc.ServiceContext) {
+	for c := range svcCtx.Calls.Ended() {
+		chat := chat_models.ChatModel{
+			SendUserID: c.CallerID,
+			RevUserID:  c.CalleeID,
+		}
+		if c.Type == call.VideoCall {
+			chat.MsgType = ctype.VideoCallMsgType
+			chat.Msg = ctype.Msg{Type: ctype.VideoCallMsgType, VideoCallMsg: &ctype.VideoCallMsg{
+				StartTime: c.StartTime,
+				EndTime:   c.EndTime,
+				EndReason: c.EndReason,
+			}}
+		} else {
+			chat.MsgType = ctype.VoiceCallMsgType
+			chat.Msg = ctype.Msg{Type: ctype.VoiceCallMsgType, VoiceCallMsg: &ctype.VoiceCallMsg{
+				StartTime: c.StartTime,
+				EndTime:   c.EndTime,
+				EndReason: c.EndReason,
+			}}
+		}
+		chat.MsgPreview = chat.MsgPreviewMethod()
+		events, err := saveChat(svcCtx.DB, &chat)
+		if err != nil {
+			logx.Errorf("%s 通话记录保存失败 %s", c.ID, err.Error())
+			continue
+		}
+		pushEvents(svcCtx, events)
+	}
+}
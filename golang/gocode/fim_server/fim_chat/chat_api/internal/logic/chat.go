@@ -0,0 +1,92 @@
+package logic
+
+import (
+	"encoding/json"
+	"fim_server/fim_chat/chat_api/internal/hub"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+	"fim_server/fim_chat/chat_models"
+
+	"gorm.io/gorm"
+)
+
+// 自己发的消息的状态
+const (
+	sentStatus      int8 = iota + 1 // 已发送
+	deliveredStatus                 // 已送达
+	readStatus                      // 已读
+)
+
+// getAck 某个人在某个会话的送达和已读位置 没有就是都没收到
+func getAck(db *gorm.DB, userID, targetID uint) (ack chat_models.ChatAckModel) {
+	db.Take(&ack, "user_id = ? and target_id = ?", userID, targetID)
+	return
+}
+
+// msgStatus 对方的位置到了哪里 自己发的消息就是什么状态
+func msgStatus(chat chat_models.ChatModel, userID uint, peerAck chat_models.ChatAckModel) int8 {
+	if chat.SendUserID != userID || chat.SystemMsg != nil {
+		return 0
+	}
+	if chat.ID <= peerAck.ReadMsgID {
+		return readStatus
+	}
+	if chat.ID <= peerAck.DeliveredMsgID {
+		return deliveredStatus
+	}
+	return sentStatus
+}
+
+// chatMessage userID是看消息的人
+func chatMessage(chat chat_models.ChatModel, userID uint, peerAck chat_models.ChatAckModel) types.ChatMessage {
+	return types.ChatMessage{
+		ID:         chat.ID,
+		SendUserID: chat.SendUserID,
+		RevUserID:  chat.RevUserID,
+		MsgType:    int8(chat.MsgType),
+		Msg:        chat.Msg,
+		SystemMsg:  chat.SystemMsg,
+		CreatedAt:  chat.CreatedAt,
+		Status:     msgStatus(chat, userID, peerAck),
+	}
+}
+
+// eventFrame 收件箱的事件推给客户端 内容入库的时候已经是json了
+func eventFrame(inbox chat_models.InboxModel) hub.Frame {
+	return hub.Frame{Type: inbox.Type, Seq: inbox.Seq, Data: json.RawMessage(inbox.Data)}
+}
+
+// saveChat 消息入库 发送人和接收人的收件箱里面都加一个msg事件 被系统拦截的只有发送人有
+func saveChat(db *gorm.DB, chat *chat_models.ChatModel) (events []chat_models.InboxModel, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(chat).Error
+		if err != nil {
+			return err
+		}
+		userIDList := []uint{chat.SendUserID}
+		if chat.SystemMsg == nil {
+			userIDList = append(userIDList, chat.RevUserID)
+			err = chat_models.SaveMsgFile(tx, chat_models.MsgFileModel{
+				Source:     chat_models.ChatMsgSource,
+				MsgID:      chat.ID,
+				SendUserID: chat.SendUserID,
+				RevUserID:  chat.RevUserID,
+			}, chat.Msg)
+			if err != nil {
+				return err
+			}
+		}
+		events, err = chat_models.AppendInboxList(tx, userIDList, chat_models.MsgEvent, func(userID uint) any {
+			return chatMessage(*chat, userID, chat_models.ChatAckModel{})
+		})
+		return err
+	})
+	return
+}
+
+// pushEvents 事务提交之后推给在线的设备 推的顺序乱了客户端会按seq发现再补
+func pushEvents(svcCtx *svc.ServiceContext, list []chat_models.InboxModel) {
+	for _, inbox := range list {
+		svcCtx.Hub.Push(inbox.UserID, eventFrame(inbox))
+	}
+}
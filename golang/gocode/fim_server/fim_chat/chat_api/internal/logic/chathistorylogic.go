@@ -0,0 +1,73 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/common/list_query"
+	"fim_server/fim_chat/chat_models"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ChatHistoryLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChatHistoryLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChatHistoryLogic {
+	return &ChatHistoryLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ChatHistoryLogic) ChatHistory(req *types.ChatHistoryRequest) (resp *types.ChatHistoryResponse, err error) {
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+	db := l.svcCtx.DB
+	// 对方被系统拦截的消息自己看不到
+	where := db.Where("(send_user_id = ? and rev_user_id = ?) or (send_user_id = ? and rev_user_id = ? and system_msg is null)",
+		req.UserID, req.FriendID, req.FriendID, req.UserID)
+	list, next, err := list_query.CursorQuery(db, chat_models.ChatModel{}, list_query.Option{
+		Where: where,
+	}, list_query.CursorOption{
+		Column: "id",
+		Desc:   true,
+		Cursor: req.Cursor,
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("查询聊天记录失败")
+	}
+
+	ack := getAck(db, req.UserID, req.FriendID)
+	peerAck := getAck(db, req.FriendID, req.UserID)
+	resp = &types.ChatHistoryResponse{
+		List:               make([]types.ChatMessage, 0, len(list)),
+		Next:               next,
+		ReadMsgID:          ack.ReadMsgID,
+		PeerDeliveredMsgID: peerAck.DeliveredMsgID,
+		PeerReadMsgID:      peerAck.ReadMsgID,
+	}
+	var msgIDList []uint
+	for _, chat := range list {
+		msgIDList = append(msgIDList, chat.ID)
+	}
+	reactionList, err := reactionMap(db, &chat_models.ChatReactionModel{}, msgIDList, req.UserID)
+	if err != nil {
+		logx.Error(err)
+	}
+	for _, chat := range list {
+		message := chatMessage(chat, req.UserID, peerAck)
+		message.Reactions = reactionList[chat.ID]
+		resp.List = append(resp.List, message)
+	}
+	return resp, nil
+}
@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"encoding/base64"
+	"errors"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_user/user_models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// 公钥变化的动作
+const (
+	keyUpdate = "update" // 新设备或者身份公钥变了
+	keyRemove = "remove" // 设备退出了
+)
+
+// keyChange 推给好友的key事件
+type keyChange struct {
+	UserID   uint   `json:"userID"`
+	DeviceID string `json:"deviceID"`
+	Action   string `json:"action"`
+}
+
+// checkKey 公钥和签名都是base64 不校验具体的算法 客户端自己验
+func checkKey(name string, key string, minLen, maxLen int) error {
+	byteData, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("%s不是base64", name)
+	}
+	if len(byteData) < minLen || len(byteData) > maxLen {
+		return fmt.Errorf("%s长度错误", name)
+	}
+	return nil
+}
+
+func checkDeviceID(deviceID string) error {
+	if deviceID == "" || len(deviceID) > 64 {
+		return errors.New("设备id错误")
+	}
+	return nil
+}
+
+// keyChangeEvents 设备公钥变了 好友和自己的其他设备都要知道 在事务里面调用
+func keyChangeEvents(tx *gorm.DB, userID uint, deviceID string, action string) (events []chat_models.InboxModel, err error) {
+	change := keyChange{UserID: userID, DeviceID: deviceID, Action: action}
+	userIDList := []uint{userID}
+	var friend user_models.FriendModel
+	for _, f := range friend.Friends(tx, userID) {
+		if f.SendUserID == userID {
+			userIDList = append(userIDList, f.RevUserID)
+		} else {
+			userIDList = append(userIDList, f.SendUserID)
+		}
+	}
+	return chat_models.AppendInboxList(tx, userIDList, chat_models.KeyEvent, func(uint) any {
+		return change
+	})
+}
+
+func oneTimePreKeyCount(db *gorm.DB, userID uint, deviceID string) (count int64) {
+	db.Model(&chat_models.OneTimePreKeyModel{}).Where("user_id = ? and device_id = ?", userID, deviceID).Count(&count)
+	return
+}
@@ -0,0 +1,69 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_user/user_models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ChatKeyBundleLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChatKeyBundleLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChatKeyBundleLogic {
+	return &ChatKeyBundleLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ChatKeyBundleLogic) ChatKeyBundle(req *types.ChatKeyBundleRequest) (resp *types.ChatKeyBundleResponse, err error) {
+	if req.TargetID != req.UserID {
+		var friend user_models.FriendModel
+		if !friend.IsFriend(l.svcCtx.DB, req.UserID, req.TargetID) {
+			return nil, errors.New("你们还不是好友")
+		}
+	}
+	var deviceList []chat_models.DeviceKeyModel
+	l.svcCtx.DB.Order("id").Find(&deviceList, "user_id = ?", req.TargetID)
+
+	resp = &types.ChatKeyBundleResponse{List: []types.ChatKeyBundle{}}
+	for _, device := range deviceList {
+		bundle := types.ChatKeyBundle{
+			DeviceID:    device.DeviceID,
+			IdentityKey: device.IdentityKey,
+			SignedPreKey: types.SignedPreKey{
+				KeyID:     device.SignedPreKeyID,
+				PublicKey: device.SignedPreKey,
+				Signature: device.SignedPreKeySig,
+			},
+		}
+		// 每个设备取走一个一次性预密钥 同时取的人拿到的不一样
+		var key chat_models.OneTimePreKeyModel
+		err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("user_id = ? and device_id = ?", device.UserID, device.DeviceID).
+				Order("id").Take(&key).Error
+			if err != nil {
+				return err
+			}
+			return tx.Delete(&key).Error
+		})
+		if err == nil {
+			bundle.OneTimePreKey = &types.PreKey{KeyID: key.KeyID, PublicKey: key.PublicKey}
+		}
+		resp.List = append(resp.List, bundle)
+	}
+	return resp, nil
+}
@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"context"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestChatKeyBundleOneTime 一次性预密钥取一次就删掉 再取就没有了 只给签名预密钥
+func TestChatKeyBundleOneTime(t *testing.T) {
+	db, mock := testDB(t)
+	l := NewChatKeyBundleLogic(context.Background(), &svc.ServiceContext{DB: db})
+
+	expectBundle := func(keyRows *sqlmock.Rows) {
+		mock.ExpectQuery("FROM `friend_models`").WithArgs(1, 2, 2, 1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "send_user_id", "rev_user_id"}).AddRow(1, 1, 2))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM `device_key_models` WHERE user_id = ?")).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "device_id", "signed_pre_key_id"}).AddRow(1, 2, "phone", 3))
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("FROM `one_time_pre_key_models` WHERE user_id = ? and device_id = ? ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED")).
+			WithArgs(2, "phone", 1).WillReturnRows(keyRows)
+	}
+
+	expectBundle(sqlmock.NewRows([]string{"id", "user_id", "device_id", "key_id", "public_key"}).AddRow(7, 2, "phone", 100, "a2V5"))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `one_time_pre_key_models` WHERE `one_time_pre_key_models`.`id` = ?")).
+		WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	resp, err := l.ChatKeyBundle(&types.ChatKeyBundleRequest{UserID: 1, TargetID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := resp.List[0].OneTimePreKey
+	if key == nil || key.KeyID != 100 || resp.List[0].SignedPreKey.KeyID != 3 {
+		t.Fatalf("第一次要取到一次性预密钥 %+v", resp.List[0])
+	}
+
+	// 已经被删了 第二次取不到
+	expectBundle(sqlmock.NewRows([]string{"id", "user_id", "device_id", "key_id", "public_key"}))
+	mock.ExpectRollback()
+	resp, err = l.ChatKeyBundle(&types.ChatKeyBundleRequest{UserID: 1, TargetID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.List[0].OneTimePreKey != nil {
+		t.Errorf("同一个一次性预密钥给了两次 %+v", resp.List[0].OneTimePreKey)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,34 @@
+package logic
+
+import (
+	"context"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ChatKeyCountLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChatKeyCountLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChatKeyCountLogic {
+	return &ChatKeyCountLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ChatKeyCountLogic) ChatKeyCount(req *types.ChatKeyCountRequest) (resp *types.ChatKeyCountResponse, err error) {
+	err = checkDeviceID(req.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ChatKeyCountResponse{
+		OneTimePreKeyCount: oneTimePreKeyCount(l.svcCtx.DB, req.UserID, req.DeviceID),
+	}, nil
+}
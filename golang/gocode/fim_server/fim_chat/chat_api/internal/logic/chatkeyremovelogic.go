@@ -0,0 +1,56 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_chat/chat_models"
+	"gorm.io/gorm"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ChatKeyRemoveLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChatKeyRemoveLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChatKeyRemoveLogic {
+	return &ChatKeyRemoveLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ChatKeyRemoveLogic) ChatKeyRemove(req *types.ChatKeyRemoveRequest) (resp *types.ChatKeyRemoveResponse, err error) {
+	err = checkDeviceID(req.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	var events []chat_models.InboxModel
+	err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&chat_models.DeviceKeyModel{}, "user_id = ? and device_id = ?", req.UserID, req.DeviceID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		err := tx.Delete(&chat_models.OneTimePreKeyModel{}, "user_id = ? and device_id = ?", req.UserID, req.DeviceID).Error
+		if err != nil {
+			return err
+		}
+		events, err = keyChangeEvents(tx, req.UserID, req.DeviceID, keyRemove)
+		return err
+	})
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("删除失败")
+	}
+	pushEvents(l.svcCtx, events)
+	return &types.ChatKeyRemoveResponse{}, nil
+}
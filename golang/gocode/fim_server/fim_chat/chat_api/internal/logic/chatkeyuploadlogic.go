@@ -0,0 +1,120 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_chat/chat_models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ChatKeyUploadLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChatKeyUploadLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChatKeyUploadLogic {
+	return &ChatKeyUploadLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// maxOneTimePreKeys 每个设备最多存多少个一次性预密钥
+const maxOneTimePreKeys = 200
+
+func (l *ChatKeyUploadLogic) ChatKeyUpload(req *types.ChatKeyUploadRequest) (resp *types.ChatKeyUploadResponse, err error) {
+	err = checkDeviceID(req.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	err = checkKey("身份公钥", req.IdentityKey, 32, 64)
+	if err != nil {
+		return nil, err
+	}
+	err = checkKey("签名预密钥", req.SignedPreKey.PublicKey, 32, 64)
+	if err != nil {
+		return nil, err
+	}
+	err = checkKey("签名预密钥的签名", req.SignedPreKey.Signature, 64, 128)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.OneTimePreKeys) > maxOneTimePreKeys {
+		return nil, errors.New("一次性预密钥太多了")
+	}
+	for _, key := range req.OneTimePreKeys {
+		err = checkKey("一次性预密钥", key.PublicKey, 32, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var events []chat_models.InboxModel
+	err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		var device chat_models.DeviceKeyModel
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Take(&device, "user_id = ? and device_id = ?", req.UserID, req.DeviceID).Error
+		exist := err == nil
+		changed := !exist || device.IdentityKey != req.IdentityKey
+		if exist && changed {
+			// 身份公钥换了 之前的一次性预密钥也不能用了
+			err = tx.Delete(&chat_models.OneTimePreKeyModel{}, "user_id = ? and device_id = ?", req.UserID, req.DeviceID).Error
+			if err != nil {
+				return err
+			}
+		}
+		device.UserID = req.UserID
+		device.DeviceID = req.DeviceID
+		device.IdentityKey = req.IdentityKey
+		device.SignedPreKeyID = req.SignedPreKey.KeyID
+		device.SignedPreKey = req.SignedPreKey.PublicKey
+		device.SignedPreKeySig = req.SignedPreKey.Signature
+		err = tx.Save(&device).Error
+		if err != nil {
+			return err
+		}
+
+		if len(req.OneTimePreKeys) > 0 {
+			if oneTimePreKeyCount(tx, req.UserID, req.DeviceID)+int64(len(req.OneTimePreKeys)) > maxOneTimePreKeys {
+				return errors.New("一次性预密钥太多了")
+			}
+			var keyList []chat_models.OneTimePreKeyModel
+			for _, key := range req.OneTimePreKeys {
+				keyList = append(keyList, chat_models.OneTimePreKeyModel{
+					UserID:    req.UserID,
+					DeviceID:  req.DeviceID,
+					KeyID:     key.KeyID,
+					PublicKey: key.PublicKey,
+				})
+			}
+			err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&keyList).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if changed {
+			events, err = keyChangeEvents(tx, req.UserID, req.DeviceID, keyUpdate)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("公钥上传失败")
+	}
+	pushEvents(l.svcCtx, events)
+	return &types.ChatKeyUploadResponse{
+		OneTimePreKeyCount: oneTimePreKeyCount(l.svcCtx.DB, req.UserID, req.DeviceID),
+	}, nil
+}
@@ -0,0 +1,245 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/common/models"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_group/group_models"
+	"fim_server/fim_user/user_models"
+	"fim_server/utils/highlight"
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ChatSearchLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChatSearchLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChatSearchLogic {
+	return &ChatSearchLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// searchRow 两边消息查出来的公共字段
+type searchRow struct {
+	Source        string
+	ID            uint
+	TargetID      uint
+	SendUserID    uint
+	MsgType       int8
+	SearchContent string
+	CreatedAt     string
+}
+
+// minMatchLen ngram分词是两个字一组 一个字的关键词全文索引搜不到 只能用like
+const minMatchLen = 2
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// matchQuery 每个关键词都要包含
+func matchQuery(db *gorm.DB, column string, terms []string) *gorm.DB {
+	query := db.Where("")
+	var matchList []string
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) < minMatchLen {
+			query.Where(fmt.Sprintf("%s like ?", column), "%"+likeEscaper.Replace(term)+"%")
+			continue
+		}
+		matchList = append(matchList, `+"`+term+`"`)
+	}
+	if len(matchList) > 0 {
+		query.Where(fmt.Sprintf("match(%s) against(? in boolean mode)", column), strings.Join(matchList, " "))
+	}
+	return query
+}
+
+func parseDate(date string, end bool) (string, error) {
+	t, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return "", errors.New("日期格式错误 比如 2024-01-01")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t.Format(models.TimeFormat), nil
+}
+
+func (l *ChatSearchLogic) ChatSearch(req *types.ChatSearchRequest) (resp *types.ChatSearchResponse, err error) {
+	var terms []string
+	for _, term := range strings.Fields(req.Key) {
+		// 布尔模式下引号有特殊含义
+		term = strings.ReplaceAll(term, `"`, "")
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, errors.New("请输入搜索内容")
+	}
+	if len(terms) > 5 {
+		return nil, errors.New("关键词最多5个")
+	}
+	switch req.Source {
+	case "", "chat", "group":
+	default:
+		return nil, errors.New("消息来源错误")
+	}
+	if req.TargetID != 0 && req.Source == "" {
+		return nil, errors.New("按会话搜索要传消息来源")
+	}
+	var start, end string
+	if req.Start != "" {
+		start, err = parseDate(req.Start, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.End != "" {
+		end, err = parseDate(req.End, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	db := l.svcCtx.DB
+	var queryList []any
+
+	if req.Source != "group" {
+		// 私聊 自己发的和发给自己的
+		query := db.Model(&chat_models.ChatModel{}).
+			Select("'chat' as source, id, case when send_user_id = ? then rev_user_id else send_user_id end as target_id, send_user_id, msg_type, search_content, created_at", req.UserID).
+			Where("(send_user_id = ? or rev_user_id = ?)", req.UserID, req.UserID).
+			Where(matchQuery(db, "search_content", terms))
+		if req.TargetID != 0 {
+			query = query.Where("(send_user_id = ? or rev_user_id = ?)", req.TargetID, req.TargetID)
+		}
+		if req.SendUserID != 0 {
+			query = query.Where("send_user_id = ?", req.SendUserID)
+		}
+		if req.MsgType != 0 {
+			query = query.Where("msg_type = ?", req.MsgType)
+		}
+		if start != "" {
+			query = query.Where("created_at >= ?", start)
+		}
+		if end != "" {
+			query = query.Where("created_at < ?", end)
+		}
+		queryList = append(queryList, query)
+	}
+
+	if req.Source != "chat" {
+		// 群聊 只能搜自己在的群 进群之前的消息也搜不到
+		query := db.Model(&group_models.GroupMsgModel{}).
+			Select("'group' as source, group_msg_models.id, group_msg_models.group_id as target_id, group_msg_models.send_user_id, group_msg_models.msg_type, group_msg_models.search_content, group_msg_models.created_at").
+			Joins("join group_member_models gm on gm.group_id = group_msg_models.group_id and gm.user_id = ?", req.UserID).
+			Where("group_msg_models.created_at >= gm.created_at").
+			Where(matchQuery(db, "group_msg_models.search_content", terms))
+		if req.TargetID != 0 {
+			query = query.Where("group_msg_models.group_id = ?", req.TargetID)
+		}
+		if req.SendUserID != 0 {
+			query = query.Where("group_msg_models.send_user_id = ?", req.SendUserID)
+		}
+		if req.MsgType != 0 {
+			query = query.Where("group_msg_models.msg_type = ?", req.MsgType)
+		}
+		if start != "" {
+			query = query.Where("group_msg_models.created_at >= ?", start)
+		}
+		if end != "" {
+			query = query.Where("group_msg_models.created_at < ?", end)
+		}
+		queryList = append(queryList, query)
+	}
+
+	union := "(?)"
+	if len(queryList) == 2 {
+		union = "(?) union all (?)"
+	}
+
+	resp = new(types.ChatSearchResponse)
+	err = db.Table("("+union+") as t", queryList...).Count(&resp.Count).Error
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("搜索失败")
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 || req.Limit > 50 {
+		req.Limit = 20
+	}
+	var rowList []searchRow
+	err = db.Table("("+union+") as t", queryList...).
+		Order("created_at desc, id desc").
+		Limit(req.Limit).Offset((req.Page - 1) * req.Limit).
+		Scan(&rowList).Error
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("搜索失败")
+	}
+
+	// 查出发送人和会话的名字
+	var userIDList, groupIDList []uint
+	for _, row := range rowList {
+		userIDList = append(userIDList, row.SendUserID)
+		if row.Source == "chat" {
+			userIDList = append(userIDList, row.TargetID)
+		} else {
+			groupIDList = append(groupIDList, row.TargetID)
+		}
+	}
+	userMap := map[uint]user_models.UserModel{}
+	if len(userIDList) > 0 {
+		var userList []user_models.UserModel
+		db.Find(&userList, userIDList)
+		for _, user := range userList {
+			userMap[user.ID] = user
+		}
+	}
+	groupMap := map[uint]group_models.GroupModel{}
+	if len(groupIDList) > 0 {
+		var groupList []group_models.GroupModel
+		db.Find(&groupList, groupIDList)
+		for _, group := range groupList {
+			groupMap[group.ID] = group
+		}
+	}
+
+	resp.List = make([]types.ChatSearchInfo, 0, len(rowList))
+	for _, row := range rowList {
+		targetName := groupMap[row.TargetID].Title
+		if row.Source == "chat" {
+			targetName = userMap[row.TargetID].Nickname
+		}
+		resp.List = append(resp.List, types.ChatSearchInfo{
+			Source:           row.Source,
+			MsgID:            row.ID,
+			TargetID:         row.TargetID,
+			TargetName:       targetName,
+			SendUserID:       row.SendUserID,
+			SendUserNickname: userMap[row.SendUserID].Nickname,
+			SendUserAvatar:   userMap[row.SendUserID].Avatar,
+			MsgType:          row.MsgType,
+			Snippet:          highlight.Snippet(row.SearchContent, terms, 40),
+			CreatedAt:        row.CreatedAt,
+		})
+	}
+	return resp, nil
+}
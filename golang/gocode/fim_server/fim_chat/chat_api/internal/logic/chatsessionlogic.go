@@ -0,0 +1,144 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_user/user_models"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ChatSessionLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChatSessionLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChatSessionLogic {
+	return &ChatSessionLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+type sessionRow struct {
+	TargetID uint
+	MaxID    uint
+}
+
+type unreadRow struct {
+	SendUserID uint
+	Count      int64
+}
+
+func (l *ChatSessionLogic) ChatSession(req *types.ChatSessionRequest) (resp *types.ChatSessionResponse, err error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+	db := l.svcCtx.DB
+
+	// 按对方分组 每个会话最新的一条消息 对方被系统拦截的消息不算
+	sessionQuery := db.Model(&chat_models.ChatModel{}).
+		Select("case when send_user_id = ? then rev_user_id else send_user_id end as target_id, max(id) as max_id", req.UserID).
+		Where("send_user_id = ? or (rev_user_id = ? and system_msg is null)", req.UserID, req.UserID).
+		Group("target_id")
+
+	// 先拿seq再查会话 中间来的新消息连上websocket之后还会补
+	resp = &types.ChatSessionResponse{
+		List: []types.ChatSessionInfo{},
+		Seq:  chat_models.InboxSeq(db, req.UserID),
+	}
+	err = db.Table("(?) as t", sessionQuery).Count(&resp.Count).Error
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("查询会话失败")
+	}
+	var rowList []sessionRow
+	err = db.Table("(?) as t", sessionQuery).
+		Order("max_id desc").
+		Limit(req.Limit).Offset((req.Page - 1) * req.Limit).
+		Scan(&rowList).Error
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("查询会话失败")
+	}
+	if len(rowList) == 0 {
+		return resp, nil
+	}
+
+	var targetIDList, msgIDList []uint
+	for _, row := range rowList {
+		targetIDList = append(targetIDList, row.TargetID)
+		msgIDList = append(msgIDList, row.MaxID)
+	}
+
+	var chatList []chat_models.ChatModel
+	db.Find(&chatList, msgIDList)
+	chatMap := map[uint]chat_models.ChatModel{}
+	for _, chat := range chatList {
+		chatMap[chat.ID] = chat
+	}
+
+	var userList []user_models.UserModel
+	db.Find(&userList, targetIDList)
+	userMap := map[uint]user_models.UserModel{}
+	for _, user := range userList {
+		userMap[user.ID] = user
+	}
+
+	var ackList []chat_models.ChatAckModel
+	db.Find(&ackList, "(user_id = ? and target_id in ?) or (user_id in ? and target_id = ?)",
+		req.UserID, targetIDList, targetIDList, req.UserID)
+	ackMap := map[uint]chat_models.ChatAckModel{}
+	peerAckMap := map[uint]chat_models.ChatAckModel{}
+	for _, ack := range ackList {
+		if ack.UserID == req.UserID {
+			ackMap[ack.TargetID] = ack
+		} else {
+			peerAckMap[ack.UserID] = ack
+		}
+	}
+
+	// 对方发的 id比自己已读位置大的就是未读
+	var unreadList []unreadRow
+	db.Model(&chat_models.ChatModel{}).
+		Select("chat_models.send_user_id, count(*) as count").
+		Joins("left join chat_ack_models a on a.user_id = chat_models.rev_user_id and a.target_id = chat_models.send_user_id").
+		Where("chat_models.rev_user_id = ? and chat_models.send_user_id in ? and chat_models.system_msg is null and chat_models.id > coalesce(a.read_msg_id, 0)",
+			req.UserID, targetIDList).
+		Group("chat_models.send_user_id").
+		Scan(&unreadList)
+	unreadMap := map[uint]int64{}
+	for _, row := range unreadList {
+		unreadMap[row.SendUserID] = row.Count
+	}
+
+	for _, row := range rowList {
+		chat := chatMap[row.MaxID]
+		user := userMap[row.TargetID]
+		peerAck := peerAckMap[row.TargetID]
+		resp.List = append(resp.List, types.ChatSessionInfo{
+			TargetID:           row.TargetID,
+			Nickname:           user.Nickname,
+			Avatar:             user.Avatar,
+			MsgID:              chat.ID,
+			SendUserID:         chat.SendUserID,
+			MsgPreview:         chat.MsgPreview,
+			CreatedAt:          chat.CreatedAt,
+			Status:             msgStatus(chat, req.UserID, peerAck),
+			Unread:             unreadMap[row.TargetID],
+			ReadMsgID:          ackMap[row.TargetID].ReadMsgID,
+			PeerDeliveredMsgID: peerAck.DeliveredMsgID,
+			PeerReadMsgID:      peerAck.ReadMsgID,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,496 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fim_server/common/models"
+	"fim_server/common/models/ctype"
+	"fim_server/common/moderation"
+	"fim_server/fim_chat/chat_api/internal/call"
+	"fim_server/fim_chat/chat_api/internal/hub"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_group/group_models"
+	"fim_server/fim_user/user_models"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+	"time"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ChatWebsocketLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChatWebsocketLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChatWebsocketLogic {
+	return &ChatWebsocketLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// syncBatch 补发的时候一次查多少条
+const syncBatch = 200
+
+// maxSync 落后太多就不一条一条补了 让客户端重新拉会话和聊天记录
+const maxSync = 5000
+
+// recallTime 只能撤回这么久以内的消息
+const recallTime = 2 * time.Minute
+
+// sendTypeMap 客户端可以直接发的消息类型
+var sendTypeMap = map[ctype.MsgType]bool{
+	ctype.TextMsgType:      true,
+	ctype.ImageMsgType:     true,
+	ctype.VideoMsgType:     true,
+	ctype.FileMsgType:      true,
+	ctype.VoiceMsgType:     true,
+	ctype.ImageTextMsgType: true,
+	ctype.EncryptedMsgType: true,
+	ctype.LocationMsgType:  true,
+	ctype.CardMsgType:      true,
+	ctype.StickerMsgType:   true,
+}
+
+// groupSendTypeMap 群里可以发的消息类型 群里不能发加密消息 投票只有群里有
+var groupSendTypeMap = map[ctype.MsgType]bool{
+	ctype.TextMsgType:      true,
+	ctype.ImageMsgType:     true,
+	ctype.VideoMsgType:     true,
+	ctype.FileMsgType:      true,
+	ctype.VoiceMsgType:     true,
+	ctype.ImageTextMsgType: true,
+	ctype.LocationMsgType:  true,
+	ctype.CardMsgType:      true,
+	ctype.StickerMsgType:   true,
+	ctype.PollMsgType:      true,
+}
+
+func (l *ChatWebsocketLogic) ChatWebsocket(req *types.ChatWebsocketRequest, conn *websocket.Conn) {
+	client := hub.NewClient(req.UserID, conn)
+	if l.svcCtx.Hub.Register(client) {
+		l.setOnline(req.UserID, true)
+	}
+	defer func() {
+		l.svcCtx.Calls.Disconnect(client)
+		client.Close()
+		if l.svcCtx.Hub.Unregister(client) {
+			l.setOnline(req.UserID, false)
+		}
+	}()
+	go client.WriteLoop()
+
+	// 先注册再补发 补发期间的新事件在补发完之后发
+	l.sync(client, req.Seq)
+
+	for {
+		var text string
+		err := websocket.Message.Receive(conn, &text)
+		if err != nil {
+			return
+		}
+		var request hub.Request
+		err = json.Unmarshal([]byte(text), &request)
+		if err != nil {
+			client.Send(hub.Frame{Type: hub.ErrorType, Msg: "消息格式错误"})
+			continue
+		}
+		switch request.Type {
+		case hub.SendType:
+			l.send(client, request)
+		case hub.DeliveredType, hub.ReadType:
+			l.ack(client, request)
+		case hub.RecallType:
+			l.recall(client, request)
+		case hub.SyncType:
+			client.StartSync()
+			l.sync(client, request.Seq)
+		case hub.CallType:
+			l.call(client, request)
+		case hub.GroupSendType:
+			l.groupSend(client, request)
+		default:
+			client.Send(hub.Frame{Type: hub.ErrorType, ClientMsgID: request.ClientMsgID, Msg: "不支持的消息类型"})
+		}
+	}
+}
+
+func (l *ChatWebsocketLogic) setOnline(userID uint, online bool) {
+	err := l.svcCtx.DB.Model(&user_models.UserConfModel{}).Where("user_id = ?", userID).Update("online", online).Error
+	if err != nil {
+		logx.Error(err)
+	}
+}
+
+// sync 把seq之后的事件按顺序补过来 补完发synced 补不了发reset
+func (l *ChatWebsocketLogic) sync(client *hub.Client, seq uint64) {
+	defer client.SyncDone()
+	db := l.svcCtx.DB
+	current := chat_models.InboxSeq(db, client.UserID)
+	if seq == 0 {
+		// 没有seq的设备只补没送达的消息
+		l.syncUndelivered(client)
+		client.Send(hub.Frame{Type: hub.SyncedType, Data: current})
+		return
+	}
+	if seq > current || current-seq > maxSync {
+		client.Send(hub.Frame{Type: hub.ResetType, Data: current})
+		return
+	}
+	if seq < current {
+		// 要补的事件已经过期删掉了
+		first := chat_models.InboxFirstSeq(db, client.UserID)
+		if first == 0 || seq+1 < first {
+			client.Send(hub.Frame{Type: hub.ResetType, Data: current})
+			return
+		}
+	}
+	for seq < current {
+		list, err := chat_models.InboxAfter(db, client.UserID, seq, syncBatch)
+		if err != nil {
+			logx.Error(err)
+			client.Send(hub.Frame{Type: hub.ErrorType, Msg: "同步失败"})
+			return
+		}
+		if len(list) == 0 {
+			break
+		}
+		for _, inbox := range list {
+			client.Send(eventFrame(inbox))
+			seq = inbox.Seq
+		}
+	}
+	client.Send(hub.Frame{Type: hub.SyncedType, Data: seq})
+}
+
+// syncUndelivered 补发不在线的时候收到的消息 从每个会话送达的位置往后 按消息id的顺序发
+// 客户端确认送达之前断开了 下次上线还会再发 客户端按消息id去重
+func (l *ChatWebsocketLogic) syncUndelivered(client *hub.Client) {
+	var lastID uint
+	for {
+		var list []chat_models.ChatModel
+		err := l.svcCtx.DB.
+			Joins("left join chat_ack_models a on a.user_id = chat_models.rev_user_id and a.target_id = chat_models.send_user_id").
+			Where("chat_models.rev_user_id = ? and chat_models.system_msg is null and chat_models.id > coalesce(a.delivered_msg_id, 0) and chat_models.id > ?",
+				client.UserID, lastID).
+			Order("chat_models.id").
+			Limit(syncBatch).
+			Find(&list).Error
+		if err != nil {
+			logx.Error(err)
+			return
+		}
+		for _, chat := range list {
+			client.Send(hub.Frame{Type: chat_models.MsgEvent, Data: chatMessage(chat, client.UserID, chat_models.ChatAckModel{})})
+			lastID = chat.ID
+		}
+		if len(list) < syncBatch {
+			return
+		}
+	}
+}
+
+func (l *ChatWebsocketLogic) send(client *hub.Client, req hub.Request) {
+	if !sendTypeMap[req.Msg.Type] {
+		client.Send(hub.Frame{Type: hub.ErrorType, ClientMsgID: req.ClientMsgID, Msg: "不支持的消息类型"})
+		return
+	}
+	chat, events, err := sendChat(l.svcCtx, client.UserID, req.RevUserID, req.Msg)
+	if err != nil {
+		client.Send(hub.Frame{Type: hub.ErrorType, ClientMsgID: req.ClientMsgID, Msg: err.Error()})
+		return
+	}
+	client.Send(hub.Frame{Type: hub.SentType, ClientMsgID: req.ClientMsgID, Data: chatMessage(chat, client.UserID, chat_models.ChatAckModel{})})
+	// 自己的所有设备和对方都会收到msg事件 对方不在线的话上线的时候补
+	pushEvents(l.svcCtx, events)
+}
+
+// sendChat 校验之后入库 被审核拦截的也会入库 只有发送人能看到 消息类型调用的地方检查
+func sendChat(svcCtx *svc.ServiceContext, userID, revUserID uint, msg ctype.Msg) (chat chat_models.ChatModel, events []chat_models.InboxModel, err error) {
+	if revUserID == 0 || revUserID == userID {
+		return chat, nil, errors.New("接收人错误")
+	}
+	err = msg.Validate()
+	if err != nil {
+		return chat, nil, err
+	}
+	err = fillMsg(svcCtx.DB, &msg)
+	if err != nil {
+		return chat, nil, err
+	}
+	if isBan(svcCtx.DB, userID) {
+		return chat, nil, errBan
+	}
+	var friend user_models.FriendModel
+	if !friend.IsFriend(svcCtx.DB, userID, revUserID) {
+		return chat, nil, errors.New("你们还不是好友")
+	}
+	var userConf user_models.UserConfModel
+	err = svcCtx.DB.Take(&userConf, "user_id = ?", userID).Error
+	if err == nil && userConf.IsCurtail(svcCtx.Redis, svcCtx.DB, user_models.CurtailChatType) {
+		return chat, nil, errors.New("你已被限制聊天")
+	}
+	if msg.Type != ctype.EncryptedMsgType {
+		// 开了安全链接 私聊只能发端到端加密的消息
+		if userConf.SecureLink {
+			return chat, nil, errors.New("你开启了安全链接 只能发加密消息")
+		}
+		var revUserConf user_models.UserConfModel
+		err = svcCtx.DB.Take(&revUserConf, "user_id = ?", revUserID).Error
+		if err == nil && revUserConf.SecureLink {
+			return chat, nil, errors.New("对方开启了安全链接 只能发加密消息")
+		}
+	}
+
+	chat = chat_models.ChatModel{
+		SendUserID: userID,
+		RevUserID:  revUserID,
+		MsgType:    msg.Type,
+	}
+	// 系统设置还没发布的时候默认审核 加密消息服务端看不到内容 不审核
+	info := svcCtx.Settings.Load()
+	if msg.Type != ctype.EncryptedMsgType && (info == nil || info.Moderation.Chat) {
+		res := svcCtx.Moderator.Check(&msg)
+		moderation.Audit(svcCtx.DB, "chat", userID, revUserID, msg.Type, res)
+		if res.Action == moderation.Block {
+			chat.SystemMsg = res.SystemMsg
+		}
+	}
+	chat.Msg = msg
+	chat.MsgPreview = chat.MsgPreviewMethod()
+	events, err = saveChat(svcCtx.DB, &chat)
+	if err != nil {
+		logx.Error(err)
+		return chat, nil, errors.New("消息发送失败")
+	}
+	return chat, events, nil
+}
+
+func (l *ChatWebsocketLogic) groupSend(client *hub.Client, req hub.Request) {
+	if !groupSendTypeMap[req.Msg.Type] {
+		client.Send(hub.Frame{Type: hub.ErrorType, ClientMsgID: req.ClientMsgID, Msg: "不支持的消息类型"})
+		return
+	}
+	msg, events, err := sendGroup(l.svcCtx, client.UserID, req.GroupID, req.Msg)
+	if err != nil {
+		client.Send(hub.Frame{Type: hub.ErrorType, ClientMsgID: req.ClientMsgID, Msg: err.Error()})
+		return
+	}
+	client.Send(hub.Frame{Type: hub.SentType, ClientMsgID: req.ClientMsgID, Data: groupMessage(msg)})
+	pushEvents(l.svcCtx, events)
+}
+
+// sendGroup 校验之后入库 被审核拦截的也会入库 只有发送人能看到 消息类型调用的地方检查
+func sendGroup(svcCtx *svc.ServiceContext, userID, groupID uint, msg ctype.Msg) (groupMsg group_models.GroupMsgModel, events []chat_models.InboxModel, err error) {
+	err = msg.Validate()
+	if err != nil {
+		return groupMsg, nil, err
+	}
+	db := svcCtx.DB
+	if isBan(db, userID) {
+		return groupMsg, nil, errBan
+	}
+	member, err := getMember(db, groupID, userID)
+	if err != nil {
+		return groupMsg, nil, err
+	}
+	var group group_models.GroupModel
+	err = db.Take(&group, groupID).Error
+	if err != nil {
+		return groupMsg, nil, errors.New("群不存在")
+	}
+	// 全员禁言的时候只有群主和管理员能说话
+	if group.IsProhibition && member.Role == memberRole {
+		return groupMsg, nil, errors.New("当前群正在全员禁言中")
+	}
+	if member.GetProhibitionTime(svcCtx.Redis, db) != nil {
+		return groupMsg, nil, errors.New("你已被禁言")
+	}
+	var userConf user_models.UserConfModel
+	err = db.Take(&userConf, "user_id = ?", userID).Error
+	if err == nil && userConf.IsCurtail(svcCtx.Redis, db, user_models.CurtailInGroupChatType) {
+		return groupMsg, nil, errors.New("你已被限制在群里聊天")
+	}
+	err = fillMsg(db, &msg)
+	if err != nil {
+		return groupMsg, nil, err
+	}
+
+	groupMsg = group_models.GroupMsgModel{
+		GroupID:       groupID,
+		SendUserID:    userID,
+		GroupMemberID: member.ID,
+		MsgType:       msg.Type,
+	}
+	info := svcCtx.Settings.Load()
+	if info == nil || info.Moderation.Group {
+		res := svcCtx.Moderator.Check(&msg)
+		moderation.Audit(db, "group", userID, groupID, msg.Type, res)
+		if res.Action == moderation.Block {
+			groupMsg.SystemMsg = res.SystemMsg
+		}
+	}
+	groupMsg.Msg = msg
+	groupMsg.MsgPreview = groupMsg.MsgPreviewMethod()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&groupMsg).Error
+		if err != nil {
+			return err
+		}
+		if groupMsg.SystemMsg == nil {
+			err = chat_models.SaveMsgFile(tx, chat_models.MsgFileModel{
+				Source:     chat_models.GroupMsgSource,
+				MsgID:      groupMsg.ID,
+				SendUserID: userID,
+				GroupID:    groupID,
+			}, groupMsg.Msg)
+			if err != nil {
+				return err
+			}
+		}
+		events, err = groupMsgEvents(tx, groupMsg)
+		return err
+	})
+	if err != nil {
+		logx.Error(err)
+		return groupMsg, nil, errors.New("消息发送失败")
+	}
+	return groupMsg, events, nil
+}
+
+// ack 确认送达或者已读 位置只会往前推 发消息的人和自己的其他设备都会收到receipt事件
+func (l *ChatWebsocketLogic) ack(client *hub.Client, req hub.Request) {
+	var chat chat_models.ChatModel
+	err := l.svcCtx.DB.Take(&chat, "id = ? and send_user_id = ? and rev_user_id = ?", req.MsgID, req.TargetID, client.UserID).Error
+	if err != nil {
+		client.Send(hub.Frame{Type: hub.ErrorType, Msg: "消息不存在"})
+		return
+	}
+	var deliveredMsgID, readMsgID uint
+	if req.Type == hub.ReadType {
+		readMsgID = req.MsgID
+	} else {
+		deliveredMsgID = req.MsgID
+	}
+	var events []chat_models.InboxModel
+	err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		ack, err := chat_models.Ack(tx, client.UserID, req.TargetID, deliveredMsgID, readMsgID)
+		if err != nil {
+			return err
+		}
+		receipt := hub.Receipt{
+			UserID:         client.UserID,
+			TargetID:       req.TargetID,
+			DeliveredMsgID: ack.DeliveredMsgID,
+			ReadMsgID:      ack.ReadMsgID,
+		}
+		events, err = chat_models.AppendInboxList(tx, []uint{req.TargetID, client.UserID}, chat_models.ReceiptEvent, func(uint) any {
+			return receipt
+		})
+		return err
+	})
+	if err != nil {
+		logx.Error(err)
+		client.Send(hub.Frame{Type: hub.ErrorType, Msg: "确认失败"})
+		return
+	}
+	pushEvents(l.svcCtx, events)
+}
+
+// recall 撤回自己发的消息 原消息存在撤回消息里面 取出来的时候会去掉
+func (l *ChatWebsocketLogic) recall(client *hub.Client, req hub.Request) {
+	db := l.svcCtx.DB
+	var chat chat_models.ChatModel
+	err := db.Take(&chat, "id = ? and send_user_id = ?", req.MsgID, client.UserID).Error
+	if err != nil {
+		client.Send(hub.Frame{Type: hub.ErrorType, Msg: "消息不存在"})
+		return
+	}
+	if chat.MsgType == ctype.WithdrawMsgType {
+		client.Send(hub.Frame{Type: hub.ErrorType, Msg: "消息已经撤回了"})
+		return
+	}
+	createdAt, err := time.ParseInLocation(models.TimeFormat, chat.CreatedAt, time.Local)
+	if err != nil || time.Since(createdAt) > recallTime {
+		client.Send(hub.Frame{Type: hub.ErrorType, Msg: "只能撤回两分钟以内的消息"})
+		return
+	}
+
+	content := "撤回了一条消息"
+	var userConf user_models.UserConfModel
+	err = db.Take(&userConf, "user_id = ?", client.UserID).Error
+	if err == nil && userConf.RecallMessage != nil && *userConf.RecallMessage != "" {
+		content = *userConf.RecallMessage
+	}
+	origin := chat.Msg
+	msg := ctype.Msg{
+		Type: ctype.WithdrawMsgType,
+		WithdrawMsg: &ctype.WithdrawMsg{
+			Content:   content,
+			MsgID:     chat.ID,
+			OriginMsg: &origin,
+		},
+	}
+
+	var events []chat_models.InboxModel
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&chat).Updates(map[string]any{
+			"msg_type":    ctype.WithdrawMsgType,
+			"msg":         msg,
+			"msg_preview": msg.MsgPreview(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = chat_models.DeleteMsgFile(tx, chat_models.ChatMsgSource, chat.ID)
+		if err != nil {
+			return err
+		}
+		// 推出去的不能带原消息
+		chat.MsgType = ctype.WithdrawMsgType
+		chat.MsgPreview = msg.MsgPreview()
+		chat.Msg = ctype.Msg{
+			Type:        ctype.WithdrawMsgType,
+			WithdrawMsg: &ctype.WithdrawMsg{Content: content, MsgID: chat.ID},
+		}
+		userIDList := []uint{client.UserID}
+		if chat.SystemMsg == nil {
+			userIDList = append(userIDList, chat.RevUserID)
+		}
+		events, err = chat_models.AppendInboxList(tx, userIDList, chat_models.RecallEvent, func(userID uint) any {
+			return chatMessage(chat, userID, chat_models.ChatAckModel{})
+		})
+		return err
+	})
+	if err != nil {
+		logx.Error(err)
+		client.Send(hub.Frame{Type: hub.ErrorType, Msg: "撤回失败"})
+		return
+	}
+	pushEvents(l.svcCtx, events)
+}
+
+// call 通话信令 发起的时候要是好友 其他的信令交给通话管理
+func (l *ChatWebsocketLogic) call(client *hub.Client, req hub.Request) {
+	var err error
+	if req.Action == call.StartAction {
+		var friend user_models.FriendModel
+		if req.RevUserID == 0 || req.RevUserID == client.UserID || !friend.IsFriend(l.svcCtx.DB, client.UserID, req.RevUserID) {
+			err = errors.New("你们还不是好友")
+		} else {
+			err = l.svcCtx.Calls.Start(client, req.RevUserID, req.CallType)
+		}
+	} else {
+		err = l.svcCtx.Calls.Handle(client, req.Action, req.CallID, req.Payload)
+	}
+	if err != nil {
+		client.Send(hub.Frame{Type: hub.ErrorType, Msg: err.Error()})
+	}
+}
@@ -0,0 +1,320 @@
+package logic
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fim_server/common/models/ctype"
+	"fim_server/common/moderation"
+	"fim_server/fim_chat/chat_api/internal/hub"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_models"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"golang.org/x/net/websocket"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func testDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+// testClient 真的建一个websocket连接 服务端是hub的连接 返回客户端这一头用来收帧
+func testClient(t *testing.T, userID uint) (*hub.Client, *websocket.Conn) {
+	ready := make(chan *hub.Client, 1)
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		client := hub.NewClient(userID, conn)
+		ready <- client
+		client.WriteLoop()
+	}))
+	t.Cleanup(server.Close)
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := <-ready
+	t.Cleanup(client.Close)
+	return client, ws
+}
+
+// testFrame 收到的一帧 data还是json 方便比较
+type testFrame struct {
+	Type string          `json:"type"`
+	Seq  uint64          `json:"seq"`
+	Data json.RawMessage `json:"data"`
+}
+
+func receive(t *testing.T, ws *websocket.Conn) (frame testFrame) {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	err := websocket.JSON.Receive(ws, &frame)
+	if err != nil {
+		t.Fatalf("没有收到帧 %v", err)
+	}
+	return frame
+}
+
+func expectSeq(mock sqlmock.Sqlmock, userID uint, seq uint64) {
+	mock.ExpectQuery("FROM `inbox_seq_models` WHERE user_id = \\?").WithArgs(userID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "seq"}).AddRow(userID, seq))
+}
+
+// expectInbox 事件加到这些人的收件箱 userIDList要排好序 data为空就不比较内容
+func expectInbox(mock sqlmock.Sqlmock, userIDList []uint, eventType string, data string) {
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `inbox_seq_models`")).
+		WillReturnResult(sqlmock.NewResult(0, int64(len(userIDList))))
+	seqRows := sqlmock.NewRows([]string{"user_id", "seq"})
+	var args, inboxArgs []driver.Value
+	var dataArg driver.Value = sqlmock.AnyArg()
+	if data != "" {
+		dataArg = data
+	}
+	for _, userID := range userIDList {
+		seqRows.AddRow(userID, 5)
+		args = append(args, userID)
+		inboxArgs = append(inboxArgs, sqlmock.AnyArg(), sqlmock.AnyArg(), userID, 5, eventType, dataArg)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `inbox_seq_models` WHERE user_id in")).
+		WithArgs(args...).WillReturnRows(seqRows)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `inbox_models`")).
+		WithArgs(inboxArgs...).WillReturnResult(sqlmock.NewResult(1, int64(len(userIDList))))
+}
+
+// onlineClient 注册到hub上已经补完消息的连接 推过来的事件直接能收到
+func onlineClient(t *testing.T, h *hub.Hub, userID uint) (*hub.Client, *websocket.Conn) {
+	client, ws := testClient(t, userID)
+	h.Register(client)
+	client.SyncDone()
+	return client, ws
+}
+
+func expectFirstSeq(mock sqlmock.Sqlmock, seq uint64) {
+	mock.ExpectQuery("coalesce\\(min\\(seq\\), 0\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"seq"}).AddRow(seq))
+}
+
+func TestSyncReset(t *testing.T) {
+	cases := []struct {
+		name    string
+		seq     uint64
+		current uint64
+		first   uint64 // 0 不查最早的事件
+	}{
+		{"客户端的seq比服务端的大", 10, 5, 0},
+		{"落后太多", 10, 10 + maxSync + 1, 0},
+		{"要补的事件过期了", 3, 10, 5},
+		{"收件箱被清空了", 3, 10, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, mock := testDB(t)
+			expectSeq(mock, 1, c.current)
+			if c.seq < c.current && c.current-c.seq <= maxSync {
+				expectFirstSeq(mock, c.first)
+			}
+			client, ws := testClient(t, 1)
+			l := NewChatWebsocketLogic(context.Background(), &svc.ServiceContext{DB: db})
+			l.sync(client, c.seq)
+
+			frame := receive(t, ws)
+			if frame.Type != hub.ResetType || string(frame.Data) != jsonString(c.current) {
+				t.Errorf("应该reset到%d %+v", c.current, frame)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestSyncReplay 按seq补事件 补发期间推的新事件在synced之后才发
+func TestSyncReplay(t *testing.T) {
+	db, mock := testDB(t)
+	expectSeq(mock, 1, 3)
+	expectFirstSeq(mock, 1)
+	mock.ExpectQuery("FROM `inbox_models` WHERE user_id = \\? and seq > \\? ORDER BY seq").WithArgs(1, 1, syncBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "seq", "type", "data"}).
+			AddRow(2, 1, 2, "msg", `{"id":20}`).
+			AddRow(3, 1, 3, "group_msg", `{"id":30}`))
+
+	client, ws := testClient(t, 1)
+	client.StartSync()
+	client.Push(hub.Frame{Type: "reaction", Seq: 4, Data: map[string]int{"msgID": 20}})
+	l := NewChatWebsocketLogic(context.Background(), &svc.ServiceContext{DB: db})
+	l.sync(client, 1)
+
+	want := []testFrame{
+		{Type: "msg", Seq: 2, Data: json.RawMessage(`{"id":20}`)},
+		{Type: "group_msg", Seq: 3, Data: json.RawMessage(`{"id":30}`)},
+		{Type: hub.SyncedType, Data: json.RawMessage(`3`)},
+		{Type: "reaction", Seq: 4, Data: json.RawMessage(`{"msgID":20}`)},
+	}
+	for i, w := range want {
+		frame := receive(t, ws)
+		if frame.Type != w.Type || frame.Seq != w.Seq || string(frame.Data) != string(w.Data) {
+			t.Errorf("第%d帧 期望%+v 实际%+v", i, w, frame)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSyncUpToDate(t *testing.T) {
+	db, mock := testDB(t)
+	expectSeq(mock, 1, 7)
+	client, ws := testClient(t, 1)
+	l := NewChatWebsocketLogic(context.Background(), &svc.ServiceContext{DB: db})
+	l.sync(client, 7)
+
+	frame := receive(t, ws)
+	if frame.Type != hub.SyncedType || string(frame.Data) != "7" {
+		t.Errorf("已经是最新的 应该直接synced %+v", frame)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestSyncNewDevice 没有seq的设备只补没送达的私聊消息 不带seq
+func TestSyncNewDevice(t *testing.T) {
+	db, mock := testDB(t)
+	expectSeq(mock, 2, 9)
+	mock.ExpectQuery("FROM `chat_models` left join chat_ack_models").WithArgs(2, 0, syncBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "send_user_id", "rev_user_id", "msg_type", "msg"}).
+			AddRow(5, 1, 2, 1, []byte(`{"type":1,"textMsg":{"content":"你好"}}`)))
+	client, ws := testClient(t, 2)
+	l := NewChatWebsocketLogic(context.Background(), &svc.ServiceContext{DB: db})
+	l.sync(client, 0)
+
+	frame := receive(t, ws)
+	if frame.Type != "msg" || frame.Seq != 0 || !strings.Contains(string(frame.Data), `"id":5`) {
+		t.Errorf("没送达的消息没有补 %+v", frame)
+	}
+	frame = receive(t, ws)
+	if frame.Type != hub.SyncedType || string(frame.Data) != "9" {
+		t.Errorf("补完要带上当前的seq %+v", frame)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func jsonString(v any) string {
+	byteData, _ := json.Marshal(v)
+	return string(byteData)
+}
+
+// TestAckForward 确认的位置只往前推 已读一定也送达了 推给双方的是库里合并之后的位置
+func TestAckForward(t *testing.T) {
+	db, mock := testDB(t)
+	h := hub.NewHub()
+	client, ws := onlineClient(t, h, 2)
+	l := NewChatWebsocketLogic(context.Background(), &svc.ServiceContext{DB: db, Hub: h})
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `chat_models` WHERE id = ? and send_user_id = ? and rev_user_id = ?")).
+		WithArgs(10, 1, 2, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "send_user_id", "rev_user_id"}).AddRow(10, 1, 2))
+	mock.ExpectBegin()
+	// 已读10 送达也是10 已经确认到更后面的不会被改小
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `chat_ack_models`")+".*"+
+		regexp.QuoteMeta("`delivered_msg_id`=greatest(delivered_msg_id, values(delivered_msg_id)),`read_msg_id`=greatest(read_msg_id, values(read_msg_id))")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 2, 1, 10, 10).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `chat_ack_models` WHERE user_id = ? and target_id = ?")).WithArgs(2, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "target_id", "delivered_msg_id", "read_msg_id"}).AddRow(2, 1, 12, 10))
+	receipt := `{"userID":2,"targetID":1,"deliveredMsgID":12,"readMsgID":10}`
+	expectInbox(mock, []uint{1, 2}, chat_models.ReceiptEvent, receipt)
+	mock.ExpectCommit()
+
+	l.ack(client, hub.Request{Type: hub.ReadType, TargetID: 1, MsgID: 10})
+
+	frame := receive(t, ws)
+	if frame.Type != chat_models.ReceiptEvent || string(frame.Data) != receipt {
+		t.Errorf("推给自己其他设备的位置不对 %+v", frame)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// expectSender 发私聊之前查的 没封号 是好友 自己的配置
+func expectSender(mock sqlmock.Sqlmock, userID, revUserID uint, secureLink bool) {
+	mock.ExpectQuery("SELECT `ban` FROM `user_models`").WithArgs(userID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"ban"}).AddRow(false))
+	mock.ExpectQuery("FROM `friend_models`").WithArgs(userID, revUserID, revUserID, userID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "send_user_id", "rev_user_id"}).AddRow(1, userID, revUserID))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `user_conf_models` WHERE user_id = ?")).WithArgs(userID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "secure_link"}).AddRow(userID, secureLink))
+}
+
+// TestSecureLink 开了安全链接 双方谁开了都只能发加密消息 加密消息不审核
+func TestSecureLink(t *testing.T) {
+	text := ctype.Msg{Type: ctype.TextMsgType, TextMsg: &ctype.TextMsg{Content: "你好"}}
+	t.Run("自己开了", func(t *testing.T) {
+		db, mock := testDB(t)
+		expectSender(mock, 1, 2, true)
+		_, _, err := sendChat(&svc.ServiceContext{DB: db}, 1, 2, text)
+		if err == nil || err.Error() != "你开启了安全链接 只能发加密消息" {
+			t.Errorf("明文消息要拦住 %v", err)
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("对方开了", func(t *testing.T) {
+		db, mock := testDB(t)
+		expectSender(mock, 1, 2, false)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM `user_conf_models` WHERE user_id = ?")).WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "secure_link"}).AddRow(2, true))
+		_, _, err := sendChat(&svc.ServiceContext{DB: db}, 1, 2, text)
+		if err == nil || err.Error() != "对方开启了安全链接 只能发加密消息" {
+			t.Errorf("明文消息要拦住 %v", err)
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("加密消息", func(t *testing.T) {
+		db, mock := testDB(t)
+		// 密文里面刚好有敏感词 也不能拦截 不写审核记录
+		moderator := moderation.NewModeratorWithWords([]moderation.Word{{Word: "裸聊", Type: 1, Action: moderation.Block}})
+		msg := ctype.Msg{Type: ctype.EncryptedMsgType, EncryptedMsg: &ctype.EncryptedMsg{
+			SenderDeviceID: "phone",
+			Envelopes:      []ctype.EncryptedEnvelope{{DeviceID: "pc", Type: 2, Body: "裸聊"}},
+		}}
+		expectSender(mock, 1, 2, true)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `chat_models`")).WillReturnResult(sqlmock.NewResult(20, 1))
+		expectInbox(mock, []uint{1, 2}, chat_models.MsgEvent, "")
+		mock.ExpectCommit()
+
+		chat, events, err := sendChat(&svc.ServiceContext{DB: db, Moderator: moderator}, 1, 2, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chat.SystemMsg != nil || len(events) != 2 {
+			t.Errorf("加密消息被拦截了 %+v", chat.SystemMsg)
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
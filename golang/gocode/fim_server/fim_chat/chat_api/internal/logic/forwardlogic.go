@@ -0,0 +1,243 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/common/models/ctype"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_group/group_models"
+	"fim_server/fim_user/user_models"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ForwardLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewForwardLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ForwardLogic {
+	return &ForwardLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// maxForward 逐条转发一次最多多少条 合并转发按聊天记录的上限
+const maxForward = 20
+
+// forwardTypeMap 可以转发的消息类型 回复 引用 at消息转发出去就是普通的文本
+// 加密消息别人解不开 投票的结果和原消息绑在一起 都不能转发
+var forwardTypeMap = map[ctype.MsgType]bool{
+	ctype.TextMsgType:         true,
+	ctype.ImageMsgType:        true,
+	ctype.VideoMsgType:        true,
+	ctype.FileMsgType:         true,
+	ctype.VoiceMsgType:        true,
+	ctype.ReplyMsgType:        true,
+	ctype.QuoteMsgType:        true,
+	ctype.AtMsgType:           true,
+	ctype.ImageTextMsgType:    true,
+	ctype.LocationMsgType:     true,
+	ctype.CardMsgType:         true,
+	ctype.StickerMsgType:      true,
+	ctype.MergeForwardMsgType: true,
+}
+
+func (l *ForwardLogic) Forward(req *types.ForwardRequest) (resp *types.ForwardResponse, err error) {
+	if len(req.MsgIDList) == 0 {
+		return nil, errors.New("请选择要转发的消息")
+	}
+	if req.Merge && len(req.MsgIDList) > ctype.MaxMergeForward {
+		return nil, errors.New("合并转发的消息太多了")
+	}
+	if !req.Merge && len(req.MsgIDList) > maxForward {
+		return nil, errors.New("逐条转发的消息太多了 可以合并转发")
+	}
+	var itemList []ctype.MergeForwardItem
+	var title string
+	if req.IsGroup {
+		itemList, title, err = l.groupSource(req.UserID, req.MsgIDList)
+	} else {
+		itemList, title, err = l.chatSource(req.UserID, req.MsgIDList)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var msgList []ctype.Msg
+	if req.Merge {
+		msgList = append(msgList, ctype.Msg{Type: ctype.MergeForwardMsgType, MergeForwardMsg: &ctype.MergeForwardMsg{
+			Title: title,
+			List:  itemList,
+		}})
+	} else {
+		for _, item := range itemList {
+			msgList = append(msgList, item.Msg)
+		}
+	}
+
+	resp = &types.ForwardResponse{MsgIDList: []uint{}}
+	for _, msg := range msgList {
+		if req.TargetIsGroup {
+			groupMsg, events, err := sendGroup(l.svcCtx, req.UserID, req.TargetID, msg)
+			if err != nil {
+				return resp, err
+			}
+			pushEvents(l.svcCtx, events)
+			resp.MsgIDList = append(resp.MsgIDList, groupMsg.ID)
+			continue
+		}
+		chat, events, err := sendChat(l.svcCtx, req.UserID, req.TargetID, msg)
+		if err != nil {
+			return resp, err
+		}
+		pushEvents(l.svcCtx, events)
+		resp.MsgIDList = append(resp.MsgIDList, chat.ID)
+	}
+	return resp, nil
+}
+
+// chatSource 私聊里面要转发的消息 只能是自己和同一个人的会话里面 自己能看到的消息
+func (l *ForwardLogic) chatSource(userID uint, msgIDList []uint) (itemList []ctype.MergeForwardItem, title string, err error) {
+	var chatList []chat_models.ChatModel
+	l.svcCtx.DB.Order("id").Find(&chatList, "id in ? and (send_user_id = ? or rev_user_id = ?) and system_msg is null",
+		msgIDList, userID, userID)
+	if len(chatList) == 0 || len(chatList) != countUnique(msgIDList) {
+		return nil, "", errors.New("消息不存在")
+	}
+	peerID := chatList[0].SendUserID + chatList[0].RevUserID - userID
+	for _, chat := range chatList {
+		if chat.SendUserID+chat.RevUserID-userID != peerID {
+			return nil, "", errors.New("只能转发同一个会话里面的消息")
+		}
+	}
+
+	userMap := map[uint]user_models.UserModel{}
+	var userList []user_models.UserModel
+	l.svcCtx.DB.Find(&userList, []uint{userID, peerID})
+	for _, user := range userList {
+		userMap[user.ID] = user
+	}
+	for _, chat := range chatList {
+		msg, err := forwardMsg(chat.Msg)
+		if err != nil {
+			return nil, "", err
+		}
+		user := userMap[chat.SendUserID]
+		itemList = append(itemList, ctype.MergeForwardItem{
+			SendUserID: chat.SendUserID,
+			Nickname:   user.Nickname,
+			Avatar:     user.Avatar,
+			Msg:        msg,
+			CreatedAt:  chat.CreatedAt,
+		})
+	}
+	title = userMap[userID].Nickname + "和" + userMap[peerID].Nickname + "的聊天记录"
+	return itemList, title, nil
+}
+
+// groupSource 群里面要转发的消息 要是群成员 进群之前的消息看不到
+func (l *ForwardLogic) groupSource(userID uint, msgIDList []uint) (itemList []ctype.MergeForwardItem, title string, err error) {
+	db := l.svcCtx.DB
+	var msgList []group_models.GroupMsgModel
+	db.Order("id").Find(&msgList, "id in ? and system_msg is null", msgIDList)
+	if len(msgList) == 0 || len(msgList) != countUnique(msgIDList) {
+		return nil, "", errors.New("消息不存在")
+	}
+	groupID := msgList[0].GroupID
+	for _, msg := range msgList {
+		if msg.GroupID != groupID {
+			return nil, "", errors.New("只能转发同一个会话里面的消息")
+		}
+	}
+	member, err := getMember(db, groupID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if msgList[0].CreatedAt < member.CreatedAt {
+		return nil, "", errors.New("消息不存在")
+	}
+
+	// 用发消息的人现在的群昵称 退群了就用用户昵称
+	var userIDList []uint
+	for _, msg := range msgList {
+		userIDList = append(userIDList, msg.SendUserID)
+	}
+	nicknameMap := map[uint]string{}
+	var memberList []group_models.GroupMemberModel
+	db.Find(&memberList, "group_id = ? and user_id in ?", groupID, userIDList)
+	for _, m := range memberList {
+		nicknameMap[m.UserID] = m.MemberNickname
+	}
+	userMap := map[uint]user_models.UserModel{}
+	var userList []user_models.UserModel
+	db.Find(&userList, userIDList)
+	for _, user := range userList {
+		userMap[user.ID] = user
+	}
+	for _, groupMsg := range msgList {
+		msg, err := forwardMsg(groupMsg.Msg)
+		if err != nil {
+			return nil, "", err
+		}
+		user := userMap[groupMsg.SendUserID]
+		nickname := nicknameMap[groupMsg.SendUserID]
+		if nickname == "" {
+			nickname = user.Nickname
+		}
+		itemList = append(itemList, ctype.MergeForwardItem{
+			SendUserID: groupMsg.SendUserID,
+			Nickname:   nickname,
+			Avatar:     user.Avatar,
+			Msg:        msg,
+			CreatedAt:  groupMsg.CreatedAt,
+		})
+	}
+
+	var group group_models.GroupModel
+	db.Take(&group, groupID)
+	return itemList, group.Title + "的聊天记录", nil
+}
+
+// forwardMsg 深拷贝一份原消息 文件还是原来的地址 回复 引用 at消息只留文本
+func forwardMsg(origin ctype.Msg) (msg ctype.Msg, err error) {
+	if !forwardTypeMap[origin.Type] {
+		return msg, errors.New("这条消息不能转发 " + origin.MsgPreview())
+	}
+	var content string
+	switch origin.Type {
+	case ctype.ReplyMsgType:
+		if origin.ReplyMsg != nil {
+			content = origin.ReplyMsg.Content
+		}
+	case ctype.QuoteMsgType:
+		if origin.QuoteMsg != nil {
+			content = origin.QuoteMsg.Content
+		}
+	case ctype.AtMsgType:
+		if origin.AtMsg != nil {
+			content = origin.AtMsg.Content
+		}
+	default:
+		msg, err = origin.Clone()
+		if err != nil {
+			return msg, errors.New("消息转发失败")
+		}
+		return msg, nil
+	}
+	return ctype.Msg{Type: ctype.TextMsgType, TextMsg: &ctype.TextMsg{Content: content}}, nil
+}
+
+func countUnique(idList []uint) int {
+	idMap := map[uint]bool{}
+	for _, id := range idList {
+		idMap[id] = true
+	}
+	return len(idMap)
+}
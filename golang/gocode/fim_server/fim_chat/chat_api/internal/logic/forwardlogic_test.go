@@ -0,0 +1,58 @@
+package logic
+
+import (
+	"context"
+	"fim_server/common/models/ctype"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestForwardMsg 加密消息和投票不能转发 回复 引用 at转发出去只留文本
+func TestForwardMsg(t *testing.T) {
+	cases := []struct {
+		name    string
+		msg     ctype.Msg
+		err     bool
+		msgType ctype.MsgType
+	}{
+		{"加密消息", ctype.Msg{Type: ctype.EncryptedMsgType, EncryptedMsg: &ctype.EncryptedMsg{SenderDeviceID: "phone"}}, true, 0},
+		{"投票", ctype.Msg{Type: ctype.PollMsgType, PollMsg: &ctype.PollMsg{Question: "吃什么", Options: []string{"米饭", "面条"}}}, true, 0},
+		{"回复", ctype.Msg{Type: ctype.ReplyMsgType, ReplyMsg: &ctype.ReplyMsg{MsgID: 1, Content: "好的"}}, false, ctype.TextMsgType},
+		{"文本", ctype.Msg{Type: ctype.TextMsgType, TextMsg: &ctype.TextMsg{Content: "你好"}}, false, ctype.TextMsgType},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			msg, err := forwardMsg(c.msg)
+			if (err != nil) != c.err {
+				t.Fatalf("err %v", err)
+			}
+			if err == nil && (msg.Type != c.msgType || msg.TextMsg == nil) {
+				t.Errorf("转发出去的消息不对 %+v", msg)
+			}
+		})
+	}
+}
+
+// TestForwardBeforeJoin 进群之前的群消息看不到 也不能转发
+func TestForwardBeforeJoin(t *testing.T) {
+	db, mock := testDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `group_msg_models` WHERE id in (?) and system_msg is null ORDER BY id")).WithArgs(40).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "group_id", "send_user_id", "msg_type", "msg", "created_at"}).
+			AddRow(40, 3, 1, 1, []byte(`{"type":1,"textMsg":{"content":"你好"}}`), "2026-01-01 10:00:00"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `group_member_models` WHERE group_id = ? and user_id = ?")).WithArgs(3, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "group_id", "user_id", "role", "created_at"}).
+			AddRow(20, 3, 2, memberRole, "2026-02-01 10:00:00"))
+
+	l := NewForwardLogic(context.Background(), &svc.ServiceContext{DB: db})
+	_, err := l.Forward(&types.ForwardRequest{UserID: 2, IsGroup: true, MsgIDList: []uint{40}, TargetID: 1})
+	if err == nil || err.Error() != "消息不存在" {
+		t.Errorf("进群之前的消息也能转发 %v", err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
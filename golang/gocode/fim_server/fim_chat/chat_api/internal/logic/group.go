@@ -0,0 +1,206 @@
+package logic
+
+import (
+	"errors"
+	"fim_server/common/models/ctype"
+	"fim_server/fim_chat/chat_api/internal/types"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_group/group_models"
+	"fim_server/fim_user/user_models"
+
+	"gorm.io/gorm"
+)
+
+// getMember 不是群成员就报错
+func getMember(db *gorm.DB, groupID, userID uint) (member group_models.GroupMemberModel, err error) {
+	err = db.Take(&member, "group_id = ? and user_id = ?", groupID, userID).Error
+	if err != nil {
+		return member, errors.New("你不是该群成员")
+	}
+	return member, nil
+}
+
+func groupMessage(msg group_models.GroupMsgModel) types.GroupMessage {
+	return types.GroupMessage{
+		ID:         msg.ID,
+		GroupID:    msg.GroupID,
+		SendUserID: msg.SendUserID,
+		MsgType:    int8(msg.MsgType),
+		Msg:        msg.Msg,
+		SystemMsg:  msg.SystemMsg,
+		CreatedAt:  msg.CreatedAt,
+	}
+}
+
+// groupEvents 群里的事件加到每个成员的收件箱 断线的成员上线的时候补 在事务里面调用
+func groupEvents(tx *gorm.DB, groupID uint, eventType string, data any) ([]chat_models.InboxModel, error) {
+	var userIDList []uint
+	err := tx.Model(&group_models.GroupMemberModel{}).Where("group_id = ?", groupID).Pluck("user_id", &userIDList).Error
+	if err != nil {
+		return nil, err
+	}
+	return chat_models.AppendInboxList(tx, userIDList, eventType, func(uint) any {
+		return data
+	})
+}
+
+// groupMsgEvents 新的群消息 被拦截的只有自己的设备能看到
+func groupMsgEvents(tx *gorm.DB, msg group_models.GroupMsgModel) ([]chat_models.InboxModel, error) {
+	if msg.SystemMsg != nil {
+		return chat_models.AppendInboxList(tx, []uint{msg.SendUserID}, chat_models.GroupMsgEvent, func(uint) any {
+			return groupMessage(msg)
+		})
+	}
+	return groupEvents(tx, msg.GroupID, chat_models.GroupMsgEvent, groupMessage(msg))
+}
+
+// fillMsg 名片和表情客户端只传id 名字 头像 表情地址从库里查出来填上 不信客户端传的
+func fillMsg(db *gorm.DB, msg *ctype.Msg) error {
+	switch msg.Type {
+	case ctype.CardMsgType:
+		card := msg.CardMsg
+		if card.Type == ctype.UserCard {
+			var user user_models.UserModel
+			err := db.Take(&user, card.ID).Error
+			if err != nil {
+				return errors.New("分享的用户不存在")
+			}
+			card.Name = user.Nickname
+			card.Avatar = user.Avatar
+			return nil
+		}
+		var group group_models.GroupModel
+		err := db.Take(&group, card.ID).Error
+		if err != nil {
+			return errors.New("分享的群不存在")
+		}
+		card.Name = group.Title
+		card.Avatar = group.Avatar
+	case ctype.StickerMsgType:
+		sticker := msg.StickerMsg
+		var model chat_models.StickerModel
+		err := db.Take(&model, "id = ? and pack_id = ?", sticker.StickerID, sticker.PackID).Error
+		if err != nil {
+			return errors.New("表情不存在")
+		}
+		sticker.Title = model.Title
+		sticker.Src = model.Src
+	}
+	return nil
+}
+
+// getPoll 群成员才能看投票
+func getPoll(db *gorm.DB, msgID, userID uint) (msg group_models.GroupMsgModel, err error) {
+	err = db.Take(&msg, msgID).Error
+	if err != nil || msg.MsgType != ctype.PollMsgType || msg.Msg.PollMsg == nil || msg.SystemMsg != nil {
+		return msg, errors.New("投票不存在")
+	}
+	_, err = getMember(db, msg.GroupID, userID)
+	if err != nil {
+		return msg, err
+	}
+	return msg, nil
+}
+
+// pollResult 每个选项的票数和投票人数 userID不为0的时候带上这个人投的选项
+func pollResult(db *gorm.DB, msg group_models.GroupMsgModel, userID uint) (res types.PollResult, err error) {
+	poll := msg.Msg.PollMsg
+	res = types.PollResult{
+		MsgID:     msg.ID,
+		Counts:    make([]int64, len(poll.Options)),
+		Closed:    poll.Closed(),
+		MyOptions: []int{},
+	}
+	var countList []struct {
+		Option int
+		Count  int64
+	}
+	err = db.Model(&group_models.GroupPollVoteModel{}).Where("msg_id = ?", msg.ID).
+		Group("`option`").Select("`option`, count(*) as count").Scan(&countList).Error
+	if err != nil {
+		return res, err
+	}
+	for _, c := range countList {
+		if c.Option >= 0 && c.Option < len(res.Counts) {
+			res.Counts[c.Option] = c.Count
+		}
+	}
+	err = db.Model(&group_models.GroupPollVoteModel{}).Where("msg_id = ?", msg.ID).
+		Distinct("user_id").Count(&res.Voters).Error
+	if err != nil {
+		return res, err
+	}
+	if userID != 0 {
+		err = db.Model(&group_models.GroupPollVoteModel{}).Where("msg_id = ? and user_id = ?", msg.ID, userID).
+			Order("`option`").Pluck("`option`", &res.MyOptions).Error
+	}
+	return res, err
+}
+
+// 群角色
+const (
+	ownerRole  int8 = 1 // 群主
+	adminRole  int8 = 2 // 管理员
+	memberRole int8 = 3 // 普通成员
+)
+
+// memberName 群昵称 没设置就用用户昵称
+func memberName(db *gorm.DB, member group_models.GroupMemberModel) string {
+	if member.MemberNickname != "" {
+		return member.MemberNickname
+	}
+	var user user_models.UserModel
+	db.Take(&user, member.UserID)
+	return user.Nickname
+}
+
+// saveTip 群里的提示消息 要入库 之后进群的人看聊天记录的时候也能看到
+func saveTip(db *gorm.DB, member group_models.GroupMemberModel, content string) (msg group_models.GroupMsgModel, err error) {
+	tip := ctype.Msg{Type: ctype.TipMsgType, TipMsg: &ctype.TipMsg{Status: "info", Content: content}}
+	msg = group_models.GroupMsgModel{
+		GroupID:       member.GroupID,
+		SendUserID:    member.UserID,
+		GroupMemberID: member.ID,
+		MsgType:       tip.Type,
+		Msg:           tip,
+	}
+	msg.MsgPreview = msg.MsgPreviewMethod()
+	err = db.Create(&msg).Error
+	return
+}
+
+// pinChange 群置顶消息变了 推给群成员
+type pinChange struct {
+	GroupID uint `json:"groupID"`
+	MsgID   uint `json:"msgID"`
+	Pinned  bool `json:"pinned"` // true 置顶 false 取消置顶
+}
+
+// getPinMember 群主和管理员才能置顶 消息要是这个群里正常的消息
+func getPinMember(db *gorm.DB, msgID, userID uint) (msg group_models.GroupMsgModel, member group_models.GroupMemberModel, err error) {
+	err = db.Take(&msg, "id = ? and system_msg is null", msgID).Error
+	if err != nil || msg.MsgType == ctype.WithdrawMsgType || msg.MsgType == ctype.TipMsgType {
+		return msg, member, errors.New("消息不存在")
+	}
+	member, err = getMember(db, msg.GroupID, userID)
+	if err != nil {
+		return msg, member, err
+	}
+	if member.Role != ownerRole && member.Role != adminRole {
+		return msg, member, errors.New("只有群主和管理员才能置顶消息")
+	}
+	return msg, member, nil
+}
+
+// pinEvents 提示消息和置顶的变化都加到成员的收件箱 在事务里面调用
+func pinEvents(tx *gorm.DB, tip group_models.GroupMsgModel, change pinChange) ([]chat_models.InboxModel, error) {
+	events, err := groupMsgEvents(tx, tip)
+	if err != nil {
+		return nil, err
+	}
+	list, err := groupEvents(tx, change.GroupID, chat_models.PinEvent, change)
+	if err != nil {
+		return nil, err
+	}
+	return append(events, list...), nil
+}
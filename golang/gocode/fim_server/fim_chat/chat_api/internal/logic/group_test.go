@@ -0,0 +1,90 @@
+package logic
+
+import (
+	"database/sql/driver"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_group/group_models"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectMembers 查群里所有成员的id
+func expectMembers(mock sqlmock.Sqlmock, groupID uint, memberList []uint) {
+	rows := sqlmock.NewRows([]string{"user_id"})
+	for _, userID := range memberList {
+		rows.AddRow(userID)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `user_id` FROM `group_member_models` WHERE group_id = ?")).
+		WithArgs(groupID).WillReturnRows(rows)
+}
+
+// expectGroupChange 群的变化加到这些人的收件箱 内容是change
+func expectGroupChange(mock sqlmock.Sqlmock, groupID uint, memberList []uint, userIDList []uint, change string) {
+	expectMembers(mock, groupID, memberList)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `inbox_seq_models`")).
+		WillReturnResult(sqlmock.NewResult(0, int64(len(userIDList))))
+	seqRows := sqlmock.NewRows([]string{"user_id", "seq"})
+	var args, inboxArgs []driver.Value
+	for _, userID := range userIDList {
+		seqRows.AddRow(userID, 5)
+		args = append(args, userID)
+		inboxArgs = append(inboxArgs, sqlmock.AnyArg(), sqlmock.AnyArg(), userID, 5, chat_models.GroupEvent, change)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `inbox_seq_models` WHERE user_id in")).
+		WithArgs(args...).WillReturnRows(seqRows)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `inbox_models`")).
+		WithArgs(inboxArgs...).WillReturnResult(sqlmock.NewResult(1, int64(len(userIDList))))
+}
+
+// TestGroupEvents 进群 退群 改群信息 都在同一个事务里面加到成员的收件箱
+func TestGroupEvents(t *testing.T) {
+	t.Run("进群", func(t *testing.T) {
+		db, mock := testDB(t)
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `group_member_models`")).
+			WillReturnResult(sqlmock.NewResult(30, 1))
+		expectGroupChange(mock, 3, []uint{1, 2, 4}, []uint{1, 2, 4}, `{"groupID":3,"userID":4,"action":"join"}`)
+
+		err := db.Create(&group_models.GroupMemberModel{GroupID: 3, UserID: 4, Role: 3}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("退群", func(t *testing.T) {
+		db, mock := testDB(t)
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `group_member_models` WHERE `group_member_models`.`id` = ?")).
+			WithArgs(30).WillReturnResult(sqlmock.NewResult(0, 1))
+		// 退群的人已经不在成员里面了 也要通知他的其他设备
+		expectGroupChange(mock, 3, []uint{2, 1}, []uint{1, 2, 4}, `{"groupID":3,"userID":4,"action":"leave"}`)
+
+		member := group_models.GroupMemberModel{GroupID: 3, UserID: 4}
+		member.ID = 30
+		err := db.Delete(&member).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("改群信息", func(t *testing.T) {
+		db, mock := testDB(t)
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `group_models` SET `title`=?,`updated_at`=? WHERE `id` = ?")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectGroupChange(mock, 3, []uint{1, 2}, []uint{1, 2}, `{"groupID":3,"action":"update"}`)
+
+		group := group_models.GroupModel{}
+		group.ID = 3
+		err := db.Model(&group).Updates(map[string]any{"title": "新群名"}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
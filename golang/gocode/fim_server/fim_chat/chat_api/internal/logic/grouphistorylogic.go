@@ -0,0 +1,71 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/common/list_query"
+	"fim_server/fim_group/group_models"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GroupHistoryLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGroupHistoryLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GroupHistoryLogic {
+	return &GroupHistoryLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GroupHistoryLogic) GroupHistory(req *types.GroupHistoryRequest) (resp *types.GroupHistoryResponse, err error) {
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+	db := l.svcCtx.DB
+	member, err := getMember(db, req.GroupID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// 进群之前的消息看不到 别人被系统拦截的消息也看不到
+	where := db.Where("group_id = ? and created_at >= ? and (system_msg is null or send_user_id = ?)",
+		req.GroupID, member.CreatedAt, req.UserID)
+	list, next, err := list_query.CursorQuery(db, group_models.GroupMsgModel{}, list_query.Option{
+		Where: where,
+	}, list_query.CursorOption{
+		Column: "id",
+		Desc:   true,
+		Cursor: req.Cursor,
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("查询聊天记录失败")
+	}
+	resp = &types.GroupHistoryResponse{
+		List: make([]types.GroupMessage, 0, len(list)),
+		Next: next,
+	}
+	var msgIDList []uint
+	for _, msg := range list {
+		msgIDList = append(msgIDList, msg.ID)
+	}
+	reactionList, err := reactionMap(db, &group_models.GroupReactionModel{}, msgIDList, req.UserID)
+	if err != nil {
+		logx.Error(err)
+	}
+	for _, msg := range list {
+		message := groupMessage(msg)
+		message.Reactions = reactionList[msg.ID]
+		resp.List = append(resp.List, message)
+	}
+	return resp, nil
+}
@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_group/group_models"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GroupPinListLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGroupPinListLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GroupPinListLogic {
+	return &GroupPinListLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GroupPinListLogic) GroupPinList(req *types.GroupPinListRequest) (resp *types.GroupPinListResponse, err error) {
+	db := l.svcCtx.DB
+	_, err = getMember(db, req.GroupID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	var pinList []group_models.GroupPinModel
+	err = db.Where("group_id = ?", req.GroupID).Order("id desc").Find(&pinList).Error
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("查询置顶消息失败")
+	}
+	var msgIDList []uint
+	for _, pin := range pinList {
+		msgIDList = append(msgIDList, pin.MsgID)
+	}
+	msgMap := map[uint]group_models.GroupMsgModel{}
+	if len(msgIDList) > 0 {
+		var msgList []group_models.GroupMsgModel
+		db.Find(&msgList, msgIDList)
+		for _, msg := range msgList {
+			msgMap[msg.ID] = msg
+		}
+	}
+	reactionList, err := reactionMap(db, &group_models.GroupReactionModel{}, msgIDList, req.UserID)
+	if err != nil {
+		logx.Error(err)
+	}
+
+	resp = &types.GroupPinListResponse{List: make([]types.GroupPinInfo, 0, len(pinList))}
+	for _, pin := range pinList {
+		msg, ok := msgMap[pin.MsgID]
+		if !ok {
+			continue
+		}
+		info := types.GroupPinInfo{
+			Msg:       groupMessage(msg),
+			PinUserID: pin.UserID,
+			PinnedAt:  pin.CreatedAt,
+		}
+		info.Msg.Reactions = reactionList[msg.ID]
+		resp.List = append(resp.List, info)
+	}
+	return resp, nil
+}
@@ -0,0 +1,87 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_group/group_models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GroupPinLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGroupPinLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GroupPinLogic {
+	return &GroupPinLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+var (
+	errPinned  = errors.New("这条消息已经置顶了")
+	errPinFull = errors.New("置顶的消息太多了 先取消一些")
+)
+
+func (l *GroupPinLogic) GroupPin(req *types.GroupPinRequest) (resp *types.GroupPinResponse, err error) {
+	db := l.svcCtx.DB
+	msg, member, err := getPinMember(db, req.MsgID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []chat_models.InboxModel
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 锁住群 同时置顶的时候数量不会超
+		var group group_models.GroupModel
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&group, msg.GroupID).Error
+		if err != nil {
+			return err
+		}
+		var count int64
+		err = tx.Model(&group_models.GroupPinModel{}).Where("group_id = ?", msg.GroupID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		var pin group_models.GroupPinModel
+		if tx.Take(&pin, "msg_id = ?", msg.ID).Error == nil {
+			return errPinned
+		}
+		if count >= int64(l.svcCtx.Config.Group.MaxPin) {
+			return errPinFull
+		}
+		err = tx.Create(&group_models.GroupPinModel{
+			GroupID: msg.GroupID,
+			MsgID:   msg.ID,
+			UserID:  req.UserID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		tip, err := saveTip(tx, member, memberName(tx, member)+" 置顶了一条消息")
+		if err != nil {
+			return err
+		}
+		events, err = pinEvents(tx, tip, pinChange{GroupID: msg.GroupID, MsgID: msg.ID, Pinned: true})
+		return err
+	})
+	if errors.Is(err, errPinned) || errors.Is(err, errPinFull) {
+		return nil, err
+	}
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("置顶失败")
+	}
+	pushEvents(l.svcCtx, events)
+	return &types.GroupPinResponse{}, nil
+}
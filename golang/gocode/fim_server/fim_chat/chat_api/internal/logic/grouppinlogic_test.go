@@ -0,0 +1,35 @@
+package logic
+
+import (
+	"context"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+	"testing"
+)
+
+// TestGroupPinRole 只有群主和管理员能置顶 不是群成员更不行
+func TestGroupPinRole(t *testing.T) {
+	cases := []struct {
+		name string
+		role int8
+		err  string
+	}{
+		{"不是群成员", 0, "你不是该群成员"},
+		{"普通成员", memberRole, "只有群主和管理员才能置顶消息"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, mock := testDB(t)
+			expectGroupMsg(mock, 40)
+			expectMember(mock, 2, c.role)
+			l := NewGroupPinLogic(context.Background(), &svc.ServiceContext{DB: db})
+			_, err := l.GroupPin(&types.GroupPinRequest{UserID: 2, MsgID: 40})
+			if err == nil || err.Error() != c.err {
+				t.Errorf("置顶的权限不对 %v", err)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
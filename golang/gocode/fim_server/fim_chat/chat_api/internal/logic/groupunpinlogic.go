@@ -0,0 +1,62 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_group/group_models"
+	"gorm.io/gorm"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GroupUnpinLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGroupUnpinLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GroupUnpinLogic {
+	return &GroupUnpinLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GroupUnpinLogic) GroupUnpin(req *types.GroupUnpinRequest) (resp *types.GroupUnpinResponse, err error) {
+	db := l.svcCtx.DB
+	msg, member, err := getPinMember(db, req.MsgID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []chat_models.InboxModel
+	err = db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("msg_id = ?", msg.ID).Delete(&group_models.GroupPinModel{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		tip, err := saveTip(tx, member, memberName(tx, member)+" 取消置顶了一条消息")
+		if err != nil {
+			return err
+		}
+		events, err = pinEvents(tx, tip, pinChange{GroupID: msg.GroupID, MsgID: msg.ID, Pinned: false})
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("这条消息没有置顶")
+	}
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("取消置顶失败")
+	}
+	pushEvents(l.svcCtx, events)
+	return &types.GroupUnpinResponse{}, nil
+}
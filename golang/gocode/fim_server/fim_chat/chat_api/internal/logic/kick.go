@@ -0,0 +1,34 @@
+package logic
+
+import (
+	"errors"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_user/user_models"
+	"strconv"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+var errBan = errors.New("你已被封禁")
+
+// isBan 封号之前连上的websocket 发消息的时候还要再查一次
+func isBan(db *gorm.DB, userID uint) bool {
+	var user user_models.UserModel
+	err := db.Select("ban").Take(&user, userID).Error
+	return err == nil && user.Ban
+}
+
+// KickBanned 收到封号的通知 断开这个用户连在本服务上的websocket 阻塞 用go调用
+func KickBanned(svcCtx *svc.ServiceContext) {
+	pubsub := svcCtx.Redis.Subscribe(user_models.KickChannel)
+	defer pubsub.Close()
+	for msg := range pubsub.Channel() {
+		userID, err := strconv.ParseUint(msg.Payload, 10, 64)
+		if err != nil {
+			logx.Errorf("封号通知错误 %s", msg.Payload)
+			continue
+		}
+		svcCtx.Hub.Kick(uint(userID))
+	}
+}
@@ -0,0 +1,33 @@
+package logic
+
+import (
+	"fim_server/common/models/ctype"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestSendBanned 封号之前连上的websocket 私聊和群聊都发不出去
+func TestSendBanned(t *testing.T) {
+	msg := ctype.Msg{Type: ctype.TextMsgType, TextMsg: &ctype.TextMsg{Content: "你好"}}
+	db, mock := testDB(t)
+	svcCtx := &svc.ServiceContext{DB: db}
+
+	mock.ExpectQuery("SELECT `ban` FROM `user_models`").WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"ban"}).AddRow(true))
+	_, _, err := sendChat(svcCtx, 1, 2, msg)
+	if err != errBan {
+		t.Errorf("私聊 %v", err)
+	}
+
+	mock.ExpectQuery("SELECT `ban` FROM `user_models`").WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"ban"}).AddRow(true))
+	_, _, err = sendGroup(svcCtx, 1, 3, msg)
+	if err != errBan {
+		t.Errorf("群聊 %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,38 @@
+package logic
+
+import (
+	"context"
+	"errors"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type PollResultLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewPollResultLogic(ctx context.Context, svcCtx *svc.ServiceContext) *PollResultLogic {
+	return &PollResultLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *PollResultLogic) PollResult(req *types.PollResultRequest) (resp *types.PollResult, err error) {
+	msg, err := getPoll(l.svcCtx.DB, req.MsgID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	res, err := pollResult(l.svcCtx.DB, msg, req.UserID)
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("查询投票结果失败")
+	}
+	return &res, nil
+}
@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_group/group_models"
+	"gorm.io/gorm"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type PollVoteLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewPollVoteLogic(ctx context.Context, svcCtx *svc.ServiceContext) *PollVoteLogic {
+	return &PollVoteLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *PollVoteLogic) PollVote(req *types.PollVoteRequest) (resp *types.PollResult, err error) {
+	db := l.svcCtx.DB
+	msg, err := getPoll(db, req.MsgID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	poll := msg.Msg.PollMsg
+	if poll.Closed() {
+		return nil, errors.New("投票已经截止了")
+	}
+	if len(req.Options) == 0 {
+		return nil, errors.New("请选择选项")
+	}
+	if !poll.Multi && len(req.Options) > 1 {
+		return nil, errors.New("这是单选的投票")
+	}
+	optionMap := map[int]bool{}
+	for _, option := range req.Options {
+		if option < 0 || option >= len(poll.Options) || optionMap[option] {
+			return nil, errors.New("选项错误")
+		}
+		optionMap[option] = true
+	}
+
+	// 再投一次就把之前投的换掉 新的结果加到群成员的收件箱
+	var events []chat_models.InboxModel
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("msg_id = ? and user_id = ?", msg.ID, req.UserID).Delete(&group_models.GroupPollVoteModel{}).Error
+		if err != nil {
+			return err
+		}
+		var voteList []group_models.GroupPollVoteModel
+		for _, option := range req.Options {
+			voteList = append(voteList, group_models.GroupPollVoteModel{
+				MsgID:  msg.ID,
+				UserID: req.UserID,
+				Option: option,
+			})
+		}
+		err = tx.Create(&voteList).Error
+		if err != nil {
+			return err
+		}
+		// 自己投的选项每个人不一样 不放在事件里面
+		live, err := pollResult(tx, msg, 0)
+		if err != nil {
+			return err
+		}
+		live.MyOptions = nil
+		events, err = groupEvents(tx, msg.GroupID, chat_models.PollEvent, live)
+		return err
+	})
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("投票失败")
+	}
+
+	res, err := pollResult(db, msg, req.UserID)
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("查询投票结果失败")
+	}
+	pushEvents(l.svcCtx, events)
+	return &res, nil
+}
@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"context"
+	"fim_server/fim_chat/chat_api/internal/hub"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+	"fim_server/fim_chat/chat_models"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectPoll 群3里面的投票消息40 用户2是成员
+func expectPoll(mock sqlmock.Sqlmock, multi bool) {
+	poll := `{"type":19,"pollMsg":{"question":"吃什么","options":["米饭","面条","饺子"],"multi":` + jsonString(multi) + `}}`
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `group_msg_models` WHERE `group_msg_models`.`id` = ?")).WithArgs(40, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "group_id", "send_user_id", "msg_type", "msg"}).AddRow(40, 3, 1, 19, []byte(poll)))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `group_member_models` WHERE group_id = ? and user_id = ?")).WithArgs(3, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "group_id", "user_id", "role"}).AddRow(20, 3, 2, 3))
+}
+
+// expectPollResult 每个选项的票数和投票人数
+func expectPollResult(mock sqlmock.Sqlmock, counts map[int]int64, voters int64) {
+	rows := sqlmock.NewRows([]string{"option", "count"})
+	for option, count := range counts {
+		rows.AddRow(option, count)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `option`, count(*) as count FROM `group_poll_vote_models` WHERE msg_id = ? GROUP BY `option`")).
+		WithArgs(40).WillReturnRows(rows)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(DISTINCT(`user_id`)) FROM `group_poll_vote_models` WHERE msg_id = ?")).
+		WithArgs(40).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(voters))
+}
+
+// TestPollRevote 再投一次 之前投的删掉换成新的 推给成员的结果里面没有自己投的选项
+func TestPollRevote(t *testing.T) {
+	db, mock := testDB(t)
+	l := NewPollVoteLogic(context.Background(), &svc.ServiceContext{DB: db, Hub: hub.NewHub()})
+
+	expectPoll(mock, true)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `group_poll_vote_models` WHERE msg_id = ? and user_id = ?")).
+		WithArgs(40, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `group_poll_vote_models`")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 40, 2, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), 40, 2, 2).
+		WillReturnResult(sqlmock.NewResult(50, 2))
+	expectPollResult(mock, map[int]int64{1: 2, 2: 1}, 2)
+	expectMembers(mock, 3, []uint{1, 2})
+	expectInbox(mock, []uint{1, 2}, chat_models.PollEvent, `{"msgID":40,"counts":[0,2,1],"voters":2,"closed":false,"myOptions":null}`)
+	mock.ExpectCommit()
+	expectPollResult(mock, map[int]int64{1: 2, 2: 1}, 2)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `option` FROM `group_poll_vote_models` WHERE msg_id = ? and user_id = ? ORDER BY `option`")).
+		WithArgs(40, 2).WillReturnRows(sqlmock.NewRows([]string{"option"}).AddRow(1).AddRow(2))
+
+	resp, err := l.PollVote(&types.PollVoteRequest{UserID: 2, MsgID: 40, Options: []int{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.MyOptions, []int{1, 2}) || !reflect.DeepEqual(resp.Counts, []int64{0, 2, 1}) {
+		t.Errorf("投票结果不对 %+v", resp)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestPollVoteSingle 单选的投票只能投一个 不碰库
+func TestPollVoteSingle(t *testing.T) {
+	db, mock := testDB(t)
+	l := NewPollVoteLogic(context.Background(), &svc.ServiceContext{DB: db})
+	expectPoll(mock, false)
+
+	_, err := l.PollVote(&types.PollVoteRequest{UserID: 2, MsgID: 40, Options: []int{0, 1}})
+	if err == nil || err.Error() != "这是单选的投票" {
+		t.Errorf("单选投了两个 %v", err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,180 @@
+package logic
+
+import (
+	"errors"
+	"fim_server/common/models/ctype"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_group/group_models"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// reactionChange 表情回应变了 推给客户端的内容
+type reactionChange struct {
+	MsgID     uint             `json:"msgID"`
+	IsGroup   bool             `json:"isGroup"`
+	GroupID   uint             `json:"groupID,omitempty"`
+	UserID    uint             `json:"userID"` // 谁回应或者取消的
+	Emoji     string           `json:"emoji"`
+	Add       bool             `json:"add"`       // true 回应 false 取消
+	Reactions []types.Reaction `json:"reactions"` // 群里推的me都是false 客户端按userID自己算
+}
+
+// checkEmoji 有的emoji是好几个码点拼起来的 只限制长度
+func checkEmoji(emoji string) error {
+	if emoji == "" || len(emoji) > 32 || strings.ContainsAny(emoji, " \t\r\n") {
+		return errors.New("表情错误")
+	}
+	return nil
+}
+
+// reactionMap 一批消息的表情回应 按表情汇总 先回应的表情在前面 userID是看消息的人
+func reactionMap(db *gorm.DB, model any, msgIDList []uint, userID uint) (map[uint][]types.Reaction, error) {
+	res := map[uint][]types.Reaction{}
+	if len(msgIDList) == 0 {
+		return res, nil
+	}
+	var list []struct {
+		MsgID uint
+		Emoji string
+		Count int64
+		Me    bool
+	}
+	err := db.Model(model).Where("msg_id in ?", msgIDList).Group("msg_id, emoji").
+		Select("msg_id, emoji, count(*) as count, max(user_id = ?) as me", userID).
+		Order("min(id)").Scan(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range list {
+		res[r.MsgID] = append(res[r.MsgID], types.Reaction{Emoji: r.Emoji, Count: r.Count, Me: r.Me})
+	}
+	return res, nil
+}
+
+// reactions 一条消息的表情回应 没有的时候是空列表
+func reactions(db *gorm.DB, model any, msgID uint, userID uint) ([]types.Reaction, error) {
+	m, err := reactionMap(db, model, []uint{msgID}, userID)
+	if err != nil {
+		return nil, err
+	}
+	if m[msgID] == nil {
+		return []types.Reaction{}, nil
+	}
+	return m[msgID], nil
+}
+
+// react 回应或者取消 私聊的双方和群里的成员都会收到reaction事件
+func react(svcCtx *svc.ServiceContext, userID, msgID uint, isGroup bool, emoji string, add bool) (resp *types.ReactionResponse, err error) {
+	err = checkEmoji(emoji)
+	if err != nil {
+		return nil, err
+	}
+	if isGroup {
+		return reactGroup(svcCtx, userID, msgID, emoji, add)
+	}
+	return reactChat(svcCtx, userID, msgID, emoji, add)
+}
+
+func reactChat(svcCtx *svc.ServiceContext, userID, msgID uint, emoji string, add bool) (resp *types.ReactionResponse, err error) {
+	db := svcCtx.DB
+	var chat chat_models.ChatModel
+	err = db.Take(&chat, "id = ? and (send_user_id = ? or rev_user_id = ?) and system_msg is null", msgID, userID, userID).Error
+	if err != nil || chat.MsgType == ctype.WithdrawMsgType {
+		return nil, errors.New("消息不存在")
+	}
+
+	resp = &types.ReactionResponse{MsgID: msgID}
+	var events []chat_models.InboxModel
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := saveReaction(tx, &chat_models.ChatReactionModel{MsgID: msgID, UserID: userID, Emoji: emoji}, add)
+		if err != nil {
+			return err
+		}
+		// me每个人不一样 先查好再加事件
+		userIDList := []uint{chat.SendUserID, chat.RevUserID}
+		listMap := map[uint][]types.Reaction{}
+		for _, id := range userIDList {
+			listMap[id], err = reactions(tx, &chat_models.ChatReactionModel{}, msgID, id)
+			if err != nil {
+				return err
+			}
+		}
+		resp.Reactions = listMap[userID]
+		events, err = chat_models.AppendInboxList(tx, userIDList, chat_models.ReactionEvent, func(id uint) any {
+			return reactionChange{
+				MsgID:     msgID,
+				UserID:    userID,
+				Emoji:     emoji,
+				Add:       add,
+				Reactions: listMap[id],
+			}
+		})
+		return err
+	})
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("操作失败")
+	}
+	pushEvents(svcCtx, events)
+	return resp, nil
+}
+
+func reactGroup(svcCtx *svc.ServiceContext, userID, msgID uint, emoji string, add bool) (resp *types.ReactionResponse, err error) {
+	db := svcCtx.DB
+	var msg group_models.GroupMsgModel
+	err = db.Take(&msg, "id = ? and system_msg is null", msgID).Error
+	if err != nil || msg.MsgType == ctype.WithdrawMsgType {
+		return nil, errors.New("消息不存在")
+	}
+	_, err = getMember(db, msg.GroupID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp = &types.ReactionResponse{MsgID: msgID, IsGroup: true}
+	var events []chat_models.InboxModel
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := saveReaction(tx, &group_models.GroupReactionModel{MsgID: msgID, UserID: userID, Emoji: emoji}, add)
+		if err != nil {
+			return err
+		}
+		resp.Reactions, err = reactions(tx, &group_models.GroupReactionModel{}, msgID, userID)
+		if err != nil {
+			return err
+		}
+		live, err := reactions(tx, &group_models.GroupReactionModel{}, msgID, 0)
+		if err != nil {
+			return err
+		}
+		events, err = groupEvents(tx, msg.GroupID, chat_models.ReactionEvent, reactionChange{
+			MsgID:     msgID,
+			IsGroup:   true,
+			GroupID:   msg.GroupID,
+			UserID:    userID,
+			Emoji:     emoji,
+			Add:       add,
+			Reactions: live,
+		})
+		return err
+	})
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("操作失败")
+	}
+	pushEvents(svcCtx, events)
+	return resp, nil
+}
+
+// saveReaction 重复回应和取消没有回应过的都不算错
+func saveReaction(db *gorm.DB, reaction any, add bool) error {
+	if add {
+		return db.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error
+	}
+	return db.Where(reaction).Delete(reaction).Error
+}
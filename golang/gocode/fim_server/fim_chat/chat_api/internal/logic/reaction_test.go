@@ -0,0 +1,88 @@
+package logic
+
+import (
+	"context"
+	"fim_server/fim_chat/chat_api/internal/hub"
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+	"fim_server/fim_chat/chat_models"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectGroupMsg 群3里面的一条正常消息
+func expectGroupMsg(mock sqlmock.Sqlmock, msgID uint) {
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `group_msg_models` WHERE id = ? and system_msg is null")).WithArgs(msgID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "group_id", "send_user_id", "msg_type", "msg"}).
+			AddRow(msgID, 3, 1, 1, []byte(`{"type":1,"textMsg":{"content":"你好"}}`)))
+}
+
+// expectMember 群3里面查userID role为0就是不在群里
+func expectMember(mock sqlmock.Sqlmock, userID uint, role int8) {
+	rows := sqlmock.NewRows([]string{"id", "group_id", "user_id", "role"})
+	if role != 0 {
+		rows.AddRow(20, 3, userID, role)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `group_member_models` WHERE group_id = ? and user_id = ?")).
+		WithArgs(3, userID, 1).WillReturnRows(rows)
+}
+
+// TestReactionMember 群消息只有群成员能回应 私聊只有双方能回应
+func TestReactionMember(t *testing.T) {
+	t.Run("不是群成员", func(t *testing.T) {
+		db, mock := testDB(t)
+		expectGroupMsg(mock, 40)
+		expectMember(mock, 5, 0)
+		l := NewReactionAddLogic(context.Background(), &svc.ServiceContext{DB: db})
+		_, err := l.ReactionAdd(&types.ReactionRequest{UserID: 5, MsgID: 40, IsGroup: true, Emoji: "👍"})
+		if err == nil || err.Error() != "你不是该群成员" {
+			t.Errorf("不是群成员也能回应 %v", err)
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("别人的私聊", func(t *testing.T) {
+		db, mock := testDB(t)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM `chat_models` WHERE id = ? and (send_user_id = ? or rev_user_id = ?) and system_msg is null")).
+			WithArgs(10, 5, 5, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		l := NewReactionAddLogic(context.Background(), &svc.ServiceContext{DB: db})
+		_, err := l.ReactionAdd(&types.ReactionRequest{UserID: 5, MsgID: 10, Emoji: "👍"})
+		if err == nil || err.Error() != "消息不存在" {
+			t.Errorf("别人的私聊也能回应 %v", err)
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("群成员", func(t *testing.T) {
+		db, mock := testDB(t)
+		expectGroupMsg(mock, 40)
+		expectMember(mock, 2, memberRole)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `group_reaction_models`")).WillReturnResult(sqlmock.NewResult(60, 1))
+		reactionSQL := regexp.QuoteMeta("SELECT msg_id, emoji, count(*) as count, max(user_id = ?) as me FROM `group_reaction_models` WHERE msg_id in (?)")
+		mock.ExpectQuery(reactionSQL).WithArgs(2, 40).
+			WillReturnRows(sqlmock.NewRows([]string{"msg_id", "emoji", "count", "me"}).AddRow(40, "👍", 1, true))
+		mock.ExpectQuery(reactionSQL).WithArgs(0, 40).
+			WillReturnRows(sqlmock.NewRows([]string{"msg_id", "emoji", "count", "me"}).AddRow(40, "👍", 1, false))
+		expectMembers(mock, 3, []uint{1, 2})
+		expectInbox(mock, []uint{1, 2}, chat_models.ReactionEvent,
+			`{"msgID":40,"isGroup":true,"groupID":3,"userID":2,"emoji":"👍","add":true,"reactions":[{"emoji":"👍","count":1,"me":false}]}`)
+		mock.ExpectCommit()
+
+		l := NewReactionAddLogic(context.Background(), &svc.ServiceContext{DB: db, Hub: hub.NewHub()})
+		resp, err := l.ReactionAdd(&types.ReactionRequest{UserID: 2, MsgID: 40, IsGroup: true, Emoji: "👍"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Reactions) != 1 || !resp.Reactions[0].Me {
+			t.Errorf("返回给自己的要带上me %+v", resp.Reactions)
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package logic
+
+import (
+	"context"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ReactionAddLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewReactionAddLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ReactionAddLogic {
+	return &ReactionAddLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ReactionAddLogic) ReactionAdd(req *types.ReactionRequest) (resp *types.ReactionResponse, err error) {
+	return react(l.svcCtx, req.UserID, req.MsgID, req.IsGroup, req.Emoji, true)
+}
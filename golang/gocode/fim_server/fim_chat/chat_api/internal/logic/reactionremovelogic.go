@@ -0,0 +1,28 @@
+package logic
+
+import (
+	"context"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ReactionRemoveLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewReactionRemoveLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ReactionRemoveLogic {
+	return &ReactionRemoveLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ReactionRemoveLogic) ReactionRemove(req *types.ReactionRemoveRequest) (resp *types.ReactionResponse, err error) {
+	return react(l.svcCtx, req.UserID, req.MsgID, req.IsGroup, req.Emoji, false)
+}
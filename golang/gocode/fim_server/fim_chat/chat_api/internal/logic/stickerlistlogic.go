@@ -0,0 +1,58 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_chat/chat_models"
+	"gorm.io/gorm"
+
+	"fim_server/fim_chat/chat_api/internal/svc"
+	"fim_server/fim_chat/chat_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type StickerListLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewStickerListLogic(ctx context.Context, svcCtx *svc.ServiceContext) *StickerListLogic {
+	return &StickerListLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *StickerListLogic) StickerList(req *types.StickerListRequest) (resp *types.StickerListResponse, err error) {
+	var packList []chat_models.StickerPackModel
+	err = l.svcCtx.DB.Preload("StickerList", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort, id")
+	}).Order("sort, id").Find(&packList).Error
+	if err != nil {
+		logx.Error(err)
+		return nil, errors.New("查询表情失败")
+	}
+	resp = &types.StickerListResponse{
+		List: make([]types.StickerPackInfo, 0, len(packList)),
+	}
+	for _, pack := range packList {
+		info := types.StickerPackInfo{
+			ID:    pack.ID,
+			Title: pack.Title,
+			Cover: pack.Cover,
+			List:  make([]types.StickerInfo, 0, len(pack.StickerList)),
+		}
+		for _, sticker := range pack.StickerList {
+			info.List = append(info.List, types.StickerInfo{
+				ID:    sticker.ID,
+				Title: sticker.Title,
+				Src:   sticker.Src,
+			})
+		}
+		resp.List = append(resp.List, info)
+	}
+	return resp, nil
+}
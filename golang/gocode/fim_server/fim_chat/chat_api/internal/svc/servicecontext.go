@@ -0,0 +1,57 @@
+package svc
+
+import (
+	"fim_server/common/models"
+	"fim_server/common/moderation"
+	"fim_server/common/settings"
+	"fim_server/core"
+	"fim_server/fim_chat/chat_api/internal/call"
+	"fim_server/fim_chat/chat_api/internal/config"
+	"fim_server/fim_chat/chat_api/internal/hub"
+	"fim_server/fim_chat/chat_models"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+type ServiceContext struct {
+	Config    config.Config
+	DB        *gorm.DB
+	Redis     *redis.Client
+	Settings  *settings.Watcher // 系统设置里面的审核开关
+	Moderator *moderation.Moderator
+	Hub       *hub.Hub // 连在这个服务上的websocket
+	Calls     *call.Manager
+}
+
+func NewServiceContext(c config.Config) *ServiceContext {
+	mysqlDb := core.InitGorm(c.Mysql.DataSource)
+	redisClient := core.InitRedis(c.Redis.Addr, c.Redis.Pwd, c.Redis.DB)
+	go cleanInbox(mysqlDb, c.Inbox.KeepDays)
+	h := hub.NewHub()
+	return &ServiceContext{
+		Config:    c,
+		DB:        mysqlDb,
+		Redis:     redisClient,
+		Settings:  settings.NewWatcher(c.Etcd),
+		Moderator: moderation.NewModerator(c.Moderation.WordFile, time.Minute),
+		Hub:       h,
+		Calls:     call.NewManager(h, time.Duration(c.Call.RingTimeout)*time.Second),
+	}
+}
+
+// cleanInbox 每小时删一次过期的收件箱事件
+func cleanInbox(db *gorm.DB, keepDays int) {
+	for {
+		before := time.Now().AddDate(0, 0, -keepDays).Format(models.TimeFormat)
+		count, err := chat_models.DeleteInboxBefore(db, before, 1000)
+		if err != nil {
+			logx.Errorf("收件箱清理失败 %s", err.Error())
+		} else if count > 0 {
+			logx.Infof("收件箱清理了 %d 条", count)
+		}
+		time.Sleep(time.Hour)
+	}
+}
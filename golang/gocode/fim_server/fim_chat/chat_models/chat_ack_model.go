@@ -0,0 +1,43 @@
+package chat_models
+
+import (
+	"fim_server/common/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChatAckModel 私聊的送达和已读位置 每个人的每个会话一条
+// 消息id是递增的 对方发来的消息id小于等于这个位置的都算送达或者已读
+type ChatAckModel struct {
+	models.Model
+	UserID         uint `gorm:"uniqueIndex:idx_chat_ack_user_target" json:"userID"`   // 收消息的人
+	TargetID       uint `gorm:"uniqueIndex:idx_chat_ack_user_target" json:"targetID"` // 对方 也就是消息的发送人
+	DeliveredMsgID uint `json:"deliveredMsgID"`                                       // 送达到哪一条
+	ReadMsgID      uint `json:"readMsgID"`                                            // 已读到哪一条
+}
+
+// Ack 往前推送达和已读的位置 只会变大不会变小 已读了一定也送达了
+func Ack(db *gorm.DB, userID, targetID, deliveredMsgID, readMsgID uint) (ack ChatAckModel, err error) {
+	if readMsgID > deliveredMsgID {
+		deliveredMsgID = readMsgID
+	}
+	err = db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "target_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"delivered_msg_id": gorm.Expr("greatest(delivered_msg_id, values(delivered_msg_id))"),
+			"read_msg_id":      gorm.Expr("greatest(read_msg_id, values(read_msg_id))"),
+			"updated_at":       gorm.Expr("values(updated_at)"),
+		}),
+	}).Create(&ChatAckModel{
+		UserID:         userID,
+		TargetID:       targetID,
+		DeliveredMsgID: deliveredMsgID,
+		ReadMsgID:      readMsgID,
+	}).Error
+	if err != nil {
+		return
+	}
+	err = db.Take(&ack, "user_id = ? and target_id = ?", userID, targetID).Error
+	return
+}
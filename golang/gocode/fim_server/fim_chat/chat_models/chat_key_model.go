@@ -0,0 +1,25 @@
+package chat_models
+
+import (
+	"fim_server/common/models"
+)
+
+// DeviceKeyModel 端到端加密 每个设备的公钥 私钥只在设备上
+type DeviceKeyModel struct {
+	models.Model
+	UserID          uint   `gorm:"uniqueIndex:idx_device_key_user_device" json:"userID"`
+	DeviceID        string `gorm:"size:64;uniqueIndex:idx_device_key_user_device" json:"deviceID"`
+	IdentityKey     string `gorm:"size:128" json:"identityKey"`     // 身份公钥 变了要通知对方
+	SignedPreKeyID  uint32 `json:"signedPreKeyID"`                  // 签名预密钥
+	SignedPreKey    string `gorm:"size:128" json:"signedPreKey"`    // 签名预密钥的公钥
+	SignedPreKeySig string `gorm:"size:256" json:"signedPreKeySig"` // 用身份密钥对签名预密钥的签名 客户端自己验
+}
+
+// OneTimePreKeyModel 一次性预密钥 别人取一个就删一个 少了客户端自己补
+type OneTimePreKeyModel struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UserID    uint   `gorm:"uniqueIndex:idx_one_time_pre_key" json:"userID"`
+	DeviceID  string `gorm:"size:64;uniqueIndex:idx_one_time_pre_key" json:"deviceID"`
+	KeyID     uint32 `gorm:"uniqueIndex:idx_one_time_pre_key" json:"keyID"`
+	PublicKey string `gorm:"size:128" json:"publicKey"`
+}
@@ -3,6 +3,8 @@ package chat_models
 import (
 	"fim_server/common/models"
 	ctype2 "fim_server/common/models/ctype"
+
+	"gorm.io/gorm"
 )
 
 type ChatModel struct {
@@ -13,4 +15,27 @@ type ChatModel struct {
 	MsgPreview string            `gorm:"size:64" json:"msgPreview"` // 消息预览
 	Msg        ctype2.Msg        `json:"msg"`                       // 消息类容
 	SystemMsg  *ctype2.SystemMsg `json:"systemMsg"`                 // 系统提示
+	// SearchContent 消息里面可以搜索的文本 有全文索引 发消息和撤回的时候自动同步
+	SearchContent string `gorm:"type:text" json:"-"`
+}
+
+// BeforeSave 消息内容变了就同步搜索内容 撤回之后就搜不到了
+func (chat *ChatModel) BeforeSave(tx *gorm.DB) error {
+	msg, ok := ctype2.UpdatedMsg(tx.Statement.Dest, chat.Msg)
+	if !ok {
+		return nil
+	}
+	if chat.SystemMsg != nil {
+		// 被系统拦截的消息不能搜
+		msg = ctype2.Msg{}
+	}
+	tx.Statement.SetColumn("search_content", msg.SearchText())
+	return nil
+}
+
+func (chat ChatModel) MsgPreviewMethod() string {
+	if chat.SystemMsg != nil {
+		return chat.SystemMsg.Preview()
+	}
+	return chat.Msg.MsgPreview()
 }
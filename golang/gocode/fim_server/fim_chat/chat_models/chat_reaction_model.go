@@ -0,0 +1,11 @@
+package chat_models
+
+import "fim_server/common/models"
+
+// ChatReactionModel 私聊消息上的表情回应 一个人对一条消息可以回应多个不同的表情
+type ChatReactionModel struct {
+	models.Model
+	MsgID  uint   `gorm:"uniqueIndex:idx_chat_reaction" json:"msgID"`
+	UserID uint   `gorm:"uniqueIndex:idx_chat_reaction" json:"userID"`
+	Emoji  string `gorm:"size:32;uniqueIndex:idx_chat_reaction" json:"emoji"`
+}
@@ -0,0 +1,169 @@
+package chat_models
+
+import (
+	"encoding/json"
+	"fim_server/common/models"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 收件箱的事件类型
+const (
+	MsgEvent      = "msg"      // 新消息 自己发的也有 其他设备要同步
+	RecallEvent   = "recall"   // 撤回消息
+	ReceiptEvent  = "receipt"  // 送达和已读的位置变了
+	GroupEvent    = "group"    // 群的变化 进群 退群 改群信息
+	KeyEvent      = "key"      // 好友的设备公钥变了 加密会话要重新建
+	ReactionEvent = "reaction" // 消息的表情回应变了
+
+	// 群里的事件 每个成员的收件箱里面都有一份
+	GroupMsgEvent = "group_msg" // 新的群消息
+	PollEvent     = "poll"      // 群投票的结果变了
+	PinEvent      = "pin"       // 群置顶消息变了
+)
+
+// 群变化的动作 GroupEvent的内容
+const (
+	GroupJoinAction   = "join"   // 有人进群
+	GroupLeaveAction  = "leave"  // 有人退群或者被踢了
+	GroupUpdateAction = "update" // 群信息改了
+)
+
+// GroupChange 群的变化 客户端收到之后重新拉群信息和成员列表
+type GroupChange struct {
+	GroupID uint   `json:"groupID"`
+	UserID  uint   `json:"userID,omitempty"` // 进群退群的人
+	Action  string `json:"action"`
+}
+
+// InboxModel 用户的收件箱 每个用户的seq从1开始连续递增
+// 设备记住收到的最后一个seq 断线重连的时候从这里往后补
+type InboxModel struct {
+	models.Model
+	UserID uint   `gorm:"uniqueIndex:idx_inbox_user_seq" json:"userID"`
+	Seq    uint64 `gorm:"uniqueIndex:idx_inbox_user_seq" json:"seq"`
+	Type   string `gorm:"size:16" json:"type"`
+	Data   string `gorm:"type:text" json:"data"` // 事件内容 json
+}
+
+// InboxSeqModel 每个用户当前的seq 分配seq的时候锁这一行
+type InboxSeqModel struct {
+	UserID uint   `gorm:"primaryKey;autoIncrement:false" json:"userID"`
+	Seq    uint64 `json:"seq"`
+}
+
+// AppendInbox 往收件箱里面加一个事件 和产生事件的改动放在同一个事务里面
+// 同一个用户的seq在事务提交之前是锁住的 不会有空洞
+func AppendInbox(tx *gorm.DB, userID uint, eventType string, data any) (inbox InboxModel, err error) {
+	byteData, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{"seq": gorm.Expr("seq + 1")}),
+	}).Create(&InboxSeqModel{UserID: userID, Seq: 1}).Error
+	if err != nil {
+		return
+	}
+	var seq InboxSeqModel
+	err = tx.Take(&seq, "user_id = ?", userID).Error
+	if err != nil {
+		return
+	}
+	inbox = InboxModel{
+		UserID: userID,
+		Seq:    seq.Seq,
+		Type:   eventType,
+		Data:   string(byteData),
+	}
+	err = tx.Create(&inbox).Error
+	return
+}
+
+// AppendInboxList 一个事务里面给好几个人加事件 一共三条语句 不管有多少人
+// 分配seq会锁住每个人的seq行 按userID从小到大插 A发给B和B发给A同时进来 顺序不一样就会死锁
+// data是每个人看到的事件内容 重复的userID只加一次
+func AppendInboxList(tx *gorm.DB, userIDList []uint, eventType string, data func(userID uint) any) (list []InboxModel, err error) {
+	userIDList = slices.Clone(userIDList)
+	slices.Sort(userIDList)
+	userIDList = slices.Compact(userIDList)
+	if len(userIDList) == 0 {
+		return nil, nil
+	}
+
+	seqList := make([]InboxSeqModel, 0, len(userIDList))
+	for _, userID := range userIDList {
+		seqList = append(seqList, InboxSeqModel{UserID: userID, Seq: 1})
+	}
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{"seq": gorm.Expr("seq + 1")}),
+	}).Create(&seqList).Error
+	if err != nil {
+		return nil, err
+	}
+	seqList = nil
+	err = tx.Find(&seqList, "user_id in ?", userIDList).Error
+	if err != nil {
+		return nil, err
+	}
+	var seqMap = map[uint]uint64{}
+	for _, seq := range seqList {
+		seqMap[seq.UserID] = seq.Seq
+	}
+
+	list = make([]InboxModel, 0, len(userIDList))
+	for _, userID := range userIDList {
+		byteData, err := json.Marshal(data(userID))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, InboxModel{
+			UserID: userID,
+			Seq:    seqMap[userID],
+			Type:   eventType,
+			Data:   string(byteData),
+		})
+	}
+	err = tx.Create(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// InboxSeq 用户收件箱当前的seq 没有事件是0
+func InboxSeq(db *gorm.DB, userID uint) uint64 {
+	var seq InboxSeqModel
+	db.Take(&seq, "user_id = ?", userID)
+	return seq.Seq
+}
+
+// InboxFirstSeq 还留着的最早的事件 过期的事件会被删掉 没有是0
+func InboxFirstSeq(db *gorm.DB, userID uint) (seq uint64) {
+	db.Model(&InboxModel{}).Where("user_id = ?", userID).Select("coalesce(min(seq), 0)").Scan(&seq)
+	return
+}
+
+// InboxAfter seq之后的事件 按seq排好序
+func InboxAfter(db *gorm.DB, userID uint, seq uint64, limit int) (list []InboxModel, err error) {
+	err = db.Where("user_id = ? and seq > ?", userID, seq).Order("seq").Limit(limit).Find(&list).Error
+	return
+}
+
+// DeleteInboxBefore 删掉这个时间之前的事件 一次删一批 不要长时间锁表
+func DeleteInboxBefore(db *gorm.DB, before string, batch int) (count int64, err error) {
+	for {
+		res := db.Where("created_at < ?", before).Limit(batch).Delete(&InboxModel{})
+		if res.Error != nil {
+			return count, res.Error
+		}
+		count += res.RowsAffected
+		if res.RowsAffected < int64(batch) {
+			return count, nil
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package chat_models
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func testDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+// TestAppendInboxListOrder 不管传进来什么顺序 seq行都按userID从小到大锁 重复的只加一次
+// 不管多少人都是一条插seq 一条查seq 一条插事件
+func TestAppendInboxListOrder(t *testing.T) {
+	db, mock := testDB(t)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `inbox_seq_models` (`user_id`,`seq`) VALUES (?,?),(?,?),(?,?) ON DUPLICATE KEY UPDATE `seq`=seq + 1")).
+		WithArgs(1, 1, 2, 1, 3, 1).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `inbox_seq_models` WHERE user_id in (?,?,?)")).
+		WithArgs(1, 2, 3).WillReturnRows(sqlmock.NewRows([]string{"user_id", "seq"}).AddRow(3, 9).AddRow(1, 7).AddRow(2, 8))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `inbox_models`")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 7, MsgEvent, `{"userID":1}`,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), 2, 8, MsgEvent, `{"userID":2}`,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), 3, 9, MsgEvent, `{"userID":3}`).
+		WillReturnResult(sqlmock.NewResult(10, 3))
+
+	input := []uint{3, 1, 2, 3}
+	list, err := AppendInboxList(db, input, MsgEvent, func(userID uint) any {
+		return map[string]uint{"userID": userID}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("事件数量错误 %+v", list)
+	}
+	for i, inbox := range list {
+		if inbox.UserID != uint(i+1) || inbox.Seq != uint64(i+7) || inbox.Data != fmt.Sprintf(`{"userID":%d}`, i+1) {
+			t.Errorf("第%d个事件错误 %+v", i, inbox)
+		}
+	}
+	if input[0] != 3 || input[3] != 3 {
+		t.Errorf("不能改调用方的列表 %v", input)
+	}
+}
+
+func TestAppendInboxListEmpty(t *testing.T) {
+	db, mock := testDB(t)
+	list, err := AppendInboxList(db, nil, MsgEvent, func(uint) any { return nil })
+	if err != nil || len(list) != 0 {
+		t.Errorf("没有人不用加 %v %v", list, err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,45 @@
+package chat_models
+
+import (
+	"fim_server/common/models"
+	"fim_server/common/models/ctype"
+
+	"gorm.io/gorm"
+)
+
+// 消息的来源
+const (
+	ChatMsgSource  = "chat"
+	GroupMsgSource = "group"
+)
+
+// MsgFileModel 消息里面引用的文件 发消息的时候记下来 撤回的时候删掉
+// 看文件的时候按这个表往前找 这个人收到过谁发的这个文件 不用去扫消息内容
+type MsgFileModel struct {
+	models.Model
+	Hash       string `gorm:"size:64;index:idx_msg_file_rev;index:idx_msg_file_group" json:"hash"` // 文件的hash
+	Source     string `gorm:"size:8;index:idx_msg_file_msg" json:"source"`                         // chat group
+	MsgID      uint   `gorm:"index:idx_msg_file_msg" json:"msgID"`
+	SendUserID uint   `json:"sendUserID"`
+	RevUserID  uint   `gorm:"index:idx_msg_file_rev" json:"revUserID"` // 私聊的接收人
+	GroupID    uint   `gorm:"index:idx_msg_file_group" json:"groupID"` // 群消息的群
+}
+
+// SaveMsgFile 记下消息里面的文件 和消息放在同一个事务里面 被拦截的消息不要记
+func SaveMsgFile(tx *gorm.DB, file MsgFileModel, msg ctype.Msg) error {
+	var list []MsgFileModel
+	for _, hash := range msg.FileHashList() {
+		item := file
+		item.Hash = hash
+		list = append(list, item)
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return tx.Create(&list).Error
+}
+
+// DeleteMsgFile 消息撤回了 里面的文件不能再凭这条消息看
+func DeleteMsgFile(tx *gorm.DB, source string, msgID uint) error {
+	return tx.Where("source = ? and msg_id = ?", source, msgID).Delete(&MsgFileModel{}).Error
+}
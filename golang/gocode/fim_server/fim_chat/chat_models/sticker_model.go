@@ -0,0 +1,21 @@
+package chat_models
+
+import "fim_server/common/models"
+
+// StickerPackModel 表情包
+type StickerPackModel struct {
+	models.Model
+	Title       string         `gorm:"size:32" json:"title"`
+	Cover       string         `gorm:"size:256" json:"cover"` // 封面
+	Sort        int            `json:"sort"`                  // 越小越靠前
+	StickerList []StickerModel `gorm:"foreignKey:PackID" json:"-"`
+}
+
+// StickerModel 表情包里面的一个表情
+type StickerModel struct {
+	models.Model
+	PackID uint   `gorm:"index" json:"packID"`
+	Title  string `gorm:"size:32" json:"title"`
+	Src    string `gorm:"size:256" json:"src"`
+	Sort   int    `json:"sort"`
+}
@@ -4,6 +4,7 @@ import (
 	"fim_server/common/etcd"
 	"flag"
 	"fmt"
+	"time"
 
 	"fim_server/fim_file/file_api/internal/config"
 	"fim_server/fim_file/file_api/internal/handler"
@@ -27,6 +28,9 @@ func main() {
 	ctx := svc.NewServiceContext(c)
 	handler.RegisterHandlers(server, ctx)
 
+	// 定时清理没有完成的分片上传
+	go ctx.ChunkStore.RunGC(time.Duration(c.Chunk.GCInterval) * time.Minute)
+
 	etcd.DeliveryAddress(c.Etcd, c.Name+"_api", fmt.Sprintf("%s:%d", c.Host, c.Port))
 
 	fmt.Printf("Starting server at %s:%d...\n", c.Host, c.Port)
@@ -0,0 +1,279 @@
+package chunk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fim_server/common/storage"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+var (
+	ErrNotFound    = errors.New("上传任务不存在或已过期")
+	ErrIndex       = errors.New("分片序号错误")
+	ErrChunkSize   = errors.New("分片大小错误")
+	ErrIncomplete  = errors.New("还有分片没有上传")
+	ErrHash        = errors.New("文件校验失败，请重新上传")
+	ErrCompleting  = errors.New("文件正在合并")
+	ErrUploadOwner = errors.New("不能操作别人的上传任务")
+)
+
+const (
+	keyPrefix = "chunks/"
+	metaName  = "meta.json"
+)
+
+// Meta 一次分片上传的信息
+type Meta struct {
+	UploadID   string    `json:"uploadID"`
+	UserID     uint      `json:"userID"`
+	FileType   string    `json:"fileType"`
+	FileName   string    `json:"fileName"`
+	Size       int64     `json:"size"`
+	Hash       string    `json:"hash"` // 整个文件的sha256
+	ChunkSize  int64     `json:"chunkSize"`
+	ChunkCount int       `json:"chunkCount"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ChunkLen 第index个分片应该有多大 最后一片可能不满
+func (m Meta) ChunkLen(index int) int64 {
+	if index == m.ChunkCount-1 {
+		return m.Size - m.ChunkSize*int64(m.ChunkCount-1)
+	}
+	return m.ChunkSize
+}
+
+// Store 分片和文件放在同一个存储里面 多个实例都能接着传同一个任务
+// chunks/{uploadID}/meta.json
+// chunks/{uploadID}/{index}.part
+// dir只放合并时的临时文件 一次请求里面用完就删 不用在实例之间共享
+type Store struct {
+	storage   storage.Storage
+	dir       string
+	chunkSize int64
+	expire    time.Duration
+}
+
+func NewStore(s storage.Storage, dir string, chunkSize int64, expire time.Duration) *Store {
+	os.MkdirAll(dir, 0755)
+	return &Store{
+		storage:   s,
+		dir:       dir,
+		chunkSize: chunkSize,
+		expire:    expire,
+	}
+}
+
+// uploadID 同一个用户上传同一个文件 得到的是同一个任务 这样就能断点续传
+func uploadID(userID uint, hash string, size int64) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s:%d", userID, hash, size)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// isUploadID id是客户端传的 只能是uploadID生成的32位hex 不能带路径
+func isUploadID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil && strings.ToLower(id) == id
+}
+
+func key(id string, name string) string {
+	return keyPrefix + id + "/" + name
+}
+
+func partName(index int) string {
+	return fmt.Sprintf("%d.part", index)
+}
+
+// Init 创建上传任务 已经有了就直接返回之前的
+func (s *Store) Init(ctx context.Context, userID uint, fileType, fileName string, size int64, hash string) (*Meta, error) {
+	hash = strings.ToLower(hash)
+	id := uploadID(userID, hash, size)
+	meta, err := s.Meta(ctx, id)
+	if err == nil {
+		return meta, nil
+	}
+
+	meta = &Meta{
+		UploadID:   id,
+		UserID:     userID,
+		FileType:   fileType,
+		FileName:   fileName,
+		Size:       size,
+		Hash:       hash,
+		ChunkSize:  s.chunkSize,
+		ChunkCount: int((size + s.chunkSize - 1) / s.chunkSize),
+		CreatedAt:  time.Now(),
+	}
+	byteData, _ := json.Marshal(meta)
+	err = s.storage.Put(ctx, key(id, metaName), bytes.NewReader(byteData), int64(len(byteData)), "application/json")
+	if err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// Meta 查上传任务
+func (s *Store) Meta(ctx context.Context, id string) (*Meta, error) {
+	if !isUploadID(id) {
+		return nil, ErrNotFound
+	}
+	body, _, err := s.storage.Get(ctx, key(id, metaName))
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	defer body.Close()
+	var meta Meta
+	if err = json.NewDecoder(body).Decode(&meta); err != nil {
+		return nil, ErrNotFound
+	}
+	return &meta, nil
+}
+
+// Put 保存一个分片 读完校验了大小再写到存储里面 重复上传同一片会覆盖
+// 一片最多读ChunkSize到内存里面
+func (s *Store) Put(ctx context.Context, meta *Meta, index int, r io.Reader) error {
+	if index < 0 || index >= meta.ChunkCount {
+		return ErrIndex
+	}
+	want := meta.ChunkLen(index)
+	// 多读一个字节 用来判断是不是传多了
+	byteData, err := io.ReadAll(io.LimitReader(r, want+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(byteData)) != want {
+		return ErrChunkSize
+	}
+	return s.storage.Put(ctx, key(meta.UploadID, partName(index)), bytes.NewReader(byteData), want, "")
+}
+
+// Received 已经收到的分片序号
+func (s *Store) Received(ctx context.Context, meta *Meta) []int {
+	list := []int{}
+	objects, err := s.storage.List(ctx, key(meta.UploadID, ""))
+	if err != nil {
+		logx.Error(err)
+		return list
+	}
+	for _, obj := range objects {
+		name, ok := strings.CutSuffix(strings.TrimPrefix(obj.Key, key(meta.UploadID, "")), ".part")
+		if !ok {
+			continue
+		}
+		index, err := strconv.Atoi(name)
+		if err != nil || index < 0 || index >= meta.ChunkCount {
+			continue
+		}
+		list = append(list, index)
+	}
+	sort.Ints(list)
+	return list
+}
+
+// Complete 按顺序把分片写到w里面 同时校验hash 不管成功失败都会删掉分片
+// 同一个任务不能同时合并 调用方要先拿到锁
+func (s *Store) Complete(ctx context.Context, meta *Meta, w io.Writer) error {
+	if len(s.Received(ctx, meta)) != meta.ChunkCount {
+		return ErrIncomplete
+	}
+
+	hash := sha256.New()
+	w = io.MultiWriter(w, hash)
+	for i := 0; i < meta.ChunkCount; i++ {
+		part, _, err := s.storage.Get(ctx, key(meta.UploadID, partName(i)))
+		if err != nil {
+			return ErrIncomplete
+		}
+		_, err = io.Copy(w, part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+	s.Remove(ctx, meta.UploadID)
+	if hex.EncodeToString(hash.Sum(nil)) != meta.Hash {
+		return ErrHash
+	}
+	return nil
+}
+
+// CreateTemp 合并用的临时文件
+func (s *Store) CreateTemp() (*os.File, error) {
+	return os.CreateTemp(s.dir, "*.tmp")
+}
+
+// Remove 删掉上传任务和它的分片
+func (s *Store) Remove(ctx context.Context, id string) {
+	objects, err := s.storage.List(ctx, key(id, ""))
+	if err != nil {
+		logx.Error(err)
+		return
+	}
+	for _, obj := range objects {
+		err = s.storage.Delete(ctx, obj.Key)
+		if err != nil {
+			logx.Error(err)
+		}
+	}
+}
+
+// GC 清理超过过期时间没有动静的上传任务 任务最后一次活动的时间就是最后写进去的分片的时间
+// 每个实例都会清理 删同一个文件没有影响
+func (s *Store) GC(ctx context.Context) {
+	objects, err := s.storage.List(ctx, keyPrefix)
+	if err != nil {
+		logx.Error(err)
+		return
+	}
+	lastActive := map[string]time.Time{}
+	for _, obj := range objects {
+		id, _, _ := strings.Cut(strings.TrimPrefix(obj.Key, keyPrefix), "/")
+		if obj.ModTime.After(lastActive[id]) {
+			lastActive[id] = obj.ModTime
+		}
+	}
+	for id, last := range lastActive {
+		if time.Since(last) < s.expire {
+			continue
+		}
+		logx.Infof("清理过期的分片上传 %s", id)
+		s.Remove(ctx, id)
+	}
+
+	// 合并的时候进程退出留下的临时文件
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err == nil && !entry.IsDir() && time.Since(info.ModTime()) > s.expire {
+			os.Remove(filepath.Join(s.dir, entry.Name()))
+		}
+	}
+}
+
+// RunGC 定时清理 阻塞 用go调用
+func (s *Store) RunGC(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.GC(context.Background())
+	}
+}
@@ -0,0 +1,190 @@
+package chunk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fim_server/common/storage"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testStore 分片存在本地存储里面 返回存储的目录
+func testStore(t *testing.T) (*Store, string) {
+	dir := t.TempDir()
+	s, err := storage.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewStore(s, t.TempDir(), 4, time.Hour), dir
+}
+
+var ctx = context.Background()
+
+func testFile(size int) ([]byte, string) {
+	data := bytes.Repeat([]byte("0123456789"), size/10+1)[:size]
+	sum := sha256.Sum256(data)
+	return data, hex.EncodeToString(sum[:])
+}
+
+func TestPutOutOfOrder(t *testing.T) {
+	s, _ := testStore(t)
+	data, hash := testFile(10)
+	meta, err := s.Init(ctx, 1, "file", "a.txt", int64(len(data)), hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.ChunkCount != 3 || meta.ChunkLen(2) != 2 {
+		t.Fatalf("分片数量错误 %+v", meta)
+	}
+
+	// 倒着传 最后一片不满
+	for _, i := range []int{2, 0, 1} {
+		end := (i + 1) * 4
+		if end > len(data) {
+			end = len(data)
+		}
+		err = s.Put(ctx, meta, i, bytes.NewReader(data[i*4:end]))
+		if err != nil {
+			t.Fatalf("第%d片上传失败 %v", i, err)
+		}
+	}
+	if got := s.Received(ctx, meta); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Fatalf("已收到的分片错误 %v", got)
+	}
+
+	// 同一个用户同一个文件再初始化是同一个任务
+	again, err := s.Init(ctx, 1, "file", "a.txt", int64(len(data)), strings.ToUpper(hash))
+	if err != nil || again.UploadID != meta.UploadID {
+		t.Fatalf("断点续传应该是同一个任务 %v %v", again, err)
+	}
+
+	var buf bytes.Buffer
+	err = s.Complete(ctx, meta, &buf)
+	if err != nil || !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("合并错误 %v %q", err, buf.String())
+	}
+	if _, err = s.Meta(ctx, meta.UploadID); err != ErrNotFound {
+		t.Errorf("合并之后任务要删掉 %v", err)
+	}
+}
+
+func TestPutSize(t *testing.T) {
+	s, _ := testStore(t)
+	data, hash := testFile(10)
+	meta, _ := s.Init(ctx, 1, "file", "a.txt", int64(len(data)), hash)
+
+	cases := []struct {
+		name  string
+		index int
+		data  []byte
+		err   error
+	}{
+		{"少了", 0, data[:3], ErrChunkSize},
+		{"多了", 0, data[:5], ErrChunkSize},
+		{"最后一片按满的传", 2, data[:4], ErrChunkSize},
+		{"序号太大", 3, data[:2], ErrIndex},
+		{"序号是负数", -1, data[:4], ErrIndex},
+		{"正好", 0, data[:4], nil},
+	}
+	for _, c := range cases {
+		err := s.Put(ctx, meta, c.index, bytes.NewReader(c.data))
+		if err != c.err {
+			t.Errorf("%s 期望%v 实际%v", c.name, c.err, err)
+		}
+	}
+	if got := s.Received(ctx, meta); !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("大小不对的分片不能留下 %v", got)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s, _ := testStore(t)
+	data, _ := testFile(8)
+
+	// 没传完
+	_, hash := testFile(8)
+	meta, _ := s.Init(ctx, 1, "file", "a.txt", 8, hash)
+	s.Put(ctx, meta, 0, bytes.NewReader(data[:4]))
+	if err := s.Complete(ctx, meta, &bytes.Buffer{}); err != ErrIncomplete {
+		t.Errorf("没传完应该报错 %v", err)
+	}
+
+	// 内容和声明的hash对不上 分片也要删掉
+	_, wrongHash := testFile(9)
+	meta, _ = s.Init(ctx, 2, "file", "b.txt", 8, wrongHash)
+	s.Put(ctx, meta, 0, bytes.NewReader(data[:4]))
+	s.Put(ctx, meta, 1, bytes.NewReader(data[4:]))
+	if err := s.Complete(ctx, meta, &bytes.Buffer{}); err != ErrHash {
+		t.Errorf("hash不对应该报错 %v", err)
+	}
+	if _, err := s.Meta(ctx, meta.UploadID); err != ErrNotFound {
+		t.Errorf("校验失败也要删掉任务 %v", err)
+	}
+}
+
+func TestMetaPath(t *testing.T) {
+	s, _ := testStore(t)
+	for _, id := range []string{"", "../x", `a\b`, "a/b", "..", "nope", strings.Repeat("../", 10) + "ab", strings.Repeat("A", 32)} {
+		if _, err := s.Meta(ctx, id); err != ErrNotFound {
+			t.Errorf("%q 应该找不到 %v", id, err)
+		}
+	}
+}
+
+func TestGC(t *testing.T) {
+	s, dir := testStore(t)
+	_, hash := testFile(8)
+	old, _ := s.Init(ctx, 1, "file", "old.txt", 8, hash)
+	active, _ := s.Init(ctx, 2, "file", "new.txt", 8, hash)
+	tmp, _ := s.CreateTemp()
+	tmp.Close()
+
+	// 过期的任务和合并留下的临时文件改成两个小时之前
+	past := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(filepath.Join(dir, "chunks", old.UploadID, metaName), past, past)
+	os.Chtimes(tmp.Name(), past, past)
+
+	s.GC(ctx)
+	if _, err := s.Meta(ctx, old.UploadID); err != ErrNotFound {
+		t.Errorf("过期的任务要清理 %v", err)
+	}
+	if _, err := s.Meta(ctx, active.UploadID); err != nil {
+		t.Errorf("没过期的任务不能清理 %v", err)
+	}
+	if _, err := os.Stat(tmp.Name()); !os.IsNotExist(err) {
+		t.Errorf("过期的临时文件要清理 %v", err)
+	}
+}
+
+// TestSharedStorage 两个实例用同一个存储 一个实例上开始的任务 另一个实例能接着传和合并
+func TestSharedStorage(t *testing.T) {
+	shared, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewStore(shared, t.TempDir(), 4, time.Hour)
+	b := NewStore(shared, t.TempDir(), 4, time.Hour)
+	data, hash := testFile(8)
+
+	meta, _ := a.Init(ctx, 1, "file", "a.txt", 8, hash)
+	a.Put(ctx, meta, 0, bytes.NewReader(data[:4]))
+	again, err := b.Init(ctx, 1, "file", "a.txt", 8, hash)
+	if err != nil || !reflect.DeepEqual(b.Received(ctx, again), []int{0}) {
+		t.Fatalf("另一个实例看不到已经传的分片 %v", err)
+	}
+	b.Put(ctx, again, 1, bytes.NewReader(data[4:]))
+
+	var buf bytes.Buffer
+	if err = b.Complete(ctx, again, &buf); err != nil || !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("合并错误 %v %q", err, buf.String())
+	}
+	if _, err = a.Meta(ctx, meta.UploadID); err != ErrNotFound {
+		t.Errorf("合并之后两个实例都看不到任务 %v", err)
+	}
+}
@@ -1,12 +1,51 @@
 package config
 
-import "github.com/zeromicro/go-zero/rest"
+import (
+	"fim_server/common/storage"
+
+	"github.com/zeromicro/go-zero/rest"
+)
 
 type Config struct {
 	rest.RestConf
-	Etcd      string
+	Mysql struct {
+		DataSource string
+	}
+	Etcd  string
+	Redis struct {
+		Addr string
+		Pwd  string
+		DB   int
+	}
 	FileSize  int64    // 文件大小限制 单位 字节
 	WhiteList []string // 图片上传的白名单
-	UploadDir string   // 上传文件保存的目录
+	Image     struct {
+		MaxPixels   int              // 图片宽乘高的上限 解码之前就检查 防止解压炸弹
+		JpegQuality int              // 重新编码jpeg的质量
+		Thumbnail   map[string][]int // 每种图片要生成的缩略图 长边的像素 key是 avatar group_avatar chat
+	}
+	Storage       storage.Conf // 文件存储 local 本地目录 s3 对象存储
+	PresignExpire int          // s3预签名下载地址的有效期 单位 秒
+	Sign          struct {
+		Secret string // 私有文件下载地址的签名密钥
+		Expire int    // 签名地址的有效期 单位 秒
+	}
+	Quota struct {
+		Default int64            // 默认的存储配额 单位 字节 0是不限制
+		Role    map[string]int64 // 按角色的配额 key是角色 1 管理员 2 普通用户
+	}
+	FileLimit map[string]FileLimit // 文件上传按类型的限制 key是 file video voice
+	Chunk     struct {
+		Dir        string // 合并分片用的临时目录 分片本身存在Storage里面 多实例部署的时候哪个实例都能接着传
+		Size       int64  // 每一片的大小 单位 字节
+		Expire     int    // 多久没有动静的上传任务会被清理 单位 小时
+		GCInterval int    // 清理的间隔 单位 分钟
+	}
+}
 
+// FileLimit 某一类文件的上传限制
+type FileLimit struct {
+	MaxSize       int64    // 大小限制 单位 字节
+	MimeList      []string // 允许的mime前缀 为空就是不限制
+	BlackMimeList []string // 不允许的mime
 }
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"fim_server/common/response"
+	"fim_server/fim_file/file_api/internal/logic"
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func ChunkCompleteHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.ChunkCompleteRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			response.Response(r, w, nil, err)
+			return
+		}
+
+		l := logic.NewChunkCompleteLogic(r.Context(), svcCtx)
+		resp, err := l.ChunkComplete(&req)
+		response.Response(r, w, resp, err)
+
+	}
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"fim_server/common/response"
+	"fim_server/fim_file/file_api/internal/logic"
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func FileHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.FileRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			response.Response(r, w, nil, err)
+			return
+		}
+
+		file, fileHead, err := r.FormFile("file")
+		if err != nil {
+			response.Response(r, w, nil, err)
+			return
+		}
+		defer file.Close()
+
+		l := logic.NewFileLogic(r.Context(), svcCtx)
+		resp, err := l.File(&req, file, fileHead)
+		response.Response(r, w, resp, err)
+	}
+}
@@ -2,15 +2,24 @@ package handler
 
 import (
 	"fim_server/common/response"
+	"fim_server/fim_file/file_api/internal/logic"
 	"fim_server/fim_file/file_api/internal/svc"
 	"fim_server/fim_file/file_api/internal/types"
+	"fim_server/fim_file/file_models"
 	"net/http"
-	"os"
 	"path"
+	"regexp"
+	"strings"
 
 	"github.com/zeromicro/go-zero/rest/httpx"
 )
 
+var (
+	// 路径参数只能是普通的名字 不能有 .. 和 /
+	imageTypeRegex = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+	imageNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_\-][a-zA-Z0-9_\-.]*$`)
+)
+
 func ImageShowHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req types.ImageShowRequest
@@ -19,15 +28,34 @@ func ImageShowHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
 			return
 		}
 
-		filePath := path.Join("fim_file/file_api/uploads/", req.ImageType, req.ImageName)
-
-		file, err := os.ReadFile(filePath)
-		if err != nil {
-			response.Response(r, w, nil, err)
+		if !imageTypeRegex.MatchString(req.ImageType) || !imageNameRegex.MatchString(req.ImageName) || strings.Contains(req.ImageName, "..") {
+			http.Error(w, "文件不存在", http.StatusNotFound)
 			return
 		}
+		key := path.Join(req.ImageType, req.ImageName)
 
-		w.Write(file)
+		// 这个地址不用登录 只有头像能直接看 其他文件要用签名地址
+		var mime string
+		if match := hashKeyRegex.FindStringSubmatch(key); match != nil {
+			var fileModel file_models.FileModel
+			err := svcCtx.DB.Take(&fileModel, "hash = ?", match[1]).Error
+			if err != nil {
+				http.Error(w, "文件不存在", http.StatusNotFound)
+				return
+			}
+			if !logic.IsPublicFile(svcCtx.DB, fileModel.ID) {
+				http.Error(w, "私有文件请使用签名地址", http.StatusForbidden)
+				return
+			}
+			mime = fileModel.Mime
+		} else if !logic.IsPublicType(req.ImageType) {
+			http.Error(w, "私有文件请使用签名地址", http.StatusForbidden)
+			return
+		}
 
+		if req.W > 0 {
+			key = variantKey(r.Context(), svcCtx, key, req.W)
+		}
+		serveFile(w, r, svcCtx, key, req.ImageName, mime, "")
 	}
 }
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"fim_server/fim_file/file_api/internal/logic"
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func PrivateHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.PrivateRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			http.Error(w, "签名错误", http.StatusForbidden)
+			return
+		}
+
+		l := logic.NewPrivateLogic(r.Context(), svcCtx)
+		fileModel, err := l.Private(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		key := fileModel.Key()
+		if req.W > 0 {
+			key = variantKey(r.Context(), svcCtx, key, req.W)
+		}
+		// 签名过期之后浏览器也不能再用缓存
+		maxAge := req.Expire - time.Now().Unix()
+		serveFile(w, r, svcCtx, key, fileModel.Hash, fileModel.Mime, fmt.Sprintf("private, max-age=%d", maxAge))
+	}
+}
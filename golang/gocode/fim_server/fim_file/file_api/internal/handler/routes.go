@@ -14,6 +14,41 @@ import (
 func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 	server.AddRoutes(
 		[]rest.Route{
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/file/chunk",
+				Handler: ChunkInitHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/file/chunk/:uploadID",
+				Handler: ChunkInfoHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPut,
+				Path:    "/api/file/chunk/:uploadID/:index",
+				Handler: ChunkUploadHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/file/chunk/:uploadID/complete",
+				Handler: ChunkCompleteHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/file/file",
+				Handler: FileHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodDelete,
+				Path:    "/api/file/file/:hash",
+				Handler: FileDeleteHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/file/file/check",
+				Handler: FileCheckHandler(serverCtx),
+			},
 			{
 				Method:  http.MethodPost,
 				Path:    "/api/file/image",
@@ -21,9 +56,34 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 			},
 			{
 				Method:  http.MethodGet,
+				Path:    "/api/file/private/:fileID",
+				Handler: PrivateHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodHead,
+				Path:    "/api/file/private/:fileID",
+				Handler: PrivateHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/file/sign",
+				Handler: FileSignHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/file/uploads/:imageType/:imageName",
+				Handler: ImageShowHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodHead,
 				Path:    "/api/file/uploads/:imageType/:imageName",
 				Handler: ImageShowHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/file/usage",
+				Handler: UsageHandler(serverCtx),
+			},
 		},
 	)
 }
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"fim_server/common/storage"
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_models"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// hashKeyRegex 按内容hash保存的文件 ab/abcdef... 缩略图后面带 _128
+var hashKeyRegex = regexp.MustCompile(`^[0-9a-f]{2}/([0-9a-f]{64})(_\d+)?$`)
+
+// serveFile 输出存储里面的文件 cacheControl为空就按是不是内容寻址的文件来定
+func serveFile(w http.ResponseWriter, r *http.Request, svcCtx *svc.ServiceContext, key, name, mime, cacheControl string) {
+	// 对象存储直接重定向到预签名地址 不经过服务 Range这些由对象存储处理
+	expire := time.Duration(svcCtx.Config.PresignExpire) * time.Second
+	url, err := svcCtx.Storage.PresignedURL(r.Context(), key, expire)
+	if err == nil {
+		http.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+
+	body, obj, err := svcCtx.Storage.Get(r.Context(), key)
+	if err != nil {
+		if err != storage.ErrNotFound {
+			logx.Errorf("文件读取失败 %s %s", key, err)
+			http.Error(w, "文件读取失败", http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, "文件不存在", http.StatusNotFound)
+		return
+	}
+	defer body.Close()
+
+	header := w.Header()
+	if match := hashKeyRegex.FindStringSubmatch(key); match != nil {
+		// 内容不会变 可以一直缓存
+		header.Set("ETag", fmt.Sprintf(`"%s%s"`, match[1], match[2]))
+		header.Set("Cache-Control", "public, max-age=31536000, immutable")
+		if match[2] == "" && mime != "" {
+			header.Set("Content-Type", mime)
+		}
+	} else {
+		// 以前按文件名保存的 同名文件可能被覆盖
+		header.Set("ETag", fmt.Sprintf(`"%x-%x"`, obj.ModTime.Unix(), obj.Size))
+		header.Set("Cache-Control", "public, max-age=3600")
+	}
+	if cacheControl != "" {
+		header.Set("Cache-Control", cacheControl)
+	}
+	if header.Get("Content-Type") == "" && obj.ContentType != "" {
+		header.Set("Content-Type", obj.ContentType)
+	}
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	// ServeContent处理Range If-None-Match If-Modified-Since HEAD
+	// 没有Content-Type的时候按内容识别
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, name, obj.ModTime, seeker)
+		return
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/octet-stream")
+	}
+	header.Set("Content-Length", fmt.Sprintf("%d", obj.Size))
+	io.Copy(w, body)
+}
+
+// variantKey 配置里面有这个尺寸 而且缩略图已经生成了才用缩略图 不然就是原图
+func variantKey(ctx context.Context, svcCtx *svc.ServiceContext, key string, size int) string {
+	for _, sizeList := range svcCtx.Config.Image.Thumbnail {
+		if !slices.Contains(sizeList, size) {
+			continue
+		}
+		variant := file_models.VariantKey(key, size)
+		if _, err := svcCtx.Storage.Stat(ctx, variant); err == nil {
+			return variant
+		}
+		break
+	}
+	return key
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fim_server/common/storage"
+	"fim_server/fim_file/file_api/internal/svc"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testServe(t *testing.T) (*svc.ServiceContext, string) {
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello world")
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := hash[:2] + "/" + hash
+	for _, k := range []string{key, "old/a.txt"} {
+		err = store.Put(context.Background(), k, bytes.NewReader(data), int64(len(data)), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &svc.ServiceContext{Storage: store}, key
+}
+
+func TestServeFile(t *testing.T) {
+	svcCtx, key := testServe(t)
+	etag := `"` + key[3:] + `"`
+	cases := []struct {
+		name   string
+		key    string
+		header map[string]string
+		status int
+		body   string
+		check  map[string]string
+	}{
+		{"整个文件", key, nil, http.StatusOK, "hello world", map[string]string{
+			"ETag":          etag,
+			"Cache-Control": "public, max-age=31536000, immutable",
+			"Content-Type":  "text/plain",
+		}},
+		{"Range", key, map[string]string{"Range": "bytes=6-10"}, http.StatusPartialContent, "world", map[string]string{
+			"Content-Range": "bytes 6-10/11",
+		}},
+		{"ETag没变", key, map[string]string{"If-None-Match": etag}, http.StatusNotModified, "", nil},
+		{"ETag变了", key, map[string]string{"If-None-Match": `"other"`}, http.StatusOK, "hello world", nil},
+		{"按文件名保存的", "old/a.txt", nil, http.StatusOK, "hello world", map[string]string{
+			"Cache-Control": "public, max-age=3600",
+		}},
+		{"不存在", "ab/none", nil, http.StatusNotFound, "文件不存在\n", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/file/x", nil)
+			for k, v := range c.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			serveFile(w, r, svcCtx, c.key, "a.txt", "text/plain", "")
+			if w.Code != c.status || w.Body.String() != c.body {
+				t.Fatalf("%d %q", w.Code, w.Body.String())
+			}
+			for k, v := range c.check {
+				if got := w.Header().Get(k); got != v {
+					t.Errorf("%s %q", k, got)
+				}
+			}
+			if c.status != http.StatusNotFound && w.Header().Get("X-Content-Type-Options") != "nosniff" {
+				t.Error("没有nosniff")
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_file/file_api/internal/chunk"
+	"fmt"
+	"os"
+	"time"
+
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// completeLockTime 合并的锁 进程退出没有释放的话过这么久自动释放
+const completeLockTime = 10 * time.Minute
+
+type ChunkCompleteLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChunkCompleteLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChunkCompleteLogic {
+	return &ChunkCompleteLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ChunkCompleteLogic) ChunkComplete(req *types.ChunkCompleteRequest) (resp *types.FileResponse, err error) {
+	store := l.svcCtx.ChunkStore
+	meta, err := chunkMeta(l.ctx, store, req.UserID, req.UploadID)
+	if err != nil {
+		return
+	}
+	limit, err := fileLimit(l.svcCtx, meta.FileType, meta.Size)
+	if err != nil {
+		store.Remove(l.ctx, meta.UploadID)
+		return
+	}
+
+	// 分片在共享的存储里面 不同实例同时合并同一个任务 只能有一个在合并
+	lockKey := fmt.Sprintf("chunk_complete_%s", meta.UploadID)
+	ok, err := l.svcCtx.Redis.SetNX(lockKey, 1, completeLockTime).Result()
+	if err != nil {
+		l.Error(err)
+		return nil, errors.New("文件合并失败")
+	}
+	if !ok {
+		return nil, chunk.ErrCompleting
+	}
+	defer l.svcCtx.Redis.Del(lockKey)
+
+	tmp, err := store.CreateTemp()
+	if err != nil {
+		l.Error(err)
+		return nil, errors.New("文件保存失败")
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	err = store.Complete(l.ctx, meta, tmp)
+	if err != nil {
+		if err == chunk.ErrIncomplete || err == chunk.ErrHash || err == chunk.ErrCompleting {
+			return nil, err
+		}
+		l.Error(err)
+		return nil, errors.New("文件合并失败")
+	}
+
+	mime, duration, err := checkFile(limit, meta.FileType, tmp, meta.Size)
+	if err != nil {
+		return
+	}
+	// 合并的时候已经校验过hash了 不用再算一遍
+	fileModel, err := saveFile(l.ctx, l.svcCtx, req.UserID, meta.FileType, meta.FileName, tmp, meta.Size, mime, duration, meta.Hash)
+	if err != nil {
+		return
+	}
+	return fileResponse(fileModel, meta.FileType, meta.FileName), nil
+}
@@ -0,0 +1,32 @@
+package logic
+
+import (
+	"context"
+
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ChunkInfoLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChunkInfoLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChunkInfoLogic {
+	return &ChunkInfoLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ChunkInfoLogic) ChunkInfo(req *types.ChunkInfoRequest) (resp *types.ChunkInfoResponse, err error) {
+	meta, err := chunkMeta(l.ctx, l.svcCtx.ChunkStore, req.UserID, req.UploadID)
+	if err != nil {
+		return
+	}
+	return chunkInfo(l.ctx, l.svcCtx.ChunkStore, meta), nil
+}
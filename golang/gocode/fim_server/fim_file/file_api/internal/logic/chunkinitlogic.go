@@ -0,0 +1,78 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_file/file_api/internal/chunk"
+	"path"
+	"regexp"
+
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ChunkInitLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChunkInitLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChunkInitLogic {
+	return &ChunkInitLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+var hashRegex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+func (l *ChunkInitLogic) ChunkInit(req *types.ChunkInitRequest) (resp *types.ChunkInfoResponse, err error) {
+	_, err = fileLimit(l.svcCtx, req.FileType, req.Size)
+	if err != nil {
+		return
+	}
+	if !hashRegex.MatchString(req.Hash) {
+		return nil, errors.New("hash必须是sha256")
+	}
+	if req.FileName == "" {
+		return nil, errors.New("文件名不能为空")
+	}
+	// 传之前先看配额 免得传完了才发现超了
+	err = checkQuota(l.svcCtx, req.UserID, req.Size)
+	if err != nil {
+		return
+	}
+
+	meta, err := l.svcCtx.ChunkStore.Init(l.ctx, req.UserID, req.FileType, path.Base(req.FileName), req.Size, req.Hash)
+	if err != nil {
+		l.Error(err)
+		return nil, errors.New("创建上传任务失败")
+	}
+	return chunkInfo(l.ctx, l.svcCtx.ChunkStore, meta), nil
+}
+
+func chunkInfo(ctx context.Context, store *chunk.Store, meta *chunk.Meta) *types.ChunkInfoResponse {
+	return &types.ChunkInfoResponse{
+		UploadID:   meta.UploadID,
+		FileName:   meta.FileName,
+		Size:       meta.Size,
+		ChunkSize:  meta.ChunkSize,
+		ChunkCount: meta.ChunkCount,
+		Received:   store.Received(ctx, meta),
+	}
+}
+
+// chunkMeta 查上传任务 只能操作自己的
+func chunkMeta(ctx context.Context, store *chunk.Store, userID uint, uploadID string) (*chunk.Meta, error) {
+	meta, err := store.Meta(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if meta.UserID != userID {
+		return nil, chunk.ErrUploadOwner
+	}
+	return meta, nil
+}
@@ -0,0 +1,38 @@
+package logic
+
+import (
+	"context"
+	"io"
+
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ChunkUploadLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewChunkUploadLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ChunkUploadLogic {
+	return &ChunkUploadLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ChunkUploadLogic) ChunkUpload(req *types.ChunkUploadRequest, body io.Reader) (resp *types.ChunkUploadResponse, err error) {
+	meta, err := chunkMeta(l.ctx, l.svcCtx.ChunkStore, req.UserID, req.UploadID)
+	if err != nil {
+		return
+	}
+	err = l.svcCtx.ChunkStore.Put(l.ctx, meta, req.Index, body)
+	if err != nil {
+		return
+	}
+	resp = new(types.ChunkUploadResponse)
+	return
+}
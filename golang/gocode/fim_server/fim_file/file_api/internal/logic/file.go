@@ -0,0 +1,259 @@
+package logic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fim_server/fim_file/file_api/internal/config"
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+	"fim_server/fim_file/file_models"
+	"fim_server/utils"
+	"fim_server/utils/media"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// fileReader 能随机读的文件 上传的multipart.File和本地文件都满足
+type fileReader interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+}
+
+// fileLimit 拿到某一类文件的限制 顺便校验大小
+func fileLimit(svcCtx *svc.ServiceContext, fileType string, size int64) (limit config.FileLimit, err error) {
+	limit, ok := svcCtx.Config.FileLimit[fileType]
+	if !ok {
+		return limit, errors.New("fileType只能为 file,video,voice")
+	}
+	limit.MaxSize = maxFileSize(svcCtx, fileType, limit.MaxSize)
+	if size <= 0 {
+		return limit, errors.New("文件不能为空")
+	}
+	if size > limit.MaxSize {
+		return limit, fmt.Errorf("文件大小超过限制，最大只能上传%.1fMB大小的文件", float64(limit.MaxSize)/1024/1024)
+	}
+	return limit, nil
+}
+
+// maxFileSize 后台系统设置里面改过的大小上限优先 没有就用配置文件的
+func maxFileSize(svcCtx *svc.ServiceContext, fileType string, size int64) int64 {
+	info := svcCtx.Settings.Load()
+	if info == nil {
+		return size
+	}
+	if s, ok := info.FileLimit[fileType]; ok && s > 0 {
+		return s
+	}
+	return size
+}
+
+// checkFile 文件类型看文件内容 不看后缀 音视频顺便解析时长
+func checkFile(limit config.FileLimit, fileType string, file fileReader, size int64) (mime string, duration int, err error) {
+	mime, err = detectType(file)
+	if err != nil {
+		return "", 0, err
+	}
+	if !checkMime(limit, mime) {
+		return "", 0, fmt.Errorf("不支持的文件类型 %s", mime)
+	}
+
+	if fileType == "video" || fileType == "voice" {
+		seconds, err := media.Duration(file, size, mime)
+		if err != nil {
+			logx.Errorf("时长解析失败 %s %s", mime, err)
+		}
+		duration = int(math.Ceil(seconds))
+	}
+	return mime, duration, nil
+}
+
+func detectType(file io.ReaderAt) (string, error) {
+	head := make([]byte, media.SniffLen)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", errors.New("文件读取失败")
+	}
+	return media.DetectType(head[:n]), nil
+}
+
+// checkMime 先看黑名单 再看白名单的前缀
+func checkMime(limit config.FileLimit, mime string) bool {
+	if utils.InList(limit.BlackMimeList, mime) {
+		return false
+	}
+	if len(limit.MimeList) == 0 {
+		return true
+	}
+	for _, prefix := range limit.MimeList {
+		if strings.HasPrefix(mime, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileHash 文件内容的sha256
+func fileHash(file io.ReadSeeker) (string, error) {
+	_, err := file.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// writeBlob 从头把文件写到存储里面
+func writeBlob(ctx context.Context, svcCtx *svc.ServiceContext, fileModel file_models.FileModel, file io.ReadSeeker) error {
+	_, err := file.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	return svcCtx.Storage.Put(ctx, fileModel.Key(), file, fileModel.Size, fileModel.Mime)
+}
+
+// saveFile 按内容的hash保存文件 已经有一样的文件就不写了 只加一次引用
+// hash为空的时候自己算
+func saveFile(ctx context.Context, svcCtx *svc.ServiceContext, userID uint, fileType, name string, file fileReader, size int64, mime string, duration int, hash string) (fileModel file_models.FileModel, err error) {
+	err = checkQuota(svcCtx, userID, size)
+	if err != nil {
+		return
+	}
+	if hash == "" {
+		hash, err = fileHash(file)
+		if err != nil {
+			logx.Error(err)
+			return fileModel, errors.New("文件读取失败")
+		}
+	}
+
+	// 引用的时候文件正好被删了 就重新写一次
+	for i := 0; i < 3; i++ {
+		err = svcCtx.DB.Take(&fileModel, "hash = ?", hash).Error
+		if err != nil {
+			fileModel = file_models.FileModel{
+				Hash:     hash,
+				Size:     size,
+				Mime:     mime,
+				Duration: duration,
+				UserID:   userID,
+				Name:     name,
+			}
+			err = writeBlob(ctx, svcCtx, fileModel, file)
+			if err != nil {
+				logx.Error(err)
+				return fileModel, errors.New("文件保存失败")
+			}
+			err = svcCtx.DB.Create(&fileModel).Error
+			if err != nil {
+				// 别人同时传了一样的文件 用别人的记录
+				err = svcCtx.DB.Take(&fileModel, "hash = ?", hash).Error
+				if err != nil {
+					logx.Error(err)
+					return fileModel, errors.New("文件保存失败")
+				}
+			}
+		}
+
+		err = addFileRef(svcCtx.DB, &fileModel, userID, fileType, name, false)
+		if err == nil {
+			return fileModel, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			logx.Error(err)
+			return fileModel, errors.New("文件保存失败")
+		}
+	}
+	return fileModel, errors.New("文件保存失败")
+}
+
+// addFileRef 加一次引用 文件记录已经被删了就返回ErrRecordNotFound
+// quick是秒传 不能拿来证明这个人能看这个文件
+func addFileRef(db *gorm.DB, fileModel *file_models.FileModel, userID uint, fileType, name string, quick bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(fileModel).Where("id = ?", fileModel.ID).
+			Update("ref_count", gorm.Expr("ref_count + 1"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		fileModel.RefCount++
+		return tx.Create(&file_models.FileRefModel{
+			FileID:   fileModel.ID,
+			UserID:   userID,
+			FileType: fileType,
+			Name:     name,
+			Size:     fileModel.Size,
+			Quick:    quick,
+		}).Error
+	})
+}
+
+// releaseFileRef 删掉一次引用 引用数到0的时候删文件
+// 删文件在事务里面做 这时候文件记录是锁住的 同时来引用的请求会等事务结束 发现记录没了再重新写文件
+func releaseFileRef(ctx context.Context, svcCtx *svc.ServiceContext, ref file_models.FileRefModel) error {
+	return svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(&ref).Error
+		if err != nil {
+			return err
+		}
+		var fileModel file_models.FileModel
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&fileModel, ref.FileID).Error
+		if err != nil {
+			return err
+		}
+		if fileModel.RefCount > 1 {
+			return tx.Model(&fileModel).Update("ref_count", gorm.Expr("ref_count - 1")).Error
+		}
+		err = svcCtx.Storage.Delete(ctx, fileModel.Key())
+		if err != nil {
+			return err
+		}
+		for _, size := range thumbnailSizeList(svcCtx) {
+			err = svcCtx.Storage.Delete(ctx, file_models.VariantKey(fileModel.Key(), size))
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&fileModel).Error
+	})
+}
+
+// fileResponse 上传接口统一的返回
+func fileResponse(fileModel file_models.FileModel, fileType, name string) *types.FileResponse {
+	return &types.FileResponse{
+		Url:      fileModel.Url(),
+		Title:    name,
+		Size:     fileModel.Size,
+		Type:     fileModel.Mime,
+		Time:     fileModel.Duration,
+		FileType: fileType,
+	}
+}
+
+// thumbnailSizeList 所有配置的缩略图尺寸 去重
+func thumbnailSizeList(svcCtx *svc.ServiceContext) (list []int) {
+	set := map[int]bool{}
+	for _, sizeList := range svcCtx.Config.Image.Thumbnail {
+		for _, size := range sizeList {
+			if !set[size] {
+				set[size] = true
+				list = append(list, size)
+			}
+		}
+	}
+	return
+}
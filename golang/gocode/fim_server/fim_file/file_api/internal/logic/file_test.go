@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fim_server/common/storage"
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_models"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// testSvc 本地存储放在临时目录 没有配额
+func testSvc(t *testing.T) (*svc.ServiceContext, sqlmock.Sqlmock) {
+	db, mock := testDB(t)
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &svc.ServiceContext{DB: db, Storage: store}, mock
+}
+
+// expectNoQuota 没单独设置配额 角色也没有配置
+func expectNoQuota(mock sqlmock.Sqlmock, userID uint) {
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `file_quota_models` WHERE user_id = ?")).WithArgs(userID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `role` FROM `user_models`")).WithArgs(userID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(2))
+}
+
+func testHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// TestSaveFileDedup 一样的文件已经有了 不再写存储 只加一次引用
+func TestSaveFileDedup(t *testing.T) {
+	svcCtx, mock := testSvc(t)
+	data := []byte("hello")
+	hash := testHash(data)
+
+	expectNoQuota(mock, 2)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `file_models` WHERE hash = ?")).WithArgs(hash, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hash", "size", "user_id", "ref_count"}).AddRow(5, hash, 5, 1, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `file_models` SET `ref_count`=ref_count + 1")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `file_ref_models`")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 5, 2, "chat", "a.txt", 5, false).
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectCommit()
+
+	fileModel, err := saveFile(context.Background(), svcCtx, 2, "chat", "a.txt", bytes.NewReader(data), 5, "text/plain", 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileModel.ID != 5 || fileModel.RefCount != 2 {
+		t.Errorf("引用数不对 %+v", fileModel)
+	}
+	if _, err = svcCtx.Storage.Stat(context.Background(), fileModel.Key()); err != storage.ErrNotFound {
+		t.Errorf("已经有的文件又写了一次 %v", err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestReleaseFileRef 还有别的引用只减引用数 减到0才删文件
+func TestReleaseFileRef(t *testing.T) {
+	cases := []struct {
+		name     string
+		refCount int
+		deleted  bool
+	}{
+		{"还有引用", 2, false},
+		{"最后一个引用", 1, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			svcCtx, mock := testSvc(t)
+			data := []byte("hello")
+			fileModel := file_models.FileModel{Hash: testHash(data), Size: 5}
+			err := svcCtx.Storage.Put(context.Background(), fileModel.Key(), bytes.NewReader(data), 5, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `file_ref_models` WHERE `file_ref_models`.`id` = ?")).
+				WithArgs(9).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(regexp.QuoteMeta("FROM `file_models` WHERE `file_models`.`id` = ? LIMIT ? FOR UPDATE")).
+				WithArgs(5, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "hash", "size", "ref_count"}).
+				AddRow(5, fileModel.Hash, 5, c.refCount))
+			if c.deleted {
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `file_models` WHERE `file_models`.`id` = ?")).
+					WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+			} else {
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `file_models` SET `ref_count`=ref_count - 1")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			mock.ExpectCommit()
+
+			ref := file_models.FileRefModel{FileID: 5}
+			ref.ID = 9
+			err = releaseFileRef(context.Background(), svcCtx, ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = svcCtx.Storage.Stat(context.Background(), fileModel.Key())
+			if (err == storage.ErrNotFound) != c.deleted {
+				t.Errorf("文件删除的时机不对 %v", err)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_file/file_models"
+	"fmt"
+	"path"
+	"strings"
+
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type FileCheckLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewFileCheckLogic(ctx context.Context, svcCtx *svc.ServiceContext) *FileCheckLogic {
+	return &FileCheckLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *FileCheckLogic) FileCheck(req *types.FileCheckRequest) (resp *types.FileCheckResponse, err error) {
+	limit, err := fileLimit(l.svcCtx, req.FileType, req.Size)
+	if err != nil {
+		return
+	}
+	if !hashRegex.MatchString(req.Hash) {
+		return nil, errors.New("hash必须是sha256")
+	}
+
+	resp = new(types.FileCheckResponse)
+	var fileModel file_models.FileModel
+	err = l.svcCtx.DB.Take(&fileModel, "hash = ? and size = ?", strings.ToLower(req.Hash), req.Size).Error
+	if err != nil {
+		// 没有就让客户端正常上传
+		return resp, nil
+	}
+	if !checkMime(limit, fileModel.Mime) {
+		return nil, fmt.Errorf("不支持的文件类型 %s", fileModel.Mime)
+	}
+
+	err = checkQuota(l.svcCtx, req.UserID, fileModel.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	// 当头像秒传会把文件变成公开的 只凭hash和大小不行 还没公开的就正常上传
+	if IsPublicType(req.FileType) && !IsPublicFile(l.svcCtx.DB, fileModel.ID) {
+		return resp, nil
+	}
+
+	name := path.Base(req.FileName)
+	err = addFileRef(l.svcCtx.DB, &fileModel, req.UserID, req.FileType, name, true)
+	if err != nil {
+		// 文件正好被删了 也让客户端正常上传
+		return resp, nil
+	}
+	resp.Exist = true
+	resp.File = fileResponse(fileModel, req.FileType, name)
+	return resp, nil
+}
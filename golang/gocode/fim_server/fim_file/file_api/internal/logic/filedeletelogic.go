@@ -0,0 +1,48 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_file/file_models"
+	"strings"
+
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type FileDeleteLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewFileDeleteLogic(ctx context.Context, svcCtx *svc.ServiceContext) *FileDeleteLogic {
+	return &FileDeleteLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *FileDeleteLogic) FileDelete(req *types.FileDeleteRequest) (resp *types.FileDeleteResponse, err error) {
+	var fileModel file_models.FileModel
+	err = l.svcCtx.DB.Take(&fileModel, "hash = ?", strings.ToLower(req.Hash)).Error
+	if err != nil {
+		return nil, errors.New("文件不存在")
+	}
+	// 只能删自己的引用 同一个文件传了多次就删最近的一次
+	var ref file_models.FileRefModel
+	err = l.svcCtx.DB.Order("id desc").Take(&ref, "file_id = ? and user_id = ?", fileModel.ID, req.UserID).Error
+	if err != nil {
+		return nil, errors.New("文件不存在")
+	}
+	err = releaseFileRef(l.ctx, l.svcCtx, ref)
+	if err != nil {
+		l.Error(err)
+		return nil, errors.New("文件删除失败")
+	}
+	resp = new(types.FileDeleteResponse)
+	return
+}
@@ -0,0 +1,44 @@
+package logic
+
+import (
+	"context"
+	"mime/multipart"
+	"path"
+
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type FileLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewFileLogic(ctx context.Context, svcCtx *svc.ServiceContext) *FileLogic {
+	return &FileLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *FileLogic) File(req *types.FileRequest, file multipart.File, fileHead *multipart.FileHeader) (resp *types.FileResponse, err error) {
+	limit, err := fileLimit(l.svcCtx, req.FileType, fileHead.Size)
+	if err != nil {
+		return nil, err
+	}
+	mime, duration, err := checkFile(limit, req.FileType, file, fileHead.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	name := path.Base(fileHead.Filename)
+	fileModel, err := saveFile(l.ctx, l.svcCtx, req.UserID, req.FileType, name, file, fileHead.Size, mime, duration, "")
+	if err != nil {
+		return nil, err
+	}
+	return fileResponse(fileModel, req.FileType, name), nil
+}
@@ -0,0 +1,149 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/common/models/ctype"
+	"fim_server/fim_chat/chat_models"
+	"fim_server/fim_file/file_models"
+	"fim_server/fim_group/group_models"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+type FileSignLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewFileSignLogic(ctx context.Context, svcCtx *svc.ServiceContext) *FileSignLogic {
+	return &FileSignLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *FileSignLogic) FileSign(req *types.FileSignRequest) (resp *types.FileSignResponse, err error) {
+	var fileModel file_models.FileModel
+	err = l.svcCtx.DB.Take(&fileModel, "hash = ?", strings.ToLower(req.Hash)).Error
+	if err != nil {
+		return nil, errors.New("文件不存在")
+	}
+	if !l.canView(req, fileModel) {
+		return nil, errors.New("没有权限查看这个文件")
+	}
+
+	expire := time.Now().Add(time.Duration(l.svcCtx.Config.Sign.Expire) * time.Second).Unix()
+	sign := FileSign(l.svcCtx.Config.Sign.Secret, fileModel.ID, req.UserID, expire)
+	return &types.FileSignResponse{
+		Url:    fmt.Sprintf("/private/%d?viewer=%d&expire=%d&sign=%s", fileModel.ID, req.UserID, expire, sign),
+		Expire: expire,
+	}, nil
+}
+
+// maxForwardDepth 文件转发了几层之后还能看 往前找发送人能不能看的时候最多找这么多层
+const maxForwardDepth = 3
+
+// canView 头像 自己上传过的 私聊的双方 群成员才能看
+// 消息里面的文件还要发送人自己能看 不然随便发一个别人文件的地址就能拿到签名
+func (l *FileSignLogic) canView(req *types.FileSignRequest, fileModel file_models.FileModel) bool {
+	db := l.svcCtx.DB
+	if IsPublicFile(db, fileModel.ID) || IsUploader(db, fileModel.ID, req.UserID) {
+		return true
+	}
+
+	switch req.Source {
+	case "chat":
+		var chat chat_models.ChatModel
+		err := db.Take(&chat, "id = ? and system_msg is null", req.MsgID).Error
+		if err != nil {
+			return false
+		}
+		if chat.SendUserID != req.UserID && chat.RevUserID != req.UserID {
+			return false
+		}
+		return msgHasFile(chat.Msg, fileModel) && userCanView(db, chat.SendUserID, fileModel, maxForwardDepth)
+	case "group":
+		var groupMsg group_models.GroupMsgModel
+		err := db.Take(&groupMsg, "id = ? and system_msg is null", req.MsgID).Error
+		if err != nil {
+			return false
+		}
+		var member group_models.GroupMemberModel
+		err = db.Take(&member, "group_id = ? and user_id = ?", groupMsg.GroupID, req.UserID).Error
+		if err != nil {
+			return false
+		}
+		return msgHasFile(groupMsg.Msg, fileModel) && userCanView(db, groupMsg.SendUserID, fileModel, maxForwardDepth)
+	}
+	return false
+}
+
+// userCanView 发送人能不能看这个文件 自己上传的 或者是别人发给他的 转发的要一层层往前找
+// 一层一个人一起查 按消息文件表的索引找这一层的人收到过谁发的这个文件 查过的人不再查
+func userCanView(db *gorm.DB, userID uint, fileModel file_models.FileModel, depth int) bool {
+	if IsPublicFile(db, fileModel.ID) {
+		return true
+	}
+	checked := map[uint]bool{userID: true}
+	userIDList := []uint{userID}
+	for {
+		if hasUploader(db, fileModel.ID, userIDList) {
+			return true
+		}
+		if depth == 0 {
+			return false
+		}
+		depth--
+
+		var chatSenderList, groupSenderList []uint
+		db.Model(&chat_models.MsgFileModel{}).Distinct("send_user_id").
+			Where("hash = ? and rev_user_id in ?", fileModel.Hash, userIDList).
+			Pluck("send_user_id", &chatSenderList)
+		db.Model(&chat_models.MsgFileModel{}).Distinct("send_user_id").
+			Where("hash = ? and group_id in (?)", fileModel.Hash,
+				db.Model(&group_models.GroupMemberModel{}).Select("group_id").Where("user_id in ?", userIDList)).
+			Pluck("send_user_id", &groupSenderList)
+
+		userIDList = nil
+		for _, id := range append(chatSenderList, groupSenderList...) {
+			if !checked[id] {
+				checked[id] = true
+				userIDList = append(userIDList, id)
+			}
+		}
+		if len(userIDList) == 0 {
+			return false
+		}
+	}
+}
+
+// hasUploader 这些人里面有没有真的上传过这个文件的 秒传的不算
+func hasUploader(db *gorm.DB, fileID uint, userIDList []uint) bool {
+	var count int64
+	db.Model(&file_models.FileRefModel{}).
+		Where("file_id = ? and user_id in ? and quick = ?", fileID, userIDList, false).
+		Count(&count)
+	return count > 0
+}
+
+// msgHasFile 消息的文件字段里面有没有这个文件 地址前面可能带了域名和网关的前缀
+func msgHasFile(msg ctype.Msg, fileModel file_models.FileModel) bool {
+	for _, src := range msg.FileSrcList() {
+		u, err := url.Parse(src)
+		if err == nil && strings.HasSuffix(u.Path, fileModel.Url()) {
+			return true
+		}
+	}
+	return false
+}
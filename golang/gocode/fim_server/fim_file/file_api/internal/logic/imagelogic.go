@@ -1,7 +1,17 @@
 package logic
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fim_server/fim_file/file_models"
+	"fim_server/utils"
+	"fim_server/utils/imgproc"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"strings"
 
 	"fim_server/fim_file/file_api/internal/svc"
 	"fim_server/fim_file/file_api/internal/types"
@@ -23,7 +33,73 @@ func NewImageLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ImageLogic
 	}
 }
 
-func (l *ImageLogic) Image(req *types.ImageRequest) (resp *types.ImageResponse, err error) {
-	resp = new(types.ImageResponse)
+func (l *ImageLogic) Image(req *types.ImageRequest, file multipart.File, fileHead *multipart.FileHeader) (resp *types.ImageResponse, err error) {
+	switch req.ImageType {
+	case "avatar", "group_avatar", "chat":
+	default:
+		return nil, errors.New("imageType只能为 avatar,group_avatar,chat")
+	}
+
+	// 文件大小限制
+	maxSize := maxFileSize(l.svcCtx, "image", l.svcCtx.Config.FileSize)
+	if fileHead.Size > maxSize {
+		return nil, fmt.Errorf("图片大小超过限制，最大只能上传%.1fMB大小的图片", float64(maxSize)/1024/1024)
+	}
+
+	// 文件后缀白名单
+	suffix := strings.TrimPrefix(strings.ToLower(path.Ext(fileHead.Filename)), ".")
+	if !utils.InList(l.svcCtx.Config.WhiteList, suffix) {
+		return nil, errors.New("图片非法")
+	}
+
+	// 图片限制了大小 可以整个读到内存里面处理
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, errors.New("图片读取失败")
+	}
+	// 后缀可以随便改 解码的时候会看文件内容是不是图片
+	// 重新编码之后exif里面的定位这些信息就没了
+	img, err := imgproc.Process(data, imgproc.Options{
+		MaxPixels:   l.svcCtx.Config.Image.MaxPixels,
+		JpegQuality: l.svcCtx.Config.Image.JpegQuality,
+	})
+	if err != nil {
+		if errors.Is(err, imgproc.ErrTooBig) {
+			return nil, err
+		}
+		return nil, errors.New("图片非法")
+	}
+
+	// 按内容hash保存 一样的图片只存一份 不会再有重名的问题
+	fileModel, err := saveFile(l.ctx, l.svcCtx, req.UserID, req.ImageType, path.Base(fileHead.Filename), bytes.NewReader(img.Data), int64(len(img.Data)), img.Mime, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	l.saveThumbnail(img, fileModel.Key(), l.svcCtx.Config.Image.Thumbnail[req.ImageType])
+
+	resp = &types.ImageResponse{
+		Url: fileModel.Url(),
+	}
 	return
 }
+
+// saveThumbnail 生成缩略图 已经有了就不再生成 失败了不影响上传 访问的时候会回退到原图
+func (l *ImageLogic) saveThumbnail(img *imgproc.Image, key string, sizeList []int) {
+	for _, size := range sizeList {
+		variantKey := file_models.VariantKey(key, size)
+		if _, err := l.svcCtx.Storage.Stat(l.ctx, variantKey); err == nil {
+			continue
+		}
+		thumb, mime, err := img.Thumbnail(size)
+		if err != nil {
+			if err != imgproc.ErrNoThumb {
+				l.Errorf("缩略图生成失败 %s %s", variantKey, err)
+			}
+			continue
+		}
+		err = l.svcCtx.Storage.Put(l.ctx, variantKey, bytes.NewReader(thumb), int64(len(thumb)), mime)
+		if err != nil {
+			l.Errorf("缩略图保存失败 %s %s", variantKey, err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/fim_file/file_models"
+	"time"
+
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type PrivateLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewPrivateLogic(ctx context.Context, svcCtx *svc.ServiceContext) *PrivateLogic {
+	return &PrivateLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// Private 校验签名 返回对应的文件
+func (l *PrivateLogic) Private(req *types.PrivateRequest) (fileModel file_models.FileModel, err error) {
+	if req.Expire < time.Now().Unix() {
+		return fileModel, errors.New("下载地址已过期")
+	}
+	if !CheckFileSign(l.svcCtx.Config.Sign.Secret, req.FileID, req.Viewer, req.Expire, req.Sign) {
+		return fileModel, errors.New("签名错误")
+	}
+	err = l.svcCtx.DB.Take(&fileModel, req.FileID).Error
+	if err != nil {
+		return fileModel, errors.New("文件不存在")
+	}
+	return fileModel, nil
+}
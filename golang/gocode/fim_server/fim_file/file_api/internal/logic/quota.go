@@ -0,0 +1,78 @@
+package logic
+
+import (
+	"errors"
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_models"
+	"fim_server/fim_user/user_models"
+	"fmt"
+)
+
+// usageCategory 用量按这几类统计 视频和语音算在文件里面
+func usageCategory(fileType string) string {
+	switch fileType {
+	case "avatar", "group_avatar", "chat":
+		return fileType
+	}
+	return "file"
+}
+
+// userQuota 管理员单独设置过的优先 没有就按角色 都没有就用默认的 0是不限制
+func userQuota(svcCtx *svc.ServiceContext, userID uint) int64 {
+	var quotaModel file_models.FileQuotaModel
+	err := svcCtx.DB.Take(&quotaModel, "user_id = ?", userID).Error
+	if err == nil {
+		return quotaModel.Quota
+	}
+	var user user_models.UserModel
+	err = svcCtx.DB.Select("role").Take(&user, userID).Error
+	if err == nil {
+		quota, ok := svcCtx.Config.Quota.Role[fmt.Sprintf("%d", user.Role)]
+		if ok {
+			return quota
+		}
+	}
+	return svcCtx.Config.Quota.Default
+}
+
+// userUsage 用户每一类文件用了多少
+func userUsage(svcCtx *svc.ServiceContext, userID uint) (map[string]int64, error) {
+	type Item struct {
+		FileType string
+		Size     int64
+	}
+	var list []Item
+	err := svcCtx.DB.Model(&file_models.FileRefModel{}).
+		Where("user_id = ?", userID).
+		Group("file_type").
+		Select("file_type, sum(size) as size").
+		Scan(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	usage := map[string]int64{}
+	for _, item := range list {
+		usage[usageCategory(item.FileType)] += item.Size
+	}
+	return usage, nil
+}
+
+// checkQuota 再传size字节会不会超过配额
+func checkQuota(svcCtx *svc.ServiceContext, userID uint, size int64) error {
+	quota := userQuota(svcCtx, userID)
+	if quota <= 0 {
+		return nil
+	}
+	usage, err := userUsage(svcCtx, userID)
+	if err != nil {
+		return errors.New("存储用量查询失败")
+	}
+	var used int64
+	for _, s := range usage {
+		used += s
+	}
+	if used+size > quota {
+		return fmt.Errorf("存储空间不足，已用%.1fMB，总共%.1fMB", float64(used)/1024/1024, float64(quota)/1024/1024)
+	}
+	return nil
+}
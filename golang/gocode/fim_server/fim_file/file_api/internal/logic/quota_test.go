@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectUsage 每种文件用了多少
+func expectUsage(mock sqlmock.Sqlmock, userID uint, usage map[string]int64) {
+	rows := sqlmock.NewRows([]string{"file_type", "size"})
+	for fileType, size := range usage {
+		rows.AddRow(fileType, size)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT file_type, sum(size) as size FROM `file_ref_models` WHERE user_id = ? GROUP BY `file_type`")).
+		WithArgs(userID).WillReturnRows(rows)
+}
+
+// TestCheckQuota 单独设置的配额优先 然后是角色的 加上这次的大小超了就拒绝
+func TestCheckQuota(t *testing.T) {
+	cases := []struct {
+		name  string
+		quota int64 // 单独设置的 0就是没设置 用角色的1000
+		size  int64
+		err   string
+	}{
+		{"角色的配额够", 0, 700, ""},
+		{"角色的配额不够", 0, 701, "存储空间不足，已用0.0MB，总共0.0MB"},
+		{"单独设置的优先", 2000, 1500, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			svcCtx, mock := testSvc(t)
+			svcCtx.Config.Quota.Default = 100
+			svcCtx.Config.Quota.Role = map[string]int64{"2": 1000}
+			if c.quota != 0 {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM `file_quota_models` WHERE user_id = ?")).WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "quota"}).AddRow(2, c.quota))
+			} else {
+				expectNoQuota(mock, 2)
+			}
+			expectUsage(mock, 2, map[string]int64{"chat": 200, "video": 100})
+
+			err := checkQuota(svcCtx, 2, c.size)
+			if c.err == "" && err != nil || c.err != "" && (err == nil || err.Error() != c.err) {
+				t.Errorf("配额判断不对 %v", err)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
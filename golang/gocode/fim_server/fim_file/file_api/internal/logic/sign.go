@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fim_server/fim_file/file_models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// publicFileTypeList 头像是公开的 不用签名就能看
+var publicFileTypeList = []string{"avatar", "group_avatar"}
+
+// IsPublicFile 文件有没有被当作头像上传过 秒传的不算
+func IsPublicFile(db *gorm.DB, fileID uint) bool {
+	var count int64
+	db.Model(&file_models.FileRefModel{}).
+		Where("file_id = ? and file_type in ? and quick = ?", fileID, publicFileTypeList, false).
+		Count(&count)
+	return count > 0
+}
+
+// IsUploader 自己真的上传过这个文件 秒传的不算
+func IsUploader(db *gorm.DB, fileID, userID uint) bool {
+	var count int64
+	db.Model(&file_models.FileRefModel{}).
+		Where("file_id = ? and user_id = ? and quick = ?", fileID, userID, false).
+		Count(&count)
+	return count > 0
+}
+
+// IsPublicType 以前按类型目录保存的文件 avatar group_avatar目录是公开的
+func IsPublicType(fileType string) bool {
+	for _, t := range publicFileTypeList {
+		if t == fileType {
+			return true
+		}
+	}
+	return false
+}
+
+// FileSign 签名的内容是 文件id:查看的人:过期时间
+func FileSign(secret string, fileID, viewer uint, expire int64) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(h, "%d:%d:%d", fileID, viewer, expire)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CheckFileSign 比较的时候用常量时间 防止按时间猜签名
+func CheckFileSign(secret string, fileID, viewer uint, expire int64, sign string) bool {
+	return hmac.Equal([]byte(FileSign(secret, fileID, viewer, expire)), []byte(sign))
+}
@@ -0,0 +1,185 @@
+package logic
+
+import (
+	"context"
+	"database/sql/driver"
+	"fim_server/common/models/ctype"
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+	"fim_server/fim_file/file_models"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func testDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+func TestFileSign(t *testing.T) {
+	expire := time.Now().Add(time.Minute).Unix()
+	sign := FileSign("secret", 1, 2, expire)
+	if !CheckFileSign("secret", 1, 2, expire, sign) {
+		t.Fatal("签名校验失败")
+	}
+	cases := []struct {
+		name   string
+		secret string
+		fileID uint
+		viewer uint
+		expire int64
+		sign   string
+	}{
+		{"换了密钥", "other", 1, 2, expire, sign},
+		{"换了文件", "secret", 3, 2, expire, sign},
+		{"换了查看的人", "secret", 1, 3, expire, sign},
+		{"改了过期时间", "secret", 1, 2, expire + 3600, sign},
+		{"大写", "secret", 1, 2, expire, strings.ToUpper(sign)},
+		{"空签名", "secret", 1, 2, expire, ""},
+	}
+	for _, c := range cases {
+		if CheckFileSign(c.secret, c.fileID, c.viewer, c.expire, c.sign) {
+			t.Errorf("%s 不应该通过", c.name)
+		}
+	}
+}
+
+// TestPrivateExpire 过期和签名错误在查库之前就拒绝
+func TestPrivateExpire(t *testing.T) {
+	svcCtx := &svc.ServiceContext{}
+	svcCtx.Config.Sign.Secret = "secret"
+	l := NewPrivateLogic(context.Background(), svcCtx)
+
+	expire := time.Now().Add(-time.Second).Unix()
+	_, err := l.Private(&types.PrivateRequest{FileID: 1, Viewer: 2, Expire: expire, Sign: FileSign("secret", 1, 2, expire)})
+	if err == nil || err.Error() != "下载地址已过期" {
+		t.Errorf("过期的地址应该拒绝 %v", err)
+	}
+
+	expire = time.Now().Add(time.Minute).Unix()
+	_, err = l.Private(&types.PrivateRequest{FileID: 1, Viewer: 3, Expire: expire, Sign: FileSign("secret", 1, 2, expire)})
+	if err == nil || err.Error() != "签名错误" {
+		t.Errorf("别人的签名应该拒绝 %v", err)
+	}
+}
+
+func TestMsgHasFile(t *testing.T) {
+	fileModel := file_models.FileModel{Hash: "ab" + strings.Repeat("0", 62)}
+	src := fileModel.Url()
+	cases := []struct {
+		name string
+		msg  ctype.Msg
+		ok   bool
+	}{
+		{"图片", ctype.Msg{Type: ctype.ImageMsgType, ImageMsg: &ctype.ImageMsg{Src: src}}, true},
+		{"带网关前缀", ctype.Msg{Type: ctype.FileMsgType, FileMsg: &ctype.FileMsg{Src: "https://im.example.com/api/file" + src + "?w=128"}}, true},
+		{"图文", ctype.Msg{Type: ctype.ImageTextMsgType, ImageTextMsg: &ctype.ImageTextMsg{Content: `<p>看<img src="` + src + `"/></p>`}}, true},
+		{"合并转发", ctype.Msg{Type: ctype.MergeForwardMsgType, MergeForwardMsg: &ctype.MergeForwardMsg{List: []ctype.MergeForwardItem{
+			{Msg: ctype.Msg{Type: ctype.VoiceMsgType, VoiceMsg: &ctype.VoiceMsg{Src: src}}},
+		}}}, true},
+		{"文本里面写地址", ctype.Msg{Type: ctype.TextMsgType, TextMsg: &ctype.TextMsg{Content: src}}, false},
+		{"图文的文字里面写地址", ctype.Msg{Type: ctype.ImageTextMsgType, ImageTextMsg: &ctype.ImageTextMsg{Content: `<p>` + src + `</p>`}}, false},
+		{"类型和字段对不上", ctype.Msg{Type: ctype.TextMsgType, TextMsg: &ctype.TextMsg{Content: "a"}, ImageMsg: &ctype.ImageMsg{Src: src}}, false},
+		{"别的文件", ctype.Msg{Type: ctype.ImageMsgType, ImageMsg: &ctype.ImageMsg{Src: src + "1"}}, false},
+	}
+	for _, c := range cases {
+		if msgHasFile(c.msg, fileModel) != c.ok {
+			t.Errorf("%s 期望%v", c.name, c.ok)
+		}
+	}
+}
+
+func expectCount(mock sqlmock.Sqlmock, sql string, count int, args ...driver.Value) {
+	mock.ExpectQuery(regexp.QuoteMeta(sql)).WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func expectSender(mock sqlmock.Sqlmock, sql string, senderList []uint, args ...driver.Value) {
+	rows := sqlmock.NewRows([]string{"send_user_id"})
+	for _, id := range senderList {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(sql)).WithArgs(args...).WillReturnRows(rows)
+}
+
+// TestUserCanView 按消息文件表一层层往前找 查过的人不再查
+func TestUserCanView(t *testing.T) {
+	fileModel := file_models.FileModel{Hash: strings.Repeat("a", 64)}
+	fileModel.ID = 9
+	const (
+		publicSql   = "SELECT count(*) FROM `file_ref_models` WHERE file_id = ? and file_type in (?,?) and quick = ?"
+		uploaderSql = "SELECT count(*) FROM `file_ref_models` WHERE file_id = ? and user_id in"
+		chatSql     = "SELECT DISTINCT `send_user_id` FROM `msg_file_models` WHERE hash = ? and rev_user_id in"
+		groupSql    = "SELECT DISTINCT `send_user_id` FROM `msg_file_models` WHERE hash = ? and group_id in (SELECT `group_id` FROM `group_member_models` WHERE user_id in"
+	)
+
+	t.Run("转发了两层", func(t *testing.T) {
+		db, mock := testDB(t)
+		expectCount(mock, publicSql, 0, 9, "avatar", "group_avatar", false)
+		expectCount(mock, uploaderSql, 0, 9, 1, false)
+		expectSender(mock, chatSql, []uint{2}, fileModel.Hash, 1)
+		// 自己在群里发的不用再查
+		expectSender(mock, groupSql, []uint{1, 3}, fileModel.Hash, 1)
+		expectCount(mock, uploaderSql, 0, 9, 2, 3, false)
+		expectSender(mock, chatSql, []uint{1}, fileModel.Hash, 2, 3)
+		expectSender(mock, groupSql, []uint{4, 2}, fileModel.Hash, 2, 3)
+		expectCount(mock, uploaderSql, 1, 9, 4, false)
+
+		if !userCanView(db, 1, fileModel, maxForwardDepth) {
+			t.Error("4上传的 转给3 3在群里发了 1应该能看")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("只有转来转去的", func(t *testing.T) {
+		db, mock := testDB(t)
+		expectCount(mock, publicSql, 0, 9, "avatar", "group_avatar", false)
+		expectCount(mock, uploaderSql, 0, 9, 1, false)
+		expectSender(mock, chatSql, []uint{2}, fileModel.Hash, 1)
+		expectSender(mock, groupSql, nil, fileModel.Hash, 1)
+		expectCount(mock, uploaderSql, 0, 9, 2, false)
+		expectSender(mock, chatSql, []uint{1}, fileModel.Hash, 2)
+		expectSender(mock, groupSql, nil, fileModel.Hash, 2)
+
+		if userCanView(db, 1, fileModel, maxForwardDepth) {
+			t.Error("没有人上传过 不能看")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("超过层数", func(t *testing.T) {
+		db, mock := testDB(t)
+		expectCount(mock, publicSql, 0, 9, "avatar", "group_avatar", false)
+		expectCount(mock, uploaderSql, 0, 9, 1, false)
+		expectSender(mock, chatSql, []uint{2}, fileModel.Hash, 1)
+		expectSender(mock, groupSql, nil, fileModel.Hash, 1)
+		expectCount(mock, uploaderSql, 0, 9, 2, false)
+
+		if userCanView(db, 1, fileModel, 1) {
+			t.Error("超过层数不能再往前找")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"context"
+	"errors"
+
+	"fim_server/fim_file/file_api/internal/svc"
+	"fim_server/fim_file/file_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type UsageLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUsageLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UsageLogic {
+	return &UsageLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UsageLogic) Usage(req *types.UsageRequest) (resp *types.UsageResponse, err error) {
+	usage, err := userUsage(l.svcCtx, req.UserID)
+	if err != nil {
+		l.Error(err)
+		return nil, errors.New("存储用量查询失败")
+	}
+	resp = &types.UsageResponse{
+		Quota:       userQuota(l.svcCtx, req.UserID),
+		Avatar:      usage["avatar"],
+		GroupAvatar: usage["group_avatar"],
+		Chat:        usage["chat"],
+		File:        usage["file"],
+	}
+	resp.Used = resp.Avatar + resp.GroupAvatar + resp.Chat + resp.File
+	return
+}
@@ -1,15 +1,38 @@
 package svc
 
 import (
+	"fim_server/common/settings"
+	"fim_server/common/storage"
+	"fim_server/core"
+	"fim_server/fim_file/file_api/internal/chunk"
 	"fim_server/fim_file/file_api/internal/config"
+	"time"
+
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
 )
 
 type ServiceContext struct {
-	Config config.Config
+	Config     config.Config
+	DB         *gorm.DB
+	Redis      *redis.Client // 分片合并的锁
+	Storage    storage.Storage
+	ChunkStore *chunk.Store
+	Settings   *settings.Watcher // 系统设置里面的文件大小限制优先
 }
 
 func NewServiceContext(c config.Config) *ServiceContext {
+	mysqlDb := core.InitGorm(c.Mysql.DataSource)
+	store, err := storage.New(c.Storage)
+	if err != nil {
+		panic(err)
+	}
 	return &ServiceContext{
-		Config: c,
+		Config:     c,
+		DB:         mysqlDb,
+		Redis:      core.InitRedis(c.Redis.Addr, c.Redis.Pwd, c.Redis.DB),
+		Storage:    store,
+		ChunkStore: chunk.NewStore(store, c.Chunk.Dir, c.Chunk.Size, time.Duration(c.Chunk.Expire)*time.Hour),
+		Settings:   settings.NewWatcher(c.Etcd),
 	}
 }
@@ -3,8 +3,92 @@
 
 package types
 
+type ChunkCompleteRequest struct {
+	UserID   uint   `header:"User-ID"`
+	UploadID string `path:"uploadID"`
+}
+
+type ChunkInfoRequest struct {
+	UserID   uint   `header:"User-ID"`
+	UploadID string `path:"uploadID"`
+}
+
+type ChunkInfoResponse struct {
+	UploadID   string `json:"uploadID"`
+	FileName   string `json:"fileName"`
+	Size       int64  `json:"size"`
+	ChunkSize  int64  `json:"chunkSize"`  // 每一片的大小 最后一片可能不满
+	ChunkCount int    `json:"chunkCount"` // 一共多少片 序号从0开始
+	Received   []int  `json:"received"`   // 已经收到的分片序号
+}
+
+type ChunkInitRequest struct {
+	UserID   uint   `header:"User-ID"`
+	FileType string `json:"fileType"` // file video voice
+	FileName string `json:"fileName"`
+	Size     int64  `json:"size"` // 文件总大小
+	Hash     string `json:"hash"` // 整个文件的sha256
+}
+
+type ChunkUploadRequest struct {
+	UserID   uint   `header:"User-ID"`
+	UploadID string `path:"uploadID"`
+	Index    int    `path:"index"`
+}
+
+type ChunkUploadResponse struct {
+}
+
+type FileCheckRequest struct {
+	UserID   uint   `header:"User-ID"`
+	FileType string `json:"fileType"` // file video voice
+	FileName string `json:"fileName"`
+	Size     int64  `json:"size"`
+	Hash     string `json:"hash"` // 文件内容的sha256
+}
+
+type FileCheckResponse struct {
+	Exist bool          `json:"exist"` // 服务端已经有这个文件了 不用再传
+	File  *FileResponse `json:"file"`
+}
+
+type FileDeleteRequest struct {
+	UserID uint   `header:"User-ID"`
+	Hash   string `path:"hash"`
+}
+
+type FileDeleteResponse struct {
+}
+
+type FileRequest struct {
+	UserID   uint   `header:"User-ID"`
+	FileType string `form:"fileType"` // file video voice
+}
+
+type FileResponse struct {
+	Url      string `json:"url"`
+	Title    string `json:"title"`    // 原始文件名
+	Size     int64  `json:"size"`     // 文件大小 字节
+	Type     string `json:"type"`     // 根据文件内容识别出来的mime
+	Time     int    `json:"time"`     // 音视频的时长 单位秒
+	FileType string `json:"fileType"` // file video voice
+}
+
+type FileSignRequest struct {
+	UserID uint   `header:"User-ID"`
+	Hash   string `json:"hash"`            // 文件的hash 就是url的最后一段
+	Source string `json:"source,optional"` // 从哪里看到的这个文件 chat 私聊 group 群聊 自己上传的可以不传
+	MsgID  uint   `json:"msgID,optional"`  // 对应的消息id
+}
+
+type FileSignResponse struct {
+	Url    string `json:"url"`    // 带签名的下载地址
+	Expire int64  `json:"expire"` // 过期时间 时间戳
+}
+
 type ImageRequest struct {
-	UserID uint `header:"User-ID"`
+	UserID    uint   `header:"User-ID"`
+	ImageType string `form:"imageType"` // avatar group_avatar chat
 }
 
 type ImageResponse struct {
@@ -14,7 +98,29 @@ type ImageResponse struct {
 type ImageShowRequest struct {
 	ImageType string `path:"imageType"`
 	ImageName string `path:"imageName"`
+	W         int    `form:"w,optional"` // 缩略图的尺寸 没有这个尺寸的缩略图就返回原图
 }
 
 type ImageShowResponse struct {
 }
+
+type PrivateRequest struct {
+	FileID uint   `path:"fileID"`
+	Viewer uint   `form:"viewer"`
+	Expire int64  `form:"expire"`
+	Sign   string `form:"sign"`
+	W      int    `form:"w,optional"`
+}
+
+type UsageRequest struct {
+	UserID uint `header:"User-ID"`
+}
+
+type UsageResponse struct {
+	Quota       int64 `json:"quota"`       // 配额 单位 字节 0是不限制
+	Used        int64 `json:"used"`        // 一共用了多少
+	Avatar      int64 `json:"avatar"`      // 头像
+	GroupAvatar int64 `json:"groupAvatar"` // 群头像
+	Chat        int64 `json:"chat"`        // 聊天图片
+	File        int64 `json:"file"`        // 文件 视频 语音
+}
@@ -0,0 +1,45 @@
+package file_models
+
+import (
+	"fim_server/common/models"
+	"fmt"
+)
+
+// FileModel 文件表 按内容的sha256去重 一样的文件只存一份
+type FileModel struct {
+	models.Model
+	Hash     string `gorm:"size:64;uniqueIndex" json:"hash"` // 文件内容的sha256
+	Size     int64  `json:"size"`                            // 文件大小 字节
+	Mime     string `gorm:"size:64" json:"mime"`             // 根据文件内容识别出来的类型
+	Duration int    `json:"duration"`                        // 音视频的时长 单位秒
+	UserID   uint   `json:"userID"`                          // 第一次上传的人
+	Name     string `gorm:"size:256" json:"name"`            // 第一次上传时的文件名
+	RefCount int    `json:"refCount"`                        // 引用计数 减到0才会删文件
+}
+
+// Key 文件在存储里面的路径 取hash的前两位做一级目录
+func (f FileModel) Key() string {
+	return f.Hash[:2] + "/" + f.Hash
+}
+
+// VariantKey 缩略图的key 跟在原图后面 ab/abcdef..._128
+func VariantKey(key string, size int) string {
+	return fmt.Sprintf("%s_%d", key, size)
+}
+
+// Url 返回给前端的地址 和之前的 /uploads/avatar/xxx.png 一样挂在uploads下面
+func (f FileModel) Url() string {
+	return "/uploads/" + f.Key()
+}
+
+// FileRefModel 文件引用表 每上传一次就是一次引用
+type FileRefModel struct {
+	models.Model
+	FileID    uint      `gorm:"index" json:"fileID"`
+	FileModel FileModel `gorm:"foreignKey:FileID" json:"-"`
+	UserID    uint      `gorm:"index" json:"userID"`     // 上传的人
+	FileType  string    `gorm:"size:16" json:"fileType"` // avatar group_avatar chat file video voice
+	Name      string    `gorm:"size:256" json:"name"`    // 这次上传的文件名
+	Size      int64     `json:"size"`                    // 算到这个用户头上的大小 去重了也照算
+	Quick     bool      `json:"quick"`                   // 秒传的引用 只凭hash和大小 不能证明手里有这个文件
+}
@@ -0,0 +1,10 @@
+package file_models
+
+import "fim_server/common/models"
+
+// FileQuotaModel 管理员单独给用户设置的存储配额 没有就按角色的默认配额
+type FileQuotaModel struct {
+	models.Model
+	UserID uint  `gorm:"uniqueIndex" json:"userID"`
+	Quota  int64 `json:"quota"` // 单位 字节 0是不限制
+}
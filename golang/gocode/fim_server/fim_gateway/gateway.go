@@ -1,15 +1,17 @@
 package main
 
 import (
-	"bytes"
 	"encoding/json"
 	"fim_server/common/etcd"
+	"fim_server/common/settings"
 	"flag"
 	"fmt"
 	"github.com/zeromicro/go-zero/core/conf"
 	"github.com/zeromicro/go-zero/core/logx"
 	"io"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"regexp"
 	"strings"
 )
@@ -29,6 +31,10 @@ func FilResponse(msg string, res http.ResponseWriter) {
 func auth(authAddr string, res http.ResponseWriter, req *http.Request) (ok bool) {
 	authReq, _ := http.NewRequest("POST", authAddr, nil)
 	authReq.Header = req.Header
+	if authReq.Header.Get("Token") == "" {
+		// 浏览器建websocket连接的时候带不了请求头 token放在查询参数里面
+		authReq.Header.Set("Token", req.URL.Query().Get("token"))
+	}
 	authReq.Header.Set("ValidPath", req.URL.Path)
 	authRes, err := http.DefaultClient.Do(authReq)
 	if err != nil {
@@ -66,26 +72,65 @@ func auth(authAddr string, res http.ResponseWriter, req *http.Request) (ok bool)
 	return true
 }
 
+// proxyClient 后端服务返回的重定向交给客户端自己跳
+var proxyClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// isWebsocket 是不是websocket握手请求
+func isWebsocket(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+}
+
+// proxyWebsocket websocket要把连接接管过去 标准库的反向代理支持协议升级
+func proxyWebsocket(addr string, res http.ResponseWriter, req *http.Request) {
+	req.Header.Del("ValidPath")
+	reverseProxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: addr})
+	reverseProxy.ErrorHandler = func(res http.ResponseWriter, req *http.Request, err error) {
+		logx.Error(err)
+		FilResponse("服务异常", res)
+	}
+	reverseProxy.ServeHTTP(res, req)
+}
+
 func proxy(proxyAddr string, res http.ResponseWriter, req *http.Request) {
-	byteData, _ := io.ReadAll(req.Body)
-	proxyReq, err := http.NewRequest(req.Method, proxyAddr, bytes.NewBuffer(byteData))
+	// 请求体直接往后转 大文件上传不能整个读到内存里
+	proxyReq, err := http.NewRequest(req.Method, proxyAddr, req.Body)
 	if err != nil {
 		logx.Error(err)
 		FilResponse("err", res)
 		return
 	}
 
+	proxyReq.ContentLength = req.ContentLength
 	proxyReq.Header = req.Header
 	proxyReq.Header.Del("ValidPath")
-	response, ProxyErr := http.DefaultClient.Do(proxyReq)
+	response, ProxyErr := proxyClient.Do(proxyReq)
 	if ProxyErr != nil {
 		logx.Error(ProxyErr)
 		FilResponse("服务异常", res)
 		return
 	}
+	defer response.Body.Close()
+	// 响应头和状态码原样返回 文件下载的重定向 Content-Type这些都要带上
+	for key, values := range response.Header {
+		res.Header()[key] = values
+	}
+	res.WriteHeader(response.StatusCode)
 	io.Copy(res, response.Body)
 }
 
+// registerClosed 系统设置关了注册 注册请求在网关就拦下来 改了设置不用重启网关
+func registerClosed(req *http.Request) bool {
+	if req.URL.Path != "/api/auth/register" {
+		return false
+	}
+	info := settingsWatcher.Load()
+	return info != nil && !info.RegisterOpen
+}
+
 func gateway(res http.ResponseWriter, req *http.Request) {
 	// 匹配请求前缀  /api/user/xx
 	regex, _ := regexp.Compile(`/api/(.*?)/`)
@@ -103,7 +148,17 @@ func gateway(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if registerClosed(req) {
+		FilResponse("暂未开放注册", res)
+		return
+	}
+
 	remoteAddr := strings.Split(req.RemoteAddr, ":")
+	// 用户id和角色只能由认证服务给 客户端自己带的要去掉 白名单里面的接口不会重新设置
+	req.Header.Del("User-ID")
+	req.Header.Del("Role")
+	// 网关是入口，客户端自己带的X-Forwarded-For不可信，直接覆盖
+	req.Header.Set("X-Forwarded-For", remoteAddr[0])
 	// 请求认证服务地址
 	authAddr := etcd.GetServiceAddr(config.Etcd, "auth_api")
 	authUrl := fmt.Sprintf("http://%s/api/auth/authentication", authAddr)
@@ -116,6 +171,10 @@ func gateway(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if isWebsocket(req) {
+		proxyWebsocket(addr, res, req)
+		return
+	}
 	proxy(proxyUrl, res, req)
 }
 
@@ -129,11 +188,14 @@ type Config struct {
 
 var config Config
 
+var settingsWatcher *settings.Watcher
+
 func main() {
 	flag.Parse()
 	conf.MustLoad(*configFile, &config)
 
 	logx.SetUp(config.Log)
+	settingsWatcher = settings.NewWatcher(config.Etcd)
 
 	// 回调函数
 	http.HandleFunc("/", gateway)
@@ -0,0 +1,44 @@
+package group_models
+
+import (
+	"fim_server/fim_chat/chat_models"
+
+	"gorm.io/gorm"
+)
+
+// 进群 退群 改群信息 不管是哪个服务改的 都在同一个事务里面加到成员的收件箱
+// 删成员要带上查出来的成员 tx.Delete(&member) 不然不知道是谁退群了
+
+// AfterCreate 进群 新成员自己的其他设备也要知道
+func (gm *GroupMemberModel) AfterCreate(tx *gorm.DB) error {
+	return groupChange(tx, chat_models.GroupChange{GroupID: gm.GroupID, UserID: gm.UserID, Action: chat_models.GroupJoinAction})
+}
+
+// AfterDelete 退群或者被踢 这时候已经不在成员里面了 单独给他加一条
+func (gm *GroupMemberModel) AfterDelete(tx *gorm.DB) error {
+	if gm.GroupID == 0 || gm.UserID == 0 {
+		return nil
+	}
+	return groupChange(tx, chat_models.GroupChange{GroupID: gm.GroupID, UserID: gm.UserID, Action: chat_models.GroupLeaveAction}, gm.UserID)
+}
+
+// AfterUpdate 改群信息
+func (group *GroupModel) AfterUpdate(tx *gorm.DB) error {
+	if group.ID == 0 {
+		return nil
+	}
+	return groupChange(tx, chat_models.GroupChange{GroupID: group.ID, Action: chat_models.GroupUpdateAction})
+}
+
+// groupChange 群的变化加到每个成员的收件箱 extra是已经不在群里但也要通知的人
+func groupChange(tx *gorm.DB, change chat_models.GroupChange, extra ...uint) error {
+	var userIDList []uint
+	err := tx.Model(&GroupMemberModel{}).Where("group_id = ?", change.GroupID).Pluck("user_id", &userIDList).Error
+	if err != nil {
+		return err
+	}
+	_, err = chat_models.AppendInboxList(tx, append(userIDList, extra...), chat_models.GroupEvent, func(uint) any {
+		return change
+	})
+	return err
+}
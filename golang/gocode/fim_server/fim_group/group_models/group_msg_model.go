@@ -3,6 +3,8 @@ package group_models
 import (
 	"fim_server/common/models"
 	"fim_server/common/models/ctype"
+
+	"gorm.io/gorm"
 )
 
 // GroupMsgModel 群消息表
@@ -17,21 +19,27 @@ type GroupMsgModel struct {
 	MsgPreview       string            `gorm:"size:64" json:"msgPreview"`         // 消息预览
 	Msg              ctype.Msg         `json:"msg"`                               // 消息内容
 	SystemMsg        *ctype.SystemMsg  `json:"systemMsg"`                         // 系统提示
+	// SearchContent 消息里面可以搜索的文本 有全文索引 发消息和撤回的时候自动同步
+	SearchContent string `gorm:"type:text" json:"-"`
+}
+
+// BeforeSave 消息内容变了就同步搜索内容 撤回之后就搜不到了
+func (chat *GroupMsgModel) BeforeSave(tx *gorm.DB) error {
+	msg, ok := ctype.UpdatedMsg(tx.Statement.Dest, chat.Msg)
+	if !ok {
+		return nil
+	}
+	if chat.SystemMsg != nil {
+		// 被系统拦截的消息不能搜
+		msg = ctype.Msg{}
+	}
+	tx.Statement.SetColumn("search_content", msg.SearchText())
+	return nil
 }
 
 func (chat GroupMsgModel) MsgPreviewMethod() string {
 	if chat.SystemMsg != nil {
-		switch chat.SystemMsg.Type {
-		case 1:
-			return "[系统消息]- 该消息涉黄，已被系统拦截"
-		case 2:
-			return "[系统消息]- 该消息涉恐，已被系统拦截"
-		case 3:
-			return "[系统消息]- 该消息涉政，已被系统拦截"
-		case 4:
-			return "[系统消息]- 该消息不正当言论，已被系统拦截"
-		}
-		return "[系统消息]"
+		return chat.SystemMsg.Preview()
 	}
 	return chat.Msg.MsgPreview()
 }
@@ -0,0 +1,11 @@
+package group_models
+
+import "fim_server/common/models"
+
+// GroupPinModel 群置顶消息 群主和管理员才能置顶
+type GroupPinModel struct {
+	models.Model
+	GroupID uint `gorm:"index" json:"groupID"`
+	MsgID   uint `gorm:"uniqueIndex" json:"msgID"`
+	UserID  uint `json:"userID"` // 谁置顶的
+}
@@ -0,0 +1,11 @@
+package group_models
+
+import "fim_server/common/models"
+
+// GroupPollVoteModel 群投票 一个选项一条 多选就是多条
+type GroupPollVoteModel struct {
+	models.Model
+	MsgID  uint `gorm:"uniqueIndex:idx_group_poll_vote" json:"msgID"` // 投票消息的id
+	UserID uint `gorm:"uniqueIndex:idx_group_poll_vote" json:"userID"`
+	Option int  `gorm:"uniqueIndex:idx_group_poll_vote" json:"option"` // 选项的下标
+}
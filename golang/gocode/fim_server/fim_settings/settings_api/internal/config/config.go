@@ -1,13 +1,21 @@
 package config
 
-import "github.com/zeromicro/go-zero/rest"
+import (
+	"fim_server/common/settings"
+
+	"github.com/zeromicro/go-zero/rest"
+)
 
 type Config struct {
 	rest.RestConf
-	OpenLoginList []struct {
-		Name string
-		Icon string
-		Href string
+	Mysql struct {
+		DataSource string
+	}
+	Redis struct {
+		Addr string
+		Pwd  string
+		DB   int
 	}
-	Etcd string
+	Default settings.Info // 数据库里面还没有设置的时候用这个初始化
+	Etcd    string
 }
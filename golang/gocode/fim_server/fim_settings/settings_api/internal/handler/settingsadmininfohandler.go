@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"fim_server/common/response"
+	"fim_server/fim_settings/settings_api/internal/logic"
+	"fim_server/fim_settings/settings_api/internal/svc"
+	"net/http"
+)
+
+func settingsAdminInfoHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		l := logic.NewSettingsAdminInfoLogic(r.Context(), svcCtx)
+		resp, err := l.SettingsAdminInfo()
+		response.Response(r, w, resp, err)
+
+	}
+}
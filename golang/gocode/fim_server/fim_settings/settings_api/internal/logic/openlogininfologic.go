@@ -24,12 +24,9 @@ func NewOpen_login_infoLogic(ctx context.Context, svcCtx *svc.ServiceContext) *O
 }
 
 func (l *Open_login_infoLogic) Open_login_info() (resp []types.OpenLoginInfoResponse, err error) {
-	for _, s := range l.svcCtx.Config.OpenLoginList {
-		resp = append(resp, types.OpenLoginInfoResponse{
-			Name: s.Name,
-			Href: s.Href,
-			Icon: s.Icon,
-		})
+	model, err := LoadSettings(l.svcCtx)
+	if err != nil {
+		return nil, err
 	}
-	return
+	return openLoginList(model.Info.OpenLoginList), nil
 }
@@ -0,0 +1,110 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fim_server/common/settings"
+	"fim_server/fim_settings/settings_api/internal/svc"
+	"fim_server/fim_settings/settings_api/internal/types"
+	"fim_server/fim_settings/settings_models"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const settingsCacheExpire = time.Hour
+
+// LoadSettings 先读redis缓存 没有再读数据库 数据库里面也没有就用配置文件里面的默认值初始化
+func LoadSettings(svcCtx *svc.ServiceContext) (*settings_models.SettingsModel, error) {
+	var model settings_models.SettingsModel
+	val, err := svcCtx.Redis.Get(settings.Key).Result()
+	if err == nil && json.Unmarshal([]byte(val), &model) == nil {
+		return &model, nil
+	}
+
+	err = svcCtx.DB.Take(&model, 1).Error
+	if err != nil {
+		model = settings_models.SettingsModel{Info: svcCtx.Config.Default}
+		model.ID = 1
+		err = svcCtx.DB.Create(&model).Error
+		if err != nil {
+			// 其他实例可能同时在初始化
+			err = svcCtx.DB.Take(&model, 1).Error
+		}
+		if err != nil {
+			logx.Error(err)
+			return nil, errors.New("系统设置读取失败")
+		}
+	}
+	cacheSettings(svcCtx, &model)
+	return &model, nil
+}
+
+// updateSettings 锁住这一行再改 两个管理员同时改不同的部分不会互相覆盖 改完刷新缓存再通知其他服务
+func updateSettings(svcCtx *svc.ServiceContext, apply func(info *settings.Info) error) (*settings_models.SettingsModel, error) {
+	_, err := LoadSettings(svcCtx)
+	if err != nil {
+		return nil, err
+	}
+	var model settings_models.SettingsModel
+	err = svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&model, 1).Error
+		if err != nil {
+			logx.Error(err)
+			return errors.New("系统设置读取失败")
+		}
+		err = apply(&model.Info)
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model).Update("info", model.Info).Error
+		if err != nil {
+			logx.Error(err)
+			return errors.New("系统设置保存失败")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	svcCtx.DB.Take(&model, 1)
+	cacheSettings(svcCtx, &model)
+
+	err = settings.Publish(svcCtx.Config.Etcd, model.Info)
+	if err != nil {
+		// 数据库已经改了 settings服务重启的时候会再发布一次
+		logx.Errorf("系统设置发布失败 %s", err.Error())
+		return nil, errors.New("系统设置已保存，但通知其他服务失败")
+	}
+	return &model, nil
+}
+
+func cacheSettings(svcCtx *svc.ServiceContext, model *settings_models.SettingsModel) {
+	byteData, _ := json.Marshal(model)
+	err := svcCtx.Redis.Set(settings.Key, string(byteData), settingsCacheExpire).Err()
+	if err != nil {
+		logx.Errorf("系统设置缓存失败 %s", err.Error())
+	}
+}
+
+func openLoginList(list []settings.OpenLogin) []types.OpenLoginInfoResponse {
+	resp := make([]types.OpenLoginInfoResponse, 0)
+	for _, s := range list {
+		resp = append(resp, types.OpenLoginInfoResponse{
+			Flag: s.Flag,
+			Name: s.Name,
+			Href: s.Href,
+			Icon: s.Icon,
+		})
+	}
+	return resp
+}
+
+func fileLimit(limit map[string]int64) map[string]int64 {
+	if limit == nil {
+		return map[string]int64{}
+	}
+	return limit
+}
@@ -0,0 +1,47 @@
+package logic
+
+import (
+	"context"
+
+	"fim_server/fim_settings/settings_api/internal/svc"
+	"fim_server/fim_settings/settings_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type SettingsAdminInfoLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewSettingsAdminInfoLogic(ctx context.Context, svcCtx *svc.ServiceContext) *SettingsAdminInfoLogic {
+	return &SettingsAdminInfoLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *SettingsAdminInfoLogic) SettingsAdminInfo() (resp *types.SettingsAdminInfoResponse, err error) {
+	model, err := LoadSettings(l.svcCtx)
+	if err != nil {
+		return nil, err
+	}
+	info := model.Info
+	return &types.SettingsAdminInfoResponse{
+		Site: types.SettingsSite{
+			Name:     info.Site.Name,
+			Logo:     info.Site.Logo,
+			Abstract: info.Site.Abstract,
+		},
+		RegisterOpen:  info.RegisterOpen,
+		FileLimit:     fileLimit(info.FileLimit),
+		OpenLoginList: openLoginList(info.OpenLoginList),
+		Moderation: types.SettingsModeration{
+			Chat:  info.Moderation.Chat,
+			Group: info.Moderation.Group,
+		},
+		UpdatedAt: model.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"context"
+
+	"fim_server/fim_settings/settings_api/internal/svc"
+	"fim_server/fim_settings/settings_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type SettingsInfoLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewSettingsInfoLogic(ctx context.Context, svcCtx *svc.ServiceContext) *SettingsInfoLogic {
+	return &SettingsInfoLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *SettingsInfoLogic) SettingsInfo() (resp *types.SettingsInfoResponse, err error) {
+	model, err := LoadSettings(l.svcCtx)
+	if err != nil {
+		return nil, err
+	}
+	info := model.Info
+	return &types.SettingsInfoResponse{
+		Site: types.SettingsSite{
+			Name:     info.Site.Name,
+			Logo:     info.Site.Logo,
+			Abstract: info.Site.Abstract,
+		},
+		RegisterOpen:  info.RegisterOpen,
+		FileLimit:     fileLimit(info.FileLimit),
+		OpenLoginList: openLoginList(info.OpenLoginList),
+	}, nil
+}
@@ -0,0 +1,130 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fim_server/common/settings"
+	"fmt"
+	"strings"
+
+	"fim_server/fim_settings/settings_api/internal/svc"
+	"fim_server/fim_settings/settings_api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type SettingsUpdateLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewSettingsUpdateLogic(ctx context.Context, svcCtx *svc.ServiceContext) *SettingsUpdateLogic {
+	return &SettingsUpdateLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// fileTypeList 可以单独设置大小上限的文件类型
+var fileTypeList = map[string]bool{"image": true, "file": true, "video": true, "voice": true}
+
+// openLoginFlagList 已经接入的第三方登录
+var openLoginFlagList = map[string]bool{"qq": true}
+
+func (l *SettingsUpdateLogic) SettingsUpdate(req *types.SettingsUpdateRequest) (resp *types.SettingsUpdateResponse, err error) {
+	err = checkSettingsUpdate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = updateSettings(l.svcCtx, func(info *settings.Info) error {
+		if req.Site != nil {
+			info.Site = settings.Site{
+				Name:     strings.TrimSpace(req.Site.Name),
+				Logo:     req.Site.Logo,
+				Abstract: req.Site.Abstract,
+			}
+		}
+		if req.RegisterOpen != nil {
+			info.RegisterOpen = *req.RegisterOpen
+		}
+		if len(req.FileLimit) > 0 && info.FileLimit == nil {
+			info.FileLimit = map[string]int64{}
+		}
+		for fileType, size := range req.FileLimit {
+			if size == 0 {
+				delete(info.FileLimit, fileType)
+				continue
+			}
+			info.FileLimit[fileType] = size
+		}
+		if req.OpenLoginList != nil {
+			info.OpenLoginList = make([]settings.OpenLogin, 0)
+			for _, item := range req.OpenLoginList {
+				info.OpenLoginList = append(info.OpenLoginList, settings.OpenLogin{
+					Flag: item.Flag,
+					Name: item.Name,
+					Icon: item.Icon,
+					Href: item.Href,
+				})
+			}
+		}
+		if req.Moderation != nil {
+			info.Moderation = settings.Moderation{
+				Chat:  req.Moderation.Chat,
+				Group: req.Moderation.Group,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.Infof("系统设置已修改")
+	return
+}
+
+func checkSettingsUpdate(req *types.SettingsUpdateRequest) error {
+	if req.Site != nil {
+		name := strings.TrimSpace(req.Site.Name)
+		if name == "" {
+			return errors.New("站点名称不能为空")
+		}
+		if len([]rune(name)) > 32 {
+			return errors.New("站点名称不能超过32个字符")
+		}
+		if len(req.Site.Logo) > 256 {
+			return errors.New("logo地址过长")
+		}
+		if len([]rune(req.Site.Abstract)) > 128 {
+			return errors.New("站点简介不能超过128个字符")
+		}
+	}
+	for fileType, size := range req.FileLimit {
+		if !fileTypeList[fileType] {
+			return fmt.Errorf("不支持的文件类型 %s", fileType)
+		}
+		if size < 0 {
+			return fmt.Errorf("%s 的大小上限不能小于0", fileType)
+		}
+	}
+	flagSet := map[string]bool{}
+	for _, item := range req.OpenLoginList {
+		if !openLoginFlagList[item.Flag] {
+			return fmt.Errorf("不支持的第三方登录 %s", item.Flag)
+		}
+		if flagSet[item.Flag] {
+			return fmt.Errorf("第三方登录 %s 重复", item.Flag)
+		}
+		flagSet[item.Flag] = true
+		if item.Name == "" {
+			return errors.New("第三方登录的名称不能为空")
+		}
+		if !strings.HasPrefix(item.Href, "https://") && !strings.HasPrefix(item.Href, "http://") {
+			return errors.New("第三方登录的跳转地址必须是http或https")
+		}
+	}
+	return nil
+}
@@ -1,15 +1,29 @@
 package svc
 
 import (
+	"fim_server/core"
 	"fim_server/fim_settings/settings_api/internal/config"
+	"fim_server/fim_settings/settings_api/internal/middleware"
+	"github.com/go-redis/redis"
+	"github.com/zeromicro/go-zero/rest"
+	"gorm.io/gorm"
 )
 
 type ServiceContext struct {
 	Config config.Config
+	DB     *gorm.DB
+	Redis  *redis.Client
+	Admin  rest.Middleware
 }
 
 func NewServiceContext(c config.Config) *ServiceContext {
+	mysqlDb := core.InitGorm(c.Mysql.DataSource)
+	redisClient := core.InitRedis(c.Redis.Addr, c.Redis.Pwd, c.Redis.DB)
+
 	return &ServiceContext{
 		Config: c,
+		DB:     mysqlDb,
+		Redis:  redisClient,
+		Admin:  middleware.NewAdminMiddleware().Handle,
 	}
 }
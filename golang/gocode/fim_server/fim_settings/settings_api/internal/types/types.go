@@ -4,7 +4,46 @@
 package types
 
 type OpenLoginInfoResponse struct {
+	Flag string `json:"flag"` // 登录方式 qq
 	Name string `json:"name"`
 	Icon string `json:"icon"`
 	Href string `json:"href"` // 跳转地址
 }
+
+type SettingsAdminInfoResponse struct {
+	Site          SettingsSite            `json:"site"`
+	RegisterOpen  bool                    `json:"registerOpen"`
+	FileLimit     map[string]int64        `json:"fileLimit"`
+	OpenLoginList []OpenLoginInfoResponse `json:"openLoginList"`
+	Moderation    SettingsModeration      `json:"moderation"`
+	UpdatedAt     string                  `json:"updatedAt"`
+}
+
+type SettingsInfoResponse struct {
+	Site          SettingsSite            `json:"site"`
+	RegisterOpen  bool                    `json:"registerOpen"`
+	FileLimit     map[string]int64        `json:"fileLimit"` // 每类文件的大小上限 单位 字节
+	OpenLoginList []OpenLoginInfoResponse `json:"openLoginList"`
+}
+
+type SettingsModeration struct {
+	Chat  bool `json:"chat"`  // 私聊消息是否审核
+	Group bool `json:"group"` // 群聊消息是否审核
+}
+
+type SettingsSite struct {
+	Name     string `json:"name"`
+	Logo     string `json:"logo"`
+	Abstract string `json:"abstract,optional"`
+}
+
+type SettingsUpdateRequest struct {
+	Site          *SettingsSite           `json:"site,optional"`
+	RegisterOpen  *bool                   `json:"registerOpen,optional"`
+	FileLimit     map[string]int64        `json:"fileLimit,optional"`     // 只改传了的类型 0是删掉 按服务自己的配置
+	OpenLoginList []OpenLoginInfoResponse `json:"openLoginList,optional"` // 传了就整个替换
+	Moderation    *SettingsModeration     `json:"moderation,optional"`
+}
+
+type SettingsUpdateResponse struct {
+}
@@ -2,14 +2,17 @@ package main
 
 import (
 	"fim_server/common/etcd"
+	"fim_server/common/settings"
 	"flag"
 	"fmt"
 
 	"fim_server/fim_settings/settings_api/internal/config"
 	"fim_server/fim_settings/settings_api/internal/handler"
+	"fim_server/fim_settings/settings_api/internal/logic"
 	"fim_server/fim_settings/settings_api/internal/svc"
 
 	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/rest"
 )
 
@@ -27,6 +30,15 @@ func main() {
 	ctx := svc.NewServiceContext(c)
 	handler.RegisterHandlers(server, ctx)
 
+	// 启动的时候把当前的设置发布一次 etcd的数据丢了或者上次发布失败都能补上
+	model, err := logic.LoadSettings(ctx)
+	if err == nil {
+		err = settings.Publish(c.Etcd, model.Info)
+	}
+	if err != nil {
+		logx.Errorf("系统设置发布失败 %s", err.Error())
+	}
+
 	etcd.DeliveryAddress(c.Etcd, c.Name+"_api", fmt.Sprintf("%s:%d", c.Host, c.Port))
 
 	fmt.Printf("Starting server at %s:%d...\n", c.Host, c.Port)
@@ -0,0 +1,12 @@
+package settings_models
+
+import (
+	"fim_server/common/models"
+	"fim_server/common/settings"
+)
+
+// SettingsModel 系统设置表 只有一行
+type SettingsModel struct {
+	models.Model
+	Info settings.Info `json:"info"`
+}
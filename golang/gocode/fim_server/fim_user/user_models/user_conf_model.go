@@ -3,6 +3,10 @@ package user_models
 import (
 	"fim_server/common/models"
 	"fim_server/common/models/ctype"
+	"fmt"
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+	"time"
 )
 
 // UserConfModel 用户配置表
@@ -23,4 +27,122 @@ type UserConfModel struct {
 	CurtailAddUser       bool                        `json:"curtailAddUser"`               // 限制加人
 	CurtailCreateGroup   bool                        `json:"curtailCreateGroup"`           // 限制建群
 	CurtailInGroupChat   bool                        `json:"curtailInGroupChat"`           // 限制加群
+	// 限制的到期时间 空的是永久限制 以这里为准 redis里面的key只是缓存
+	CurtailChatExpire        *time.Time `json:"curtailChatExpire"`
+	CurtailAddUserExpire     *time.Time `json:"curtailAddUserExpire"`
+	CurtailCreateGroupExpire *time.Time `json:"curtailCreateGroupExpire"`
+	CurtailInGroupChatExpire *time.Time `json:"curtailInGroupChatExpire"`
+}
+
+// 限制的类型 就是对应的列名
+const (
+	CurtailChatType        = "curtail_chat"
+	CurtailAddUserType     = "curtail_add_user"
+	CurtailCreateGroupType = "curtail_create_group"
+	CurtailInGroupChatType = "curtail_in_group_chat"
+)
+
+// CurtailKey 限制的redis key 有过期时间的限制会带上ttl
+func CurtailKey(curtailType string, userID uint) string {
+	return fmt.Sprintf("%s__%d", curtailType, userID)
+}
+
+// CurtailExpireColumn 限制到期时间的列名
+func CurtailExpireColumn(curtailType string) string {
+	return curtailType + "_expire"
+}
+
+// SetCurtailCache 把限制写到redis里面 到期时间为空就是永久
+func SetCurtailCache(client *redis.Client, curtailType string, userID uint, expire *time.Time) error {
+	var ttl time.Duration
+	if expire != nil {
+		ttl = time.Until(*expire)
+		if ttl <= 0 {
+			return nil
+		}
+	}
+	return client.Set(CurtailKey(curtailType, userID), "", ttl).Err()
+}
+
+func (uc UserConfModel) curtail(curtailType string) (bool, *time.Time) {
+	switch curtailType {
+	case CurtailChatType:
+		return uc.CurtailChat, uc.CurtailChatExpire
+	case CurtailAddUserType:
+		return uc.CurtailAddUser, uc.CurtailAddUserExpire
+	case CurtailCreateGroupType:
+		return uc.CurtailCreateGroup, uc.CurtailCreateGroupExpire
+	case CurtailInGroupChatType:
+		return uc.CurtailInGroupChat, uc.CurtailInGroupChatExpire
+	}
+	return false, nil
+}
+
+// IsCurtail 判断某一项限制是否生效 限制到期了就把这个值改回去
+// redis的key没了不代表到期了 可能是redis被清了 按库里的到期时间判断 还没到期就把缓存补回去
+func (uc UserConfModel) IsCurtail(client *redis.Client, db *gorm.DB, curtailType string) bool {
+	curtail, expire := uc.curtail(curtailType)
+	if !curtail {
+		return false
+	}
+	ttl, err := client.TTL(CurtailKey(curtailType, uc.UserID)).Result()
+	return uc.checkCurtail(client, db, curtailType, expire, ttl, err)
+}
+
+// checkCurtail 拿到redis里面的ttl之后判断限制是否生效
+func (uc UserConfModel) checkCurtail(client *redis.Client, db *gorm.DB, curtailType string, expire *time.Time, ttl time.Duration, err error) bool {
+	if err == nil && ttl != -2*time.Second {
+		if expire == nil && ttl > 0 {
+			// 加到期时间这一列之前的限制 到期时间只在redis里面 补到库里
+			db.Model(&uc).Update(CurtailExpireColumn(curtailType), time.Now().Add(ttl))
+		}
+		return true
+	}
+	if expire != nil && !expire.After(time.Now()) {
+		db.Model(&uc).Updates(map[string]any{curtailType: false, CurtailExpireColumn(curtailType): nil})
+		return false
+	}
+	if err == nil {
+		SetCurtailCache(client, curtailType, uc.UserID, expire)
+	}
+	return true
+}
+
+// CurtailTypeList 所有的限制类型
+var CurtailTypeList = []string{CurtailChatType, CurtailAddUserType, CurtailCreateGroupType, CurtailInGroupChatType}
+
+// CurtailMap 一批用户的限制 redis的ttl用pipeline一次查完 key是userID 没有限制的用户不在里面
+func CurtailMap(client *redis.Client, db *gorm.DB, list []UserConfModel) map[uint]map[string]bool {
+	type item struct {
+		uc          UserConfModel
+		curtailType string
+		expire      *time.Time
+		cmd         *redis.DurationCmd
+	}
+	var itemList []item
+	pipe := client.Pipeline()
+	defer pipe.Close()
+	for _, uc := range list {
+		for _, curtailType := range CurtailTypeList {
+			curtail, expire := uc.curtail(curtailType)
+			if !curtail {
+				continue
+			}
+			itemList = append(itemList, item{uc, curtailType, expire, pipe.TTL(CurtailKey(curtailType, uc.UserID))})
+		}
+	}
+	curtailMap := map[uint]map[string]bool{}
+	if len(itemList) == 0 {
+		return curtailMap
+	}
+	// 每条命令的错误在cmd里面 下面分开判断
+	pipe.Exec()
+	for _, it := range itemList {
+		ttl, err := it.cmd.Result()
+		if curtailMap[it.uc.UserID] == nil {
+			curtailMap[it.uc.UserID] = map[string]bool{}
+		}
+		curtailMap[it.uc.UserID][it.curtailType] = it.uc.checkCurtail(client, db, it.curtailType, it.expire, ttl, err)
+	}
+	return curtailMap
 }
@@ -14,5 +14,10 @@ type UserModel struct {
 	Role           int8           `json:"role"`                          // 角色 1 管理员  2 普通用户
 	OpenID         string         `gorm:"size:64" json:"-"`              // 第三方平台登录的凭证
 	RegisterSource string         `gorm:"size:16" json:"registerSource"` // 注册来源
+	Ban            bool           `json:"ban"`                           // 是否被封禁
+	BanReason      string         `gorm:"size:128" json:"banReason"`     // 封禁原因
 	UserConfModel  *UserConfModel `gorm:"foreignKey:UserID" json:"UserConfModel"`
 }
+
+// KickChannel 封号之后往这个频道发用户id 聊天服务收到就断开这个用户的websocket
+const KickChannel = "user_kick"
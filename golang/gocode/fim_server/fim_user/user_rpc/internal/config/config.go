@@ -7,7 +7,8 @@ type Config struct {
 	Mysql struct {
 		DataSource string
 	}
-	RedisConf struct {
+	BcryptCost int // 密码hash的cost
+	RedisConf  struct {
 		Addr string
 		Pwd  string
 		DB   int
@@ -39,7 +39,7 @@ func (l *UserCreateLogic) UserCreate(in *user_rpc.UserCreateRequest) (*user_rpc.
 		Role:     int8(in.Role),
 		//OpenID:   in.OpenId,
 		RegisterSource: in.RegisterSource,
-		Pwd:            pwd.HashPwd(in.Password),
+		Pwd:            pwd.HashPwdWithCost(in.Password, l.svcCtx.Config.BcryptCost),
 	}
 	err1 := l.svcCtx.DB.Create(&user).Error
 	if err1 != nil {
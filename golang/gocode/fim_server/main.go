@@ -1,42 +1,121 @@
 package main
 
 import (
+	"fim_server/common/migrate"
 	"fim_server/core"
-	"fim_server/fim_chat/chat_models"
-	"fim_server/fim_group/group_models"
-	"fim_server/fim_user/user_models"
+	_ "fim_server/migrations"
 	"flag"
 	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/conf"
 )
 
 type Options struct {
-	DB bool
+	DB     bool
+	Config string
+}
+
+type Config struct {
+	Mysql struct {
+		DataSource string
+	}
 }
 
+// dsnEnv 设置了这个环境变量就不用配置文件里面的数据库地址
+const dsnEnv = "FIM_MYSQL_DSN"
+
+const usage = `用法:
+  go run main.go migrate up          执行所有还没有执行的变更
+  go run main.go migrate down N      回滚最近执行的N个变更
+  go run main.go migrate status      查看变更的执行情况
+  go run main.go migrate create name 在migrations目录下生成一个空的变更文件`
+
 func main() {
 	var opt Options
-	flag.BoolVar(&opt.DB, "db", false, "db")
+	flag.BoolVar(&opt.DB, "db", false, "db 等同于 migrate up")
+	flag.StringVar(&opt.Config, "f", "migrations/migrate.yaml", "数据库配置文件")
 	flag.Parse()
 
+	args := flag.Args()
 	if opt.DB {
-		db := core.InitGorm("root:root@tcp(127.0.0.1:3306)/fim_server_db?charset=utf8mb4&parseTime=True&loc=Local")
-		err := db.AutoMigrate(
-			&user_models.UserModel{},         // 用户表
-			&user_models.FriendModel{},       // 好友表
-			&user_models.FriendVerifyModel{}, // 好友验证表
-			&user_models.UserConfModel{},     // 用户配置表
-			&chat_models.ChatModel{},         // 对话表
-			&group_models.GroupModel{},       // 群组表
-			&group_models.GroupMemberModel{}, // 群成员表
-			&group_models.GroupMsgModel{},    // 群消息表
-			&group_models.GroupVerifyModel{}, // 群验证表
-
-		)
-		if err != nil {
-			fmt.Println("表结构生成失败", err)
+		args = []string{"migrate", "up"}
+	}
+	if len(args) < 2 || args[0] != "migrate" {
+		fmt.Println(usage)
+		return
+	}
+
+	if args[1] == "create" {
+		if len(args) != 3 {
+			fmt.Println(usage)
 			return
 		}
-		fmt.Println("表结构生成成功！")
+		path, err := migrate.Create("migrations", "migrations", args[2], time.Now())
+		if err != nil {
+			fmt.Println("变更文件生成失败", err)
+			os.Exit(1)
+		}
+		fmt.Println("变更文件生成成功", path)
+		return
+	}
 
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		var c Config
+		conf.MustLoad(opt.Config, &c)
+		dsn = c.Mysql.DataSource
+	}
+	db := core.InitGorm(dsn)
+
+	switch args[1] {
+	case "up":
+		list, err := migrate.Up(db)
+		for _, m := range list {
+			fmt.Printf("执行成功 %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("表结构更新完成 本次执行%d个变更\n", len(list))
+	case "down":
+		n := 1
+		if len(args) > 2 {
+			var err error
+			n, err = strconv.Atoi(args[2])
+			if err != nil {
+				fmt.Println(usage)
+				return
+			}
+		}
+		list, err := migrate.Down(db, n)
+		for _, m := range list {
+			fmt.Printf("回滚成功 %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	case "status":
+		list, err := migrate.GetStatus(db)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		for _, item := range list {
+			status := "未执行"
+			if item.Applied {
+				status = "已执行 " + item.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if item.Missing {
+				status += " 代码里面没有这个变更"
+			}
+			fmt.Printf("%d_%-32s %s\n", item.Version, item.Name, status)
+		}
+	default:
+		fmt.Println(usage)
 	}
 }
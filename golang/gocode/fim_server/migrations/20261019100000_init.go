@@ -0,0 +1,267 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+
+	"gorm.io/gorm"
+)
+
+// initTableList 之前main.go -db 建的表 已经建过表的库执行一遍也没有影响
+// 有外键 被引用的表要先建
+var initTableList = []struct {
+	name string
+	sql  string
+}{
+	{"user_models", `CREATE TABLE IF NOT EXISTS user_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			pwd varchar(64),
+			nickname varchar(32),
+			abstract varchar(128),
+			avatar varchar(256),
+			ip varchar(32),
+			addr varchar(64),
+			role tinyint,
+			open_id varchar(64),
+			register_source varchar(16),
+			PRIMARY KEY (id)
+		)`}, // 用户表
+	{"friend_models", `CREATE TABLE IF NOT EXISTS friend_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			send_user_id bigint unsigned,
+			rev_user_id bigint unsigned,
+			sen_user_notice varchar(128),
+			rev_user_notice varchar(128),
+			PRIMARY KEY (id),
+			CONSTRAINT fk_friend_models_rev_user_model FOREIGN KEY (rev_user_id) REFERENCES user_models(id),
+			CONSTRAINT fk_friend_models_send_user_model FOREIGN KEY (send_user_id) REFERENCES user_models(id)
+		)`}, // 好友表
+	{"friend_verify_models", `CREATE TABLE IF NOT EXISTS friend_verify_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			send_user_id bigint unsigned,
+			rev_user_id bigint unsigned,
+			status tinyint,
+			send_status tinyint,
+			rev_status tinyint,
+			additional_messages varchar(128),
+			verification_question longtext,
+			PRIMARY KEY (id),
+			CONSTRAINT fk_friend_verify_models_send_user_model FOREIGN KEY (send_user_id) REFERENCES user_models(id),
+			CONSTRAINT fk_friend_verify_models_rev_user_model FOREIGN KEY (rev_user_id) REFERENCES user_models(id)
+		)`}, // 好友验证表
+	{"user_conf_models", `CREATE TABLE IF NOT EXISTS user_conf_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			user_id bigint unsigned,
+			recall_message varchar(32),
+			friend_online boolean,
+			sound boolean,
+			secure_link boolean,
+			save_pwd boolean,
+			search_user tinyint,
+			verification tinyint,
+			verification_question longtext,
+			online boolean,
+			curtail_chat boolean,
+			curtail_add_user boolean,
+			curtail_create_group boolean,
+			curtail_in_group_chat boolean,
+			PRIMARY KEY (id),
+			CONSTRAINT fk_user_models_user_conf_model FOREIGN KEY (user_id) REFERENCES user_models(id)
+		)`}, // 用户配置表
+	{"chat_models", `CREATE TABLE IF NOT EXISTS chat_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			send_user_id bigint unsigned,
+			rev_user_id bigint unsigned,
+			msg_type tinyint,
+			msg_preview varchar(64),
+			msg longtext,
+			system_msg longtext,
+			PRIMARY KEY (id)
+		)`}, // 对话表
+	{"group_models", `CREATE TABLE IF NOT EXISTS group_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			title longtext,
+			abstract longtext,
+			avatar longtext,
+			creator bigint unsigned,
+			is_search boolean,
+			verification tinyint,
+			verification_question longtext,
+			is_invite boolean,
+			is_temporary_session boolean,
+			is_prohibition boolean,
+			size bigint,
+			PRIMARY KEY (id)
+		)`}, // 群组表
+	{"group_member_models", `CREATE TABLE IF NOT EXISTS group_member_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			group_id bigint unsigned,
+			user_id bigint unsigned,
+			member_nickname varchar(32),
+			role tinyint,
+			prohibition_time bigint,
+			PRIMARY KEY (id),
+			CONSTRAINT fk_group_models_member_list FOREIGN KEY (group_id) REFERENCES group_models(id)
+		)`}, // 群成员表
+	{"group_msg_models", `CREATE TABLE IF NOT EXISTS group_msg_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			group_id bigint unsigned,
+			send_user_id bigint unsigned,
+			group_member_id bigint unsigned,
+			msg_type tinyint,
+			msg_preview varchar(64),
+			msg longtext,
+			system_msg longtext,
+			PRIMARY KEY (id),
+			CONSTRAINT fk_group_member_models_msg_list FOREIGN KEY (group_member_id) REFERENCES group_member_models(id),
+			CONSTRAINT fk_group_models_group_msg_list FOREIGN KEY (group_id) REFERENCES group_models(id)
+		)`}, // 群消息表
+	{"group_verify_models", `CREATE TABLE IF NOT EXISTS group_verify_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			group_id bigint unsigned,
+			user_id bigint unsigned,
+			status tinyint,
+			additional_messages varchar(32),
+			verification_question longtext,
+			type tinyint,
+			PRIMARY KEY (id),
+			CONSTRAINT fk_group_verify_models_group_model FOREIGN KEY (group_id) REFERENCES group_models(id)
+		)`}, // 群验证表
+	{"user_totp_models", `CREATE TABLE IF NOT EXISTS user_totp_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			user_id bigint unsigned,
+			secret varchar(64),
+			enable boolean,
+			PRIMARY KEY (id),
+			UNIQUE INDEX idx_user_totp_models_user_id (user_id)
+		)`}, // 两步验证表
+	{"user_recovery_code_models", `CREATE TABLE IF NOT EXISTS user_recovery_code_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			user_id bigint unsigned,
+			code_hash varchar(64),
+			used boolean,
+			PRIMARY KEY (id),
+			INDEX idx_user_recovery_code_models_user_id (user_id)
+		)`}, // 两步验证恢复码表
+	{"login_log_models", `CREATE TABLE IF NOT EXISTS login_log_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			user_id bigint unsigned,
+			ip varchar(32),
+			source varchar(16),
+			status boolean,
+			msg varchar(64),
+			PRIMARY KEY (id),
+			INDEX idx_login_log_models_user_id (user_id)
+		)`}, // 登录记录表
+	{"moderation_log_models", `CREATE TABLE IF NOT EXISTS moderation_log_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			source varchar(16),
+			send_user_id bigint unsigned,
+			target_id bigint unsigned,
+			msg_type tinyint,
+			content text,
+			words varchar(256),
+			type tinyint,
+			action tinyint,
+			status tinyint,
+			remark varchar(128),
+			PRIMARY KEY (id),
+			INDEX idx_moderation_log_models_send_user_id (send_user_id)
+		)`}, // 消息审核记录表
+	{"file_models", `CREATE TABLE IF NOT EXISTS file_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			hash varchar(64),
+			size bigint,
+			mime varchar(64),
+			duration bigint,
+			user_id bigint unsigned,
+			name varchar(256),
+			ref_count bigint,
+			PRIMARY KEY (id),
+			UNIQUE INDEX idx_file_models_hash (hash)
+		)`}, // 文件表
+	{"file_ref_models", `CREATE TABLE IF NOT EXISTS file_ref_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			file_id bigint unsigned,
+			user_id bigint unsigned,
+			file_type varchar(16),
+			name varchar(256),
+			size bigint,
+			PRIMARY KEY (id),
+			INDEX idx_file_ref_models_file_id (file_id),
+			INDEX idx_file_ref_models_user_id (user_id),
+			CONSTRAINT fk_file_ref_models_file_model FOREIGN KEY (file_id) REFERENCES file_models(id)
+		)`}, // 文件引用表
+	{"file_quota_models", `CREATE TABLE IF NOT EXISTS file_quota_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			user_id bigint unsigned,
+			quota bigint,
+			PRIMARY KEY (id),
+			UNIQUE INDEX idx_file_quota_models_user_id (user_id)
+		)`}, // 文件配额表
+	{"settings_models", `CREATE TABLE IF NOT EXISTS settings_models (
+			id bigint unsigned AUTO_INCREMENT,
+			created_at longtext,
+			updated_at longtext,
+			info longtext,
+			PRIMARY KEY (id)
+		)`}, // 系统设置表
+}
+
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019100000,
+		Name:    "init",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			for _, table := range initTableList {
+				err := tx.Exec(table.sql).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// 有外键 倒着删
+			for i := len(initTableList) - 1; i >= 0; i-- {
+				err := tx.Exec("DROP TABLE IF EXISTS " + initTableList[i].name).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
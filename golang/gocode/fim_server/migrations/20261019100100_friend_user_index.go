@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 查两个人是不是好友都是按 send_user_id rev_user_id 一起查的
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019100100,
+		Name:    "friend_user_index",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			return createIndex(tx, "friend_models", "idx_friend_models_users",
+				"CREATE INDEX idx_friend_models_users ON friend_models (send_user_id, rev_user_id)")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropIndex(tx, "friend_models", "idx_friend_models_users")
+		},
+	})
+}
@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+	"fim_server/common/models/ctype"
+
+	"gorm.io/gorm"
+)
+
+// searchMsg 回填的时候只用到这几列 不走模型的钩子
+type searchMsg struct {
+	ID        uint
+	Msg       ctype.Msg
+	SystemMsg *ctype.SystemMsg
+}
+
+// 消息搜索 search_content加ngram全文索引 中文不用分词
+// 已经有的消息把搜索内容补上 ddl会隐式提交 回填单独放在事务里面
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019100200,
+		Name:    "msg_search",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			for _, table := range []string{"chat_models", "group_msg_models"} {
+				err := addColumn(tx, table, "search_content", "ALTER TABLE "+table+" ADD COLUMN search_content text")
+				if err != nil {
+					return err
+				}
+				err = createIndex(tx, table, "idx_search_content",
+					"CREATE FULLTEXT INDEX idx_search_content ON "+table+" (search_content) WITH PARSER ngram")
+				if err != nil {
+					return err
+				}
+			}
+
+			return tx.Transaction(func(tx *gorm.DB) error {
+				for _, table := range []string{"chat_models", "group_msg_models"} {
+					var list []searchMsg
+					err := tx.Table(table).Select("id", "msg", "system_msg").FindInBatches(&list, 500, func(_ *gorm.DB, _ int) error {
+						for _, msg := range list {
+							content := ""
+							if msg.SystemMsg == nil {
+								content = msg.Msg.SearchText()
+							}
+							err := tx.Table(table).Where("id = ?", msg.ID).UpdateColumn("search_content", content).Error
+							if err != nil {
+								return err
+							}
+						}
+						return nil
+					}).Error
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, table := range []string{"chat_models", "group_msg_models"} {
+				err := dropIndex(tx, table, "idx_search_content")
+				if err != nil {
+					return err
+				}
+				err = dropColumn(tx, table, "search_content")
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 私聊的送达和已读位置 查对方发给自己的未读消息要用rev_send索引
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019100300,
+		Name:    "chat_ack",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			err := execList(tx, `CREATE TABLE IF NOT EXISTS chat_ack_models (
+				id bigint unsigned AUTO_INCREMENT,
+				created_at longtext,
+				updated_at longtext,
+				user_id bigint unsigned,
+				target_id bigint unsigned,
+				delivered_msg_id bigint unsigned,
+				read_msg_id bigint unsigned,
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_chat_ack_user_target (user_id, target_id)
+			)`)
+			if err != nil {
+				return err
+			}
+			return createIndex(tx, "chat_models", "idx_chat_models_rev_send",
+				"CREATE INDEX idx_chat_models_rev_send ON chat_models (rev_user_id, send_user_id, id)")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := dropIndex(tx, "chat_models", "idx_chat_models_rev_send")
+			if err != nil {
+				return err
+			}
+			return execList(tx, "DROP TABLE IF EXISTS chat_ack_models")
+		},
+	})
+}
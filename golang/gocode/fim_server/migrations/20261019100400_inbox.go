@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 用户的收件箱和当前的seq 按创建时间清理过期的事件
+// created_at是字符串 建索引要带前缀长度 时间格式固定19个字符
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019100400,
+		Name:    "inbox",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			err := execList(tx, `CREATE TABLE IF NOT EXISTS inbox_models (
+				id bigint unsigned AUTO_INCREMENT,
+				created_at longtext,
+				updated_at longtext,
+				user_id bigint unsigned,
+				seq bigint unsigned,
+				type varchar(16),
+				data text,
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_inbox_user_seq (user_id, seq)
+			)`, `CREATE TABLE IF NOT EXISTS inbox_seq_models (
+				user_id bigint unsigned,
+				seq bigint unsigned,
+				PRIMARY KEY (user_id)
+			)`)
+			if err != nil {
+				return err
+			}
+			return createIndex(tx, "inbox_models", "idx_inbox_models_created_at",
+				"CREATE INDEX idx_inbox_models_created_at ON inbox_models (created_at(19))")
+		},
+		Down: func(tx *gorm.DB) error {
+			return execList(tx, "DROP TABLE IF EXISTS inbox_seq_models", "DROP TABLE IF EXISTS inbox_models")
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 端到端加密 设备的身份公钥 签名预密钥和一次性预密钥
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019100500,
+		Name:    "chat_key",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			return execList(tx, `CREATE TABLE IF NOT EXISTS device_key_models (
+				id bigint unsigned AUTO_INCREMENT,
+				created_at longtext,
+				updated_at longtext,
+				user_id bigint unsigned,
+				device_id varchar(64),
+				identity_key varchar(128),
+				signed_pre_key_id int unsigned,
+				signed_pre_key varchar(128),
+				signed_pre_key_sig varchar(256),
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_device_key_user_device (user_id, device_id)
+			)`, `CREATE TABLE IF NOT EXISTS one_time_pre_key_models (
+				id bigint unsigned AUTO_INCREMENT,
+				user_id bigint unsigned,
+				device_id varchar(64),
+				key_id int unsigned,
+				public_key varchar(128),
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_one_time_pre_key (user_id, device_id, key_id)
+			)`)
+		},
+		Down: func(tx *gorm.DB) error {
+			return execList(tx, "DROP TABLE IF EXISTS one_time_pre_key_models", "DROP TABLE IF EXISTS device_key_models")
+		},
+	})
+}
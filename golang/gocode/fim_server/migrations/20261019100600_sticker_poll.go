@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 表情目录和群投票
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019100600,
+		Name:    "sticker_poll",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			return execList(tx, `CREATE TABLE IF NOT EXISTS sticker_pack_models (
+				id bigint unsigned AUTO_INCREMENT,
+				created_at longtext,
+				updated_at longtext,
+				title varchar(32),
+				cover varchar(256),
+				sort bigint,
+				PRIMARY KEY (id)
+			)`, `CREATE TABLE IF NOT EXISTS sticker_models (
+				id bigint unsigned AUTO_INCREMENT,
+				created_at longtext,
+				updated_at longtext,
+				pack_id bigint unsigned,
+				title varchar(32),
+				src varchar(256),
+				sort bigint,
+				PRIMARY KEY (id),
+				INDEX idx_sticker_models_pack_id (pack_id),
+				CONSTRAINT fk_sticker_pack_models_sticker_list FOREIGN KEY (pack_id) REFERENCES sticker_pack_models(id)
+			)`, `CREATE TABLE IF NOT EXISTS group_poll_vote_models (
+				id bigint unsigned AUTO_INCREMENT,
+				created_at longtext,
+				updated_at longtext,
+				msg_id bigint unsigned,
+				user_id bigint unsigned,
+				`+"`option`"+` bigint,
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_group_poll_vote (msg_id, user_id, `+"`option`"+`)
+			)`)
+		},
+		Down: func(tx *gorm.DB) error {
+			return execList(tx, "DROP TABLE IF EXISTS group_poll_vote_models", "DROP TABLE IF EXISTS sticker_models", "DROP TABLE IF EXISTS sticker_pack_models")
+		},
+	})
+}
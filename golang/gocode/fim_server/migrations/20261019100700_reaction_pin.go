@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 消息的表情回应和群置顶消息
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019100700,
+		Name:    "reaction_pin",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			return execList(tx, `CREATE TABLE IF NOT EXISTS chat_reaction_models (
+				id bigint unsigned AUTO_INCREMENT,
+				created_at longtext,
+				updated_at longtext,
+				msg_id bigint unsigned,
+				user_id bigint unsigned,
+				emoji varchar(32),
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_chat_reaction (msg_id, user_id, emoji)
+			)`, `CREATE TABLE IF NOT EXISTS group_reaction_models (
+				id bigint unsigned AUTO_INCREMENT,
+				created_at longtext,
+				updated_at longtext,
+				msg_id bigint unsigned,
+				user_id bigint unsigned,
+				emoji varchar(32),
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_group_reaction (msg_id, user_id, emoji)
+			)`, `CREATE TABLE IF NOT EXISTS group_pin_models (
+				id bigint unsigned AUTO_INCREMENT,
+				created_at longtext,
+				updated_at longtext,
+				group_id bigint unsigned,
+				msg_id bigint unsigned,
+				user_id bigint unsigned,
+				PRIMARY KEY (id),
+				INDEX idx_group_pin_models_group_id (group_id),
+				UNIQUE INDEX idx_group_pin_models_msg_id (msg_id)
+			)`)
+		},
+		Down: func(tx *gorm.DB) error {
+			return execList(tx, "DROP TABLE IF EXISTS group_pin_models", "DROP TABLE IF EXISTS group_reaction_models", "DROP TABLE IF EXISTS chat_reaction_models")
+		},
+	})
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+
+	"gorm.io/gorm"
+)
+
+// curtailExpireColumnList 用户限制的到期时间 之前只在redis里面
+var curtailExpireColumnList = []string{
+	"curtail_chat_expire",
+	"curtail_add_user_expire",
+	"curtail_create_group_expire",
+	"curtail_in_group_chat_expire",
+}
+
+// 用户限制的到期时间存到库里 redis只做缓存
+// 已经限制了的用户 判断限制的时候按redis里面剩下的时间补到库里
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019100800,
+		Name:    "curtail_expire",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			for _, column := range curtailExpireColumnList {
+				err := addColumn(tx, "user_conf_models", column, "ALTER TABLE user_conf_models ADD COLUMN "+column+" datetime(3) NULL")
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range curtailExpireColumnList {
+				err := dropColumn(tx, "user_conf_models", column)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 文件引用区分秒传 秒传只凭hash和大小 不能当作看文件的凭证
+// 之前的引用分不出来 都按正常上传算
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019100900,
+		Name:    "file_ref_quick",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			return addColumn(tx, "file_ref_models", "quick", "ALTER TABLE file_ref_models ADD COLUMN quick boolean NOT NULL DEFAULT false")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumn(tx, "file_ref_models", "quick")
+		},
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 用户封禁 之前AutoMigrate建的库没有这两列
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019101000,
+		Name:    "user_ban",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			err := addColumn(tx, "user_models", "ban", "ALTER TABLE user_models ADD COLUMN ban boolean NOT NULL DEFAULT false")
+			if err != nil {
+				return err
+			}
+			return addColumn(tx, "user_models", "ban_reason", "ALTER TABLE user_models ADD COLUMN ban_reason varchar(128)")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := dropColumn(tx, "user_models", "ban_reason")
+			if err != nil {
+				return err
+			}
+			return dropColumn(tx, "user_models", "ban")
+		},
+	})
+}
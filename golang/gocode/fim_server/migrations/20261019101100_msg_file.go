@@ -0,0 +1,105 @@
+package migrations
+
+import (
+	"fim_server/common/migrate"
+	"fim_server/common/models/ctype"
+
+	"gorm.io/gorm"
+)
+
+// fileMsg 回填的时候只用到这几列 私聊的group_id和群聊的rev_user_id查出来是0
+type fileMsg struct {
+	ID         uint
+	SendUserID uint
+	RevUserID  uint
+	GroupID    uint
+	Msg        ctype.Msg
+	CreatedAt  string
+}
+
+// msgFileRow 回填写入的行
+type msgFileRow struct {
+	CreatedAt  string
+	UpdatedAt  string
+	Hash       string
+	Source     string
+	MsgID      uint
+	SendUserID uint
+	RevUserID  uint
+	GroupID    uint
+}
+
+// 消息里面引用的文件 看文件的时候按文件hash和接收人 群去找 不用再like扫消息内容
+// 已经有的消息补进去 被系统拦截的和撤回的不算
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: 20261019101100,
+		Name:    "msg_file",
+		NoTx:    true,
+		Up: func(tx *gorm.DB) error {
+			err := execList(tx, `CREATE TABLE IF NOT EXISTS msg_file_models (
+				id bigint unsigned AUTO_INCREMENT,
+				created_at longtext,
+				updated_at longtext,
+				hash varchar(64),
+				source varchar(8),
+				msg_id bigint unsigned,
+				send_user_id bigint unsigned,
+				rev_user_id bigint unsigned,
+				group_id bigint unsigned,
+				PRIMARY KEY (id),
+				INDEX idx_msg_file_rev (hash, rev_user_id),
+				INDEX idx_msg_file_group (hash, group_id),
+				INDEX idx_msg_file_msg (source, msg_id)
+			)`)
+			if err != nil {
+				return err
+			}
+
+			return tx.Transaction(func(tx *gorm.DB) error {
+				err := tx.Exec("DELETE FROM msg_file_models").Error
+				if err != nil {
+					return err
+				}
+				for _, source := range []struct {
+					name   string
+					table  string
+					column []string
+				}{
+					{"chat", "chat_models", []string{"id", "send_user_id", "rev_user_id", "msg", "created_at"}},
+					{"group", "group_msg_models", []string{"id", "send_user_id", "group_id", "msg", "created_at"}},
+				} {
+					var list []fileMsg
+					err = tx.Table(source.table).Select(source.column).Where("system_msg is null").FindInBatches(&list, 500, func(_ *gorm.DB, _ int) error {
+						var rowList []msgFileRow
+						for _, msg := range list {
+							for _, hash := range msg.Msg.FileHashList() {
+								rowList = append(rowList, msgFileRow{
+									CreatedAt:  msg.CreatedAt,
+									UpdatedAt:  msg.CreatedAt,
+									Hash:       hash,
+									Source:     source.name,
+									MsgID:      msg.ID,
+									SendUserID: msg.SendUserID,
+									RevUserID:  msg.RevUserID,
+									GroupID:    msg.GroupID,
+								})
+							}
+						}
+						if len(rowList) == 0 {
+							return nil
+						}
+						return tx.Table("msg_file_models").Create(&rowList).Error
+					}).Error
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		},
+		Down: func(tx *gorm.DB) error {
+			return execList(tx, "DROP TABLE IF EXISTS msg_file_models")
+		},
+	})
+}
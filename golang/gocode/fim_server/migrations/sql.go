@@ -0,0 +1,49 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// 每个版本的表结构都用sql写死 不用模型自动建表
+// 模型以后改了 之前的版本在新库上执行出来的表结构也和当时一样
+
+// execList 按顺序执行
+func execList(tx *gorm.DB, sqlList ...string) error {
+	for _, sql := range sqlList {
+		err := tx.Exec(sql).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createIndex 索引不存在才建 重复执行没有影响
+func createIndex(tx *gorm.DB, table, name, sql string) error {
+	if tx.Migrator().HasIndex(table, name) {
+		return nil
+	}
+	return tx.Exec(sql).Error
+}
+
+// dropIndex 索引存在才删
+func dropIndex(tx *gorm.DB, table, name string) error {
+	if !tx.Migrator().HasIndex(table, name) {
+		return nil
+	}
+	return tx.Exec("DROP INDEX " + name + " ON " + table).Error
+}
+
+// addColumn 列不存在才加
+func addColumn(tx *gorm.DB, table, column, sql string) error {
+	if tx.Migrator().HasColumn(table, column) {
+		return nil
+	}
+	return tx.Exec(sql).Error
+}
+
+// dropColumn 列存在才删
+func dropColumn(tx *gorm.DB, table, column string) error {
+	if !tx.Migrator().HasColumn(table, column) {
+		return nil
+	}
+	return tx.Exec("ALTER TABLE " + table + " DROP COLUMN " + column).Error
+}
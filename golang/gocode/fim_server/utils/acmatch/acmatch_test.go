@@ -0,0 +1,35 @@
+package acmatch
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	m := New([]string{"he", "she", "his", "hers", "敏感词"})
+	list := m.Find([]rune("uSHErs 有一个敏感词"))
+	var words []string
+	for _, match := range list {
+		words = append(words, m.Word(match.Index))
+	}
+	fmt.Println(list, words)
+	expect := []string{"she", "he", "hers", "敏感词"}
+	if len(words) != len(expect) {
+		t.Fatalf("期望 %v, 结果 %v", expect, words)
+	}
+	for i := range expect {
+		if words[i] != expect[i] {
+			t.Errorf("期望 %v, 结果 %v", expect, words)
+		}
+	}
+	if list[3].Start != 10 || list[3].End != 13 {
+		t.Errorf("位置错误 %v", list[3])
+	}
+}
+
+func TestFindEmpty(t *testing.T) {
+	m := New(nil)
+	if len(m.Find([]rune("abc"))) != 0 {
+		t.Error("空词表不应该有命中")
+	}
+}
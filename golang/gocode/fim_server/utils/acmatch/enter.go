@@ -0,0 +1,104 @@
+package acmatch
+
+import "unicode"
+
+// Match 一次命中 Start和End是rune下标 左闭右开
+type Match struct {
+	Start int
+	End   int
+	Index int // 命中的是第几个词
+}
+
+type node struct {
+	next  map[rune]int
+	fail  int
+	words []int // 以这个节点结尾的词 包括fail链上的
+}
+
+// Matcher Aho-Corasick多模式匹配 构建之后只读，可以并发使用
+type Matcher struct {
+	nodes []node
+	words [][]rune
+}
+
+// New 用词表构建自动机 大小写不敏感
+func New(words []string) *Matcher {
+	m := &Matcher{nodes: []node{{next: map[rune]int{}}}}
+	for i, word := range words {
+		runes := []rune(word)
+		for j, r := range runes {
+			runes[j] = unicode.ToLower(r)
+		}
+		m.words = append(m.words, runes)
+		if len(runes) == 0 {
+			continue
+		}
+		cur := 0
+		for _, r := range runes {
+			nxt, ok := m.nodes[cur].next[r]
+			if !ok {
+				m.nodes = append(m.nodes, node{next: map[rune]int{}})
+				nxt = len(m.nodes) - 1
+				m.nodes[cur].next[r] = nxt
+			}
+			cur = nxt
+		}
+		m.nodes[cur].words = append(m.nodes[cur].words, i)
+	}
+	m.build()
+	return m
+}
+
+// build 广度优先计算fail指针
+func (m *Matcher) build() {
+	var queue []int
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			f := m.nodes[cur].fail
+			for f != 0 {
+				if _, ok := m.nodes[f].next[r]; ok {
+					break
+				}
+				f = m.nodes[f].fail
+			}
+			if nxt, ok := m.nodes[f].next[r]; ok && nxt != child {
+				m.nodes[child].fail = nxt
+			}
+			m.nodes[child].words = append(m.nodes[child].words, m.nodes[m.nodes[child].fail].words...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// Find 找出文本里面所有命中的词
+func (m *Matcher) Find(text []rune) (list []Match) {
+	cur := 0
+	for i, r := range text {
+		r = unicode.ToLower(r)
+		for cur != 0 {
+			if _, ok := m.nodes[cur].next[r]; ok {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		cur = m.nodes[cur].next[r] // 没有就回到根节点0
+		for _, index := range m.nodes[cur].words {
+			list = append(list, Match{
+				Start: i + 1 - len(m.words[index]),
+				End:   i + 1,
+				Index: index,
+			})
+		}
+	}
+	return
+}
+
+// Word 第index个词
+func (m *Matcher) Word(index int) string {
+	return string(m.words[index])
+}
@@ -0,0 +1,142 @@
+package captcha
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"image"
+	"image/color"
+	"image/png"
+	"math/big"
+	"math/rand"
+)
+
+const (
+	Width  = 120
+	Height = 40
+	scale  = 3 // 字模放大的倍数
+)
+
+var digits = []byte("0123456789")
+
+// font 5x7的数字字模 每一行用低5位表示
+var font = map[byte][7]uint8{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+}
+
+// RandCode 生成n位数字验证码 用crypto/rand 画图的干扰用math/rand就够了
+func RandCode(n int) string {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(digits)))
+	for i := range b {
+		index, err := crand.Int(crand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b[i] = digits[index.Int64()]
+	}
+	return string(b)
+}
+
+// Draw 把验证码画成png图片
+func Draw(code string) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	bg := color.RGBA{R: uint8(230 + rand.Intn(25)), G: uint8(230 + rand.Intn(25)), B: uint8(230 + rand.Intn(25)), A: 255}
+	for x := 0; x < Width; x++ {
+		for y := 0; y < Height; y++ {
+			img.Set(x, y, bg)
+		}
+	}
+
+	// 干扰点
+	for i := 0; i < Width*Height/20; i++ {
+		img.Set(rand.Intn(Width), rand.Intn(Height), randColor(120))
+	}
+
+	// 字符
+	step := Width / (len(code) + 1)
+	for i := 0; i < len(code); i++ {
+		glyph, ok := font[code[i]]
+		if !ok {
+			continue
+		}
+		x0 := step*(i+1) - 5*scale/2 + rand.Intn(5) - 2
+		y0 := (Height-7*scale)/2 + rand.Intn(7) - 3
+		drawGlyph(img, glyph, x0, y0, randColor(100))
+	}
+
+	// 干扰线
+	for i := 0; i < 3; i++ {
+		drawLine(img, 0, rand.Intn(Height), Width-1, rand.Intn(Height), randColor(150))
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+func drawGlyph(img *image.RGBA, glyph [7]uint8, x0, y0 int, c color.Color) {
+	// 每一行做一点水平偏移，让字符有点倾斜
+	slant := rand.Intn(3) - 1
+	for row := 0; row < 7; row++ {
+		for col := 0; col < 5; col++ {
+			if glyph[row]&(1<<uint(4-col)) == 0 {
+				continue
+			}
+			for dx := 0; dx < scale; dx++ {
+				for dy := 0; dy < scale; dy++ {
+					x := x0 + col*scale + dx + slant*(3-row)
+					y := y0 + row*scale + dy
+					img.Set(x, y, c)
+				}
+			}
+		}
+	}
+}
+
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func randColor(max int) color.RGBA {
+	return color.RGBA{R: uint8(rand.Intn(max)), G: uint8(rand.Intn(max)), B: uint8(rand.Intn(max)), A: 255}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,24 @@
+package captcha
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"testing"
+)
+
+func TestDraw(t *testing.T) {
+	code := RandCode(4)
+	fmt.Println(code)
+	byteData, err := Draw(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(byteData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != Width || img.Bounds().Dy() != Height {
+		t.Errorf("图片尺寸错误 %v", img.Bounds())
+	}
+}
@@ -0,0 +1,97 @@
+package highlight
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Snippet 截取关键词附近的一段文本 关键词用<em>包起来 其他内容都转义过 可以直接当html输出
+// width是截取的字数 关键词不区分大小写
+func Snippet(text string, terms []string, width int) string {
+	runes := []rune(text)
+	lower := toLower(runes)
+
+	var termList [][]rune
+	for _, term := range terms {
+		if term = strings.TrimSpace(term); term != "" {
+			termList = append(termList, toLower([]rune(term)))
+		}
+	}
+	// 长的先匹配 feng和fengfeng都有的时候整个标出来
+	sort.Slice(termList, func(i, j int) bool {
+		return len(termList[i]) > len(termList[j])
+	})
+
+	first := -1
+	for i := range lower {
+		if matchAt(lower, i, termList) > 0 {
+			first = i
+			break
+		}
+	}
+
+	start := 0
+	if first > width/3 {
+		start = first - width/3
+	}
+	end := start + width
+	if end > len(runes) {
+		// 关键词靠后的时候前面多留一点
+		end = len(runes)
+		start = max(0, end-width)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	for i := start; i < end; {
+		n := matchAt(lower, i, termList)
+		if n == 0 {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+		if i+n > end {
+			end = i + n
+		}
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(string(runes[i : i+n])))
+		b.WriteString("</em>")
+		i += n
+	}
+	if end < len(runes) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+func toLower(runes []rune) []rune {
+	out := make([]rune, len(runes))
+	for i, r := range runes {
+		out[i] = unicode.ToLower(r)
+	}
+	return out
+}
+
+// matchAt 在i这个位置能匹配上的关键词长度 没有就是0
+func matchAt(text []rune, i int, termList [][]rune) int {
+	for _, term := range termList {
+		if i+len(term) > len(text) {
+			continue
+		}
+		ok := true
+		for j, r := range term {
+			if text[i+j] != r {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return len(term)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,30 @@
+package highlight
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSnippet(t *testing.T) {
+	cases := []struct {
+		text  string
+		terms []string
+		width int
+		want  string
+	}{
+		{"今天晚上一起吃火锅吗", []string{"火锅"}, 20, "今天晚上一起吃<em>火锅</em>吗"},
+		{"Hello FengFeng", []string{"feng"}, 20, "Hello <em>Feng</em><em>Feng</em>"},
+		{"Hello FengFeng", []string{"feng", "fengfeng"}, 20, "Hello <em>FengFeng</em>"},
+		{"<b>火锅</b>", []string{"火锅"}, 20, "&lt;b&gt;<em>火锅</em>&lt;/b&gt;"},
+		{"一二三四五六七八九十一二三四五六七八九十火锅一二三四五六七八九十", []string{"火锅"}, 12, "...七八九十<em>火锅</em>一二三四五六..."},
+		{"没有关键词的一段很长的文本", []string{"火锅"}, 6, "没有关键词的..."},
+		{"一二三四五六七八九十火锅", []string{"火锅"}, 6, "...七八九十<em>火锅</em>"},
+	}
+	for _, c := range cases {
+		got := Snippet(c.text, c.terms, c.width)
+		fmt.Println(got)
+		if got != c.want {
+			t.Errorf("%s 期望 %s 实际 %s", c.text, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package imgproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+var (
+	ErrFormat  = errors.New("不支持的图片格式")
+	ErrTooBig  = errors.New("图片尺寸过大")
+	ErrDecode  = errors.New("图片解析失败")
+	ErrNoThumb = errors.New("图片不需要缩略图")
+)
+
+type Options struct {
+	MaxPixels   int // 宽乘高的上限 解码之前就检查 防止解压炸弹
+	JpegQuality int // 重新编码jpeg的质量
+}
+
+// Image 处理之后的图片
+type Image struct {
+	Data   []byte // 重新编码之后的数据 去掉了exif这些元数据
+	Mime   string
+	Width  int
+	Height int
+	img    image.Image // 缩略图用 webp解不了是nil
+	opt    Options
+}
+
+// Process 校验尺寸 解码 按exif方向摆正 去掉元数据 重新编码
+func Process(data []byte, opt Options) (*Image, error) {
+	format, width, height, err := DecodeConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	if width <= 0 || height <= 0 {
+		return nil, ErrDecode
+	}
+	if opt.MaxPixels > 0 && width*height > opt.MaxPixels {
+		return nil, fmt.Errorf("%w %dx%d", ErrTooBig, width, height)
+	}
+
+	res := &Image{Width: width, Height: height, opt: opt}
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrDecode
+		}
+		rgba := orient(toRGBA(img), jpegOrientation(data))
+		err = jpeg.Encode(&buf, rgba, &jpeg.Options{Quality: opt.JpegQuality})
+		if err != nil {
+			return nil, err
+		}
+		res.img, res.Mime = rgba, "image/jpeg"
+		res.Width, res.Height = rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	case "png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrDecode
+		}
+		// png重新编码只会写图像数据 tEXt eXIf这些块都没了
+		err = png.Encode(&buf, img)
+		if err != nil {
+			return nil, err
+		}
+		res.img, res.Mime = img, "image/png"
+	case "gif":
+		frames, err := gifFrameCount(data)
+		if err != nil || frames == 0 {
+			return nil, ErrDecode
+		}
+		if opt.MaxPixels > 0 && frames*width*height > opt.MaxPixels*4 {
+			return nil, fmt.Errorf("%w 帧数太多", ErrTooBig)
+		}
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil || len(g.Image) == 0 {
+			return nil, ErrDecode
+		}
+		// 动图保留所有帧 注释和应用扩展会被丢掉
+		err = gif.EncodeAll(&buf, g)
+		if err != nil {
+			return nil, err
+		}
+		res.img, res.Mime = g.Image[0], "image/gif"
+	case "webp":
+		// 标准库解不了webp 只去掉元数据 不做缩略图
+		stripped, err := stripWebp(data)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(stripped)
+		res.Mime = "image/webp"
+	default:
+		return nil, ErrFormat
+	}
+	res.Data = buf.Bytes()
+	return res, nil
+}
+
+// DecodeConfig 只读头部拿到格式和尺寸 不解码像素
+func DecodeConfig(data []byte) (format string, width, height int, err error) {
+	if isWebp(data) {
+		width, height, err = webpConfig(data)
+		return "webp", width, height, err
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", 0, 0, ErrFormat
+	}
+	return format, config.Width, config.Height, nil
+}
+
+// Thumbnail 等比缩小到长边不超过size 原图本来就小就返回ErrNoThumb
+// 不透明的图输出jpeg 有透明的输出png
+func (i *Image) Thumbnail(size int) ([]byte, string, error) {
+	if i.img == nil || size <= 0 || (i.Width <= size && i.Height <= size) {
+		return nil, "", ErrNoThumb
+	}
+	width, height := size, i.Height*size/i.Width
+	if i.Height > i.Width {
+		width, height = i.Width*size/i.Height, size
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	thumb := resize(toRGBA(i.img), width, height)
+
+	var buf bytes.Buffer
+	if thumb.Opaque() {
+		err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: i.opt.JpegQuality})
+		return buf.Bytes(), "image/jpeg", err
+	}
+	err := png.Encode(&buf, thumb)
+	return buf.Bytes(), "image/png", err
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+// resize 区域平均缩小 每个目标像素取原图对应区域的平均值
+// 用的是预乘alpha的RGBA 透明边缘不会发黑
+func resize(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	for y := 0; y < height; y++ {
+		y0 := y * sh / height
+		y1 := (y + 1) * sh / height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := x * sw / width
+			x1 := (x + 1) * sw / width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(src.Pix[offset])
+					g += uint64(src.Pix[offset+1])
+					b += uint64(src.Pix[offset+2])
+					a += uint64(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r / n)
+			dst.Pix[offset+1] = uint8(g / n)
+			dst.Pix[offset+2] = uint8(b / n)
+			dst.Pix[offset+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
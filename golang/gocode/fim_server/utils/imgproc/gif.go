@@ -0,0 +1,58 @@
+package imgproc
+
+// gifFrameCount 只走一遍块结构数帧数 不解压像素
+// DecodeAll会先把所有帧都解出来 几千帧的小文件解完内存就没了 要在解码之前算好
+func gifFrameCount(data []byte) (int, error) {
+	// 6字节签名 7字节逻辑屏幕描述
+	if len(data) < 13 {
+		return 0, ErrDecode
+	}
+	i := 13
+	if data[10]&0x80 != 0 {
+		i += 3 << (data[10]&0x07 + 1)
+	}
+	var frames int
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // 扩展 标签后面跟数据子块
+			var ok bool
+			i, ok = skipSubBlocks(data, i+2)
+			if !ok {
+				return 0, ErrDecode
+			}
+		case 0x2C: // 图像描述 9字节 可能有局部颜色表 然后是LZW最小码长和数据子块
+			if i+10 > len(data) {
+				return 0, ErrDecode
+			}
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			var ok bool
+			i, ok = skipSubBlocks(data, i+1)
+			if !ok {
+				return 0, ErrDecode
+			}
+			frames++
+		case 0x3B: // 结束
+			return frames, nil
+		default:
+			return 0, ErrDecode
+		}
+	}
+	return 0, ErrDecode
+}
+
+// skipSubBlocks 跳过长度为0结束的数据子块 返回后面的位置
+func skipSubBlocks(data []byte, i int) (int, bool) {
+	for i < len(data) {
+		n := int(data[i])
+		i++
+		if n == 0 {
+			return i, true
+		}
+		i += n
+	}
+	return i, false
+}
@@ -0,0 +1,178 @@
+package imgproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var opt = Options{MaxPixels: 1000 * 1000, JpegQuality: 85}
+
+func testImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// withExif 在SOI后面插一个只有方向的APP1
+func withExif(jpegData []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(jpegData[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(jpegData[2:])
+	return out.Bytes()
+}
+
+func TestProcessJpeg(t *testing.T) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, testImage(40, 20, color.RGBA{R: 200, A: 255}), nil)
+	data := withExif(buf.Bytes(), 6)
+	if o := jpegOrientation(data); o != 6 {
+		t.Fatalf("方向解析错误 %d", o)
+	}
+
+	img, err := Process(data, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(img.Mime, img.Width, img.Height, len(data), len(img.Data))
+	// 顺时针转了90度 宽高互换
+	if img.Width != 20 || img.Height != 40 {
+		t.Errorf("方向没有摆正 %dx%d", img.Width, img.Height)
+	}
+	if bytes.Contains(img.Data, []byte("Exif")) {
+		t.Errorf("exif没有去掉")
+	}
+
+	thumb, mime, err := img.Thumbnail(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil || mime != "image/jpeg" || config.Width != 5 || config.Height != 10 {
+		t.Errorf("缩略图错误 %s %+v %v", mime, config, err)
+	}
+	if _, _, err = img.Thumbnail(100); err != ErrNoThumb {
+		t.Errorf("原图比缩略图小 不应该生成")
+	}
+}
+
+func TestProcessPngAlpha(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, testImage(64, 64, color.RGBA{G: 100, A: 100}))
+	img, err := Process(buf.Bytes(), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, mime, err := img.Thumbnail(16)
+	if err != nil || mime != "image/png" {
+		t.Errorf("透明图的缩略图应该是png %s %v", mime, err)
+	}
+}
+
+// TestBomb 头里面写一个很大的尺寸 解码之前就要拒绝
+func TestBomb(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, testImage(1, 1, color.Black))
+	data := buf.Bytes()
+	// 8字节签名 + 4长度 + IHDR + 宽 + 高
+	binary.BigEndian.PutUint32(data[16:20], 50000)
+	binary.BigEndian.PutUint32(data[20:24], 50000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err := Process(data, opt)
+	if !errors.Is(err, ErrTooBig) {
+		t.Errorf("应该拒绝 %v", err)
+	}
+}
+
+// testGif 全屏的帧 像素数据是坏的 先解码就会报解析失败而不是尺寸过大
+func testGif(w, h, frames int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("GIF89a")
+	binary.Write(&buf, binary.LittleEndian, uint16(w))
+	binary.Write(&buf, binary.LittleEndian, uint16(h))
+	buf.Write([]byte{0x80, 0, 0}) // 2色的全局颜色表
+	buf.Write(make([]byte, 6))
+	for i := 0; i < frames; i++ {
+		buf.Write([]byte{0x21, 0xF9, 4, 0, 0, 0, 0, 0}) // 图形控制扩展
+		buf.WriteByte(0x2C)
+		binary.Write(&buf, binary.LittleEndian, [4]uint16{0, 0, uint16(w), uint16(h)})
+		buf.Write([]byte{0, 2, 1, 0xFF, 0})
+	}
+	buf.WriteByte(0x3B)
+	return buf.Bytes()
+}
+
+func TestGifBomb(t *testing.T) {
+	_, err := Process(testGif(1000, 1000, 5), opt)
+	if !errors.Is(err, ErrTooBig) {
+		t.Errorf("帧数太多要在解码之前拒绝 %v", err)
+	}
+	n, err := gifFrameCount(testGif(10, 10, 3000))
+	if err != nil || n != 3000 {
+		t.Errorf("帧数错误 %d %v", n, err)
+	}
+	_, err = gifFrameCount(testGif(10, 10, 2)[:40])
+	if err != ErrDecode {
+		t.Errorf("截断的gif应该报错 %v", err)
+	}
+}
+
+func TestWebp(t *testing.T) {
+	chunk := func(fourcc string, body []byte) []byte {
+		b := append([]byte(fourcc), 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(b[4:], uint32(len(body)))
+		b = append(b, body...)
+		if len(body)%2 == 1 {
+			b = append(b, 0)
+		}
+		return b
+	}
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x08 | 0x10 // exif alpha
+	vp8x[4], vp8x[7] = 99, 49
+	body := append([]byte("WEBP"), chunk("VP8X", vp8x)...)
+	body = append(body, chunk("EXIF", []byte("gps data"))...)
+	body = append(body, chunk("VP8L", []byte{0x2f, 0, 0, 0, 0})...)
+	data := append([]byte("RIFF\x00\x00\x00\x00"), body...)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+
+	format, w, h, err := DecodeConfig(data)
+	if err != nil || format != "webp" || w != 100 || h != 50 {
+		t.Errorf("webp尺寸错误 %s %d %d %v", format, w, h, err)
+	}
+	img, err := Process(data, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(img.Data, []byte("gps data")) || img.Data[20]&0x08 != 0 {
+		t.Errorf("exif没有去掉")
+	}
+	if binary.LittleEndian.Uint32(img.Data[4:8]) != uint32(len(img.Data)-8) {
+		t.Errorf("RIFF长度错误")
+	}
+}
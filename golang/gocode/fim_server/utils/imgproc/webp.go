@@ -0,0 +1,73 @@
+package imgproc
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+func isWebp(data []byte) bool {
+	return len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+// webpConfig 从VP8X VP8 VP8L块里面读宽高
+func webpConfig(data []byte) (width, height int, err error) {
+	if len(data) < 30 {
+		return 0, 0, ErrDecode
+	}
+	chunk := data[12:]
+	switch string(chunk[:4]) {
+	case "VP8X":
+		// 画布宽高 24位 存的是减1之后的值
+		width = int(chunk[12]) | int(chunk[13])<<8 | int(chunk[14])<<16
+		height = int(chunk[15]) | int(chunk[16])<<8 | int(chunk[17])<<16
+		return width + 1, height + 1, nil
+	case "VP8 ":
+		// 帧头 3字节 + 起始码 9d 01 2a + 14位宽 14位高
+		if chunk[11] != 0x9d || chunk[12] != 0x01 || chunk[13] != 0x2a {
+			return 0, 0, ErrDecode
+		}
+		width = int(binary.LittleEndian.Uint16(chunk[14:16]) & 0x3FFF)
+		height = int(binary.LittleEndian.Uint16(chunk[16:18]) & 0x3FFF)
+		return width, height, nil
+	case "VP8L":
+		// 签名0x2f 后面是14位宽减1 14位高减1
+		if chunk[8] != 0x2f {
+			return 0, 0, ErrDecode
+		}
+		bits := binary.LittleEndian.Uint32(chunk[9:13])
+		width = int(bits&0x3FFF) + 1
+		height = int((bits>>14)&0x3FFF) + 1
+		return width, height, nil
+	}
+	return 0, 0, ErrDecode
+}
+
+// stripWebp 去掉EXIF和XMP块 VP8X里面对应的标记位也要清掉
+func stripWebp(data []byte) ([]byte, error) {
+	if !isWebp(data) {
+		return nil, ErrFormat
+	}
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	offset := 12
+	for offset+8 <= len(data) {
+		fourcc := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		end := offset + 8 + size + size%2 // 块的长度是奇数要补一个字节
+		if end > len(data) {
+			return nil, errors.New("webp数据不完整")
+		}
+		switch fourcc {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte{}, data[offset:end]...)
+			chunk[8] &^= 0x08 | 0x04 // exif xmp标记
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[offset:end]...)
+		}
+		offset = end
+	}
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
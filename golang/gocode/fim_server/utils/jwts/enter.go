@@ -2,6 +2,7 @@ package jwts
 
 import (
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"time"
 )
@@ -24,6 +25,7 @@ func GenToken(payload JwtPayLoad, accessSecret string, expires int) (string, err
 		JwtPayLoad: payload,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(expires))),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
@@ -45,3 +47,16 @@ func ParseToken(tokenStr string, accessSecret string) (*CustomClaims, error) {
 	}
 	return nil, errors.New("invalid token")
 }
+
+// RevokeKey 用户的token吊销时间 在这个时间之前签发的token都失效
+func RevokeKey(userID uint) string {
+	return fmt.Sprintf("revoke_%d", userID)
+}
+
+// IsRevoked 判断token是不是在吊销时间之前签发的
+func (c *CustomClaims) IsRevoked(revokeAt int64) bool {
+	if c.IssuedAt == nil {
+		return true
+	}
+	return c.IssuedAt.Unix() < revokeAt
+}
@@ -0,0 +1,284 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var ErrUnknownDuration = errors.New("无法解析时长")
+
+// Duration 从容器的头信息里面解析音视频的时长 单位秒
+func Duration(r io.ReaderAt, size int64, mime string) (float64, error) {
+	switch mime {
+	case "video/mp4", "audio/mp4", "video/quicktime", "video/3gpp", "video/3gpp2":
+		return mp4Duration(r, size)
+	case "audio/wave", "audio/wav", "audio/x-wav":
+		return wavDuration(r, size)
+	case "audio/mpeg":
+		return mp3Duration(r, size)
+	case "audio/ogg", "application/ogg":
+		return oggDuration(r, size)
+	case "audio/amr":
+		return amrDuration(r, size)
+	}
+	return 0, ErrUnknownDuration
+}
+
+// readAt 读不满就报错
+func readAt(r io.ReaderAt, size int, offset int64) ([]byte, error) {
+	buf := make([]byte, size)
+	n, err := r.ReadAt(buf, offset)
+	if n == size {
+		return buf, nil
+	}
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, err
+}
+
+// mp4Duration 找moov/mvhd box里面的timescale和duration
+func mp4Duration(r io.ReaderAt, size int64) (float64, error) {
+	moov, moovSize, err := findBox(r, 0, size, "moov")
+	if err != nil {
+		return 0, err
+	}
+	mvhd, _, err := findBox(r, moov, moov+moovSize, "mvhd")
+	if err != nil {
+		return 0, err
+	}
+	head, err := readAt(r, 4, mvhd)
+	if err != nil {
+		return 0, err
+	}
+	var timescale uint32
+	var duration uint64
+	if head[0] == 1 {
+		buf, err := readAt(r, 32, mvhd)
+		if err != nil {
+			return 0, err
+		}
+		timescale = binary.BigEndian.Uint32(buf[20:24])
+		duration = binary.BigEndian.Uint64(buf[24:32])
+	} else {
+		buf, err := readAt(r, 20, mvhd)
+		if err != nil {
+			return 0, err
+		}
+		timescale = binary.BigEndian.Uint32(buf[12:16])
+		duration = uint64(binary.BigEndian.Uint32(buf[16:20]))
+	}
+	if timescale == 0 {
+		return 0, ErrUnknownDuration
+	}
+	return float64(duration) / float64(timescale), nil
+}
+
+// findBox 在[start, end)范围里面找指定类型的box 返回box内容的起始位置和长度
+func findBox(r io.ReaderAt, start, end int64, boxType string) (int64, int64, error) {
+	offset := start
+	for offset+8 <= end {
+		head, err := readAt(r, 8, offset)
+		if err != nil {
+			return 0, 0, err
+		}
+		boxSize := int64(binary.BigEndian.Uint32(head[:4]))
+		headSize := int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = end - offset
+		case 1:
+			large, err := readAt(r, 8, offset+8)
+			if err != nil {
+				return 0, 0, err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(large))
+			headSize = 16
+		}
+		if boxSize < headSize {
+			return 0, 0, ErrUnknownDuration
+		}
+		if string(head[4:8]) == boxType {
+			return offset + headSize, boxSize - headSize, nil
+		}
+		offset += boxSize
+	}
+	return 0, 0, ErrUnknownDuration
+}
+
+// wavDuration data块的大小除以每秒的字节数
+func wavDuration(r io.ReaderAt, size int64) (float64, error) {
+	head, err := readAt(r, 12, 0)
+	if err != nil {
+		return 0, err
+	}
+	if string(head[:4]) != "RIFF" || string(head[8:12]) != "WAVE" {
+		return 0, ErrUnknownDuration
+	}
+	var byteRate uint32
+	offset := int64(12)
+	for offset+8 <= size {
+		chunk, err := readAt(r, 8, offset)
+		if err != nil {
+			return 0, err
+		}
+		chunkSize := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		switch string(chunk[:4]) {
+		case "fmt ":
+			fmtData, err := readAt(r, 12, offset+8)
+			if err != nil {
+				return 0, err
+			}
+			byteRate = binary.LittleEndian.Uint32(fmtData[8:12])
+		case "data":
+			if byteRate == 0 {
+				return 0, ErrUnknownDuration
+			}
+			if offset+8+chunkSize > size {
+				chunkSize = size - offset - 8
+			}
+			return float64(chunkSize) / float64(byteRate), nil
+		}
+		offset += 8 + chunkSize + chunkSize%2
+	}
+	return 0, ErrUnknownDuration
+}
+
+var mp3Bitrates = [2][16]int{
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}, // MPEG1 Layer3
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},     // MPEG2/2.5 Layer3
+}
+
+var mp3SampleRates = [4][3]int{
+	{11025, 12000, 8000},  // MPEG2.5
+	{0, 0, 0},             // 保留
+	{22050, 24000, 16000}, // MPEG2
+	{44100, 48000, 32000}, // MPEG1
+}
+
+// mp3Duration 有Xing/Info头就用帧数算 没有就按固定码率估算
+func mp3Duration(r io.ReaderAt, size int64) (float64, error) {
+	offset := int64(0)
+	id3, err := readAt(r, 10, 0)
+	if err != nil {
+		return 0, err
+	}
+	if string(id3[:3]) == "ID3" {
+		// id3v2的大小是synchsafe整数
+		tagSize := int64(id3[6]&0x7F)<<21 | int64(id3[7]&0x7F)<<14 | int64(id3[8]&0x7F)<<7 | int64(id3[9]&0x7F)
+		offset = 10 + tagSize
+	}
+
+	frame, err := readAt(r, 4, offset)
+	if err != nil {
+		return 0, err
+	}
+	if frame[0] != 0xFF || frame[1]&0xE0 != 0xE0 {
+		return 0, ErrUnknownDuration
+	}
+	version := (frame[1] >> 3) & 0x03 // 3 MPEG1 2 MPEG2 0 MPEG2.5
+	layer := (frame[1] >> 1) & 0x03   // 1 Layer3
+	if version == 1 || layer != 1 {
+		return 0, ErrUnknownDuration
+	}
+	bitrateIndex := frame[2] >> 4
+	sampleIndex := (frame[2] >> 2) & 0x03
+	if sampleIndex == 3 {
+		return 0, ErrUnknownDuration
+	}
+	sampleRate := mp3SampleRates[version][sampleIndex]
+	table := 0
+	samplesPerFrame := 1152
+	sideInfo := 32
+	if version != 3 {
+		table = 1
+		samplesPerFrame = 576
+		sideInfo = 17
+	}
+	channelMode := frame[3] >> 6
+	if channelMode == 3 {
+		if version == 3 {
+			sideInfo = 17
+		} else {
+			sideInfo = 9
+		}
+	}
+
+	// Xing/Info头 在side info后面
+	xing, err := readAt(r, 12, offset+4+int64(sideInfo))
+	if err == nil && (string(xing[:4]) == "Xing" || string(xing[:4]) == "Info") && xing[7]&0x01 != 0 {
+		frames := binary.BigEndian.Uint32(xing[8:12])
+		return float64(frames) * float64(samplesPerFrame) / float64(sampleRate), nil
+	}
+
+	bitrate := mp3Bitrates[table][bitrateIndex]
+	if bitrate == 0 {
+		return 0, ErrUnknownDuration
+	}
+	return float64(size-offset) * 8 / float64(bitrate*1000), nil
+}
+
+// oggDuration 最后一页的granule position除以采样率
+func oggDuration(r io.ReaderAt, size int64) (float64, error) {
+	// 页头固定27个字节 最后一个字节是段表的长度 最多255个段
+	head, err := readAt(r, 27, 0)
+	if err != nil || string(head[:4]) != "OggS" {
+		return 0, ErrUnknownDuration
+	}
+	// 段表之后就是第一个包 vorbis的采样率在包的12到16字节
+	packet, err := readAt(r, 16, 27+int64(head[26]))
+	if err != nil {
+		return 0, ErrUnknownDuration
+	}
+	var sampleRate uint32
+	switch {
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		sampleRate = binary.LittleEndian.Uint32(packet[12:16])
+	case bytes.HasPrefix(packet, []byte("OpusHead")):
+		// opus的granule固定是48k
+		sampleRate = 48000
+	default:
+		return 0, ErrUnknownDuration
+	}
+	if sampleRate == 0 {
+		return 0, ErrUnknownDuration
+	}
+
+	tailSize := int64(65536)
+	if tailSize > size {
+		tailSize = size
+	}
+	tail, err := readAt(r, int(tailSize), size-tailSize)
+	if err != nil {
+		return 0, err
+	}
+	i := bytes.LastIndex(tail, []byte("OggS"))
+	if i == -1 || i+14 > len(tail) {
+		return 0, ErrUnknownDuration
+	}
+	granule := binary.LittleEndian.Uint64(tail[i+6 : i+14])
+	return float64(granule) / float64(sampleRate), nil
+}
+
+// amrFrameSizes amr-nb每种模式一帧的字节数 包括帧头 一帧20毫秒
+var amrFrameSizes = [16]int64{13, 14, 16, 18, 20, 21, 27, 32, 6, 0, 0, 0, 0, 0, 0, 1}
+
+func amrDuration(r io.ReaderAt, size int64) (float64, error) {
+	offset := int64(6) // #!AMR\n
+	var frames int64
+	buf := make([]byte, 1)
+	for offset < size {
+		if _, err := r.ReadAt(buf, offset); err != nil {
+			break
+		}
+		frameSize := amrFrameSizes[(buf[0]>>3)&0x0F]
+		if frameSize == 0 {
+			return 0, ErrUnknownDuration
+		}
+		offset += frameSize
+		frames++
+	}
+	return float64(frames) * 0.02, nil
+}
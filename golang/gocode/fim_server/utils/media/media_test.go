@@ -0,0 +1,134 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+func box(boxType string, data ...[]byte) []byte {
+	body := bytes.Join(data, nil)
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint32(buf, uint32(8+len(body)))
+	copy(buf[4:], boxType)
+	return append(buf, body...)
+}
+
+func testMp4() []byte {
+	mvhd := make([]byte, 20)
+	binary.BigEndian.PutUint32(mvhd[12:16], 1000)  // timescale
+	binary.BigEndian.PutUint32(mvhd[16:20], 12500) // duration
+	return append(box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2")), box("moov", box("mvhd", mvhd))...)
+}
+
+func testWav(seconds int) []byte {
+	var buf bytes.Buffer
+	sampleRate, channels, bits := 8000, 1, 16
+	byteRate := sampleRate * channels * bits / 8
+	dataSize := byteRate * seconds
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(byteRate))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels*bits/8))
+	binary.Write(&buf, binary.LittleEndian, uint16(bits))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(dataSize))
+	buf.Write(make([]byte, dataSize))
+	return buf.Bytes()
+}
+
+func TestDetectType(t *testing.T) {
+	cases := []struct {
+		data []byte
+		mime string
+	}{
+		{testMp4(), "video/mp4"},
+		{testWav(1), "audio/wave"},
+		{[]byte("#!AMR\n\x3c"), "audio/amr"},
+		{[]byte("\x89PNG\r\n\x1a\n"), "image/png"},
+		{[]byte("%PDF-1.7"), "application/pdf"},
+		{[]byte{0xFF, 0xFB, 0x90, 0x44}, "audio/mpeg"},
+		{[]byte("MZ\x90\x00"), "application/x-msdownload"},
+		{[]byte("hello world"), "text/plain"},
+	}
+	for _, c := range cases {
+		mime := DetectType(c.data)
+		fmt.Println(mime)
+		if mime != c.mime {
+			t.Errorf("识别错误 %s != %s", mime, c.mime)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	mp4 := testMp4()
+	d, err := Duration(bytes.NewReader(mp4), int64(len(mp4)), "video/mp4")
+	if err != nil || d != 12.5 {
+		t.Errorf("mp4时长错误 %v %v", d, err)
+	}
+
+	wav := testWav(3)
+	d, err = Duration(bytes.NewReader(wav), int64(len(wav)), "audio/wave")
+	if err != nil || d != 3 {
+		t.Errorf("wav时长错误 %v %v", d, err)
+	}
+
+	// 128kbps 44.1k 的固定码率mp3 16000字节就是1秒
+	mp3 := make([]byte, 16000)
+	copy(mp3, []byte{0xFF, 0xFB, 0x90, 0x44})
+	d, err = Duration(bytes.NewReader(mp3), int64(len(mp3)), "audio/mpeg")
+	if err != nil || d != 1 {
+		t.Errorf("mp3时长错误 %v %v", d, err)
+	}
+
+	// 50帧 12.2k模式 就是1秒
+	amr := append([]byte("#!AMR\n"), bytes.Repeat(append([]byte{0x3c}, make([]byte, 31)...), 50)...)
+	d, err = Duration(bytes.NewReader(amr), int64(len(amr)), "audio/amr")
+	if err != nil || d != 1 {
+		t.Errorf("amr时长错误 %v %v", d, err)
+	}
+
+	_, err = Duration(bytes.NewReader(nil), 0, "application/pdf")
+	if err != ErrUnknownDuration {
+		t.Errorf("不支持的类型应该报错")
+	}
+}
+
+// testOgg 第一页带segments个段 后面跟一个vorbis头 最后一页的granule是1秒
+func testOgg(segments int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("OggS")
+	buf.Write(make([]byte, 22))
+	buf.WriteByte(byte(segments))
+	buf.Write(bytes.Repeat([]byte{1}, segments))
+	buf.WriteString("\x01vorbis")
+	buf.Write(make([]byte, 5))
+	binary.Write(&buf, binary.LittleEndian, uint32(44100))
+	buf.WriteString("OggS")
+	buf.Write(make([]byte, 2))
+	binary.Write(&buf, binary.LittleEndian, uint64(44100))
+	return buf.Bytes()
+}
+
+func TestOggDuration(t *testing.T) {
+	for _, segments := range []int{1, 38, 255} {
+		ogg := testOgg(segments)
+		d, err := Duration(bytes.NewReader(ogg), int64(len(ogg)), "audio/ogg")
+		if err != nil || d != 1 {
+			t.Errorf("%d个段的ogg时长错误 %v %v", segments, d, err)
+		}
+	}
+
+	// 段表声明了255个段 文件在段表中间就没了
+	short := testOgg(255)[:100]
+	_, err := Duration(bytes.NewReader(short), int64(len(short)), "audio/ogg")
+	if err != ErrUnknownDuration {
+		t.Errorf("页头不完整应该报错 %v", err)
+	}
+}
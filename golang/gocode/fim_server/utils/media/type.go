@@ -0,0 +1,87 @@
+package media
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// SniffLen 判断文件类型需要读的字节数
+const SniffLen = 512
+
+// signature 标准库识别不了的一些格式
+type signature struct {
+	offset int
+	magic  []byte
+	mime   string
+}
+
+var signatureList = []signature{
+	{0, []byte("#!AMR\n"), "audio/amr"},
+	{0, []byte("#!AMR-WB\n"), "audio/amr-wb"},
+	{0, []byte("fLaC"), "audio/flac"},
+	{0, []byte{0xFF, 0xF1}, "audio/aac"},
+	{0, []byte{0xFF, 0xF9}, "audio/aac"},
+	{0, []byte("#!SILK_V3"), "audio/silk"},
+	{0, []byte{0x02, '#', '!', 'S', 'I', 'L', 'K', '_', 'V', '3'}, "audio/silk"},
+	{0, []byte{0x1A, 0x45, 0xDF, 0xA3}, "video/x-matroska"}, // webm标准库已经识别了 剩下的是mkv
+	{0, []byte("7z\xBC\xAF\x27\x1C"), "application/x-7z-compressed"},
+	{0, []byte("Rar!\x1A\x07"), "application/vnd.rar"},
+	{0, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}, "application/msword"},
+	{0, []byte("MZ"), "application/x-msdownload"},
+	{0, []byte("\x7FELF"), "application/x-executable"},
+}
+
+// ftypBrands mp4容器的brand区分音频和视频
+var ftypBrands = map[string]string{
+	"M4A ": "audio/mp4",
+	"M4B ": "audio/mp4",
+	"M4V ": "video/mp4",
+	"qt  ": "video/quicktime",
+	"3gp4": "video/3gpp",
+	"3gp5": "video/3gpp",
+	"3gp6": "video/3gpp",
+	"3g2a": "video/3gpp2",
+}
+
+// DetectType 根据文件头的魔数判断文件的mime类型 不看后缀
+func DetectType(head []byte) string {
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		if mime, ok := ftypBrands[string(head[8:12])]; ok {
+			return mime
+		}
+		return "video/mp4"
+	}
+
+	mime := http.DetectContentType(head)
+	if i := strings.Index(mime, ";"); i != -1 {
+		mime = mime[:i]
+	}
+	if mime != "application/octet-stream" && mime != "text/plain" {
+		return mime
+	}
+
+	for _, s := range signatureList {
+		if len(head) >= s.offset+len(s.magic) && bytes.Equal(head[s.offset:s.offset+len(s.magic)], s.magic) {
+			return s.mime
+		}
+	}
+	// mp3的帧同步头 没有id3标签的时候标准库识别不了
+	if len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0 && head[1]&0x06 != 0 {
+		return "audio/mpeg"
+	}
+	return mime
+}
+
+// Category 根据mime判断是图片 视频 音频 还是普通文件
+func Category(mime string) string {
+	switch {
+	case strings.HasPrefix(mime, "image/"):
+		return "image"
+	case strings.HasPrefix(mime, "video/"):
+		return "video"
+	case strings.HasPrefix(mime, "audio/"):
+		return "audio"
+	}
+	return "file"
+}
@@ -5,9 +5,17 @@ import (
 	"log"
 )
 
-// HashPwd hash密码
+// HashPwd hash密码 使用默认的cost
 func HashPwd(pwd string) string {
-	hash, err := bcrypt.GenerateFromPassword([]byte(pwd), bcrypt.MinCost)
+	return HashPwdWithCost(pwd, bcrypt.DefaultCost)
+}
+
+// HashPwdWithCost 按指定的cost hash密码 cost不合法时使用默认的cost
+func HashPwdWithCost(pwd string, cost int) string {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pwd), cost)
 	if err != nil {
 		log.Println(err)
 	}
@@ -25,3 +33,12 @@ func CheckPwd(hashPwd string, pwd string) bool {
 	}
 	return true
 }
+
+// NeedRehash hash的cost低于当前配置的cost 登录成功之后需要重新hash
+func NeedRehash(hashPwd string, cost int) bool {
+	hashCost, err := bcrypt.Cost([]byte(hashPwd))
+	if err != nil {
+		return false
+	}
+	return hashCost < cost
+}
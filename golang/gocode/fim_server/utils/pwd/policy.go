@@ -0,0 +1,132 @@
+package pwd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Policy 密码强度策略
+type Policy struct {
+	MinLength     int  // 最小长度
+	MaxLength     int  // 最大长度 bcrypt只取前72个字节
+	RequireUpper  bool // 必须包含大写字母
+	RequireLower  bool // 必须包含小写字母
+	RequireDigit  bool // 必须包含数字
+	RequireSymbol bool // 必须包含特殊字符
+}
+
+// breachedPwdList 内置的常见泄露密码 统一小写比较
+var breachedPwdList = map[string]struct{}{
+	"123456":      {},
+	"1234567":     {},
+	"12345678":    {},
+	"123456789":   {},
+	"1234567890":  {},
+	"12345678910": {},
+	"111111":      {},
+	"11111111":    {},
+	"000000":      {},
+	"00000000":    {},
+	"666666":      {},
+	"888888":      {},
+	"88888888":    {},
+	"123123":      {},
+	"123123123":   {},
+	"654321":      {},
+	"987654321":   {},
+	"112233":      {},
+	"123321":      {},
+	"5201314":     {},
+	"1314520":     {},
+	"a123456":     {},
+	"a12345678":   {},
+	"aa123456":    {},
+	"abc123":      {},
+	"abc123456":   {},
+	"abcd1234":    {},
+	"qwerty":      {},
+	"qwerty123":   {},
+	"qwe123":      {},
+	"qwe123456":   {},
+	"1q2w3e4r":    {},
+	"1qaz2wsx":    {},
+	"zxcvbnm":     {},
+	"asdfghjkl":   {},
+	"password":    {},
+	"password1":   {},
+	"password123": {},
+	"passw0rd":    {},
+	"p@ssw0rd":    {},
+	"iloveyou":    {},
+	"admin":       {},
+	"admin123":    {},
+	"admin888":    {},
+	"root":        {},
+	"root123":     {},
+	"welcome":     {},
+	"welcome1":    {},
+	"letmein":     {},
+	"monkey":      {},
+	"dragon":      {},
+	"football":    {},
+	"sunshine":    {},
+	"princess":    {},
+	"woaini":      {},
+	"woaini1314":  {},
+}
+
+// IsBreached 是否是常见的泄露密码
+func IsBreached(pwd string) bool {
+	_, ok := breachedPwdList[strings.ToLower(pwd)]
+	return ok
+}
+
+// Check 校验密码是否满足策略
+func (p Policy) Check(pwd string) error {
+	length := len([]rune(pwd))
+	if p.MinLength > 0 && length < p.MinLength {
+		return fmt.Errorf("密码长度不能少于%d位", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("密码长度不能超过%d位", p.MaxLength)
+	}
+	// bcrypt超过72字节的部分会被截断
+	if len(pwd) > 72 {
+		return errors.New("密码过长")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range pwd {
+		switch {
+		case unicode.IsSpace(r) || unicode.IsControl(r):
+			return errors.New("密码不能包含空白字符")
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		return errors.New("密码必须包含大写字母")
+	}
+	if p.RequireLower && !hasLower {
+		return errors.New("密码必须包含小写字母")
+	}
+	if p.RequireDigit && !hasDigit {
+		return errors.New("密码必须包含数字")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		return errors.New("密码必须包含特殊字符")
+	}
+
+	if IsBreached(pwd) {
+		return errors.New("密码过于简单，请更换一个密码")
+	}
+	return nil
+}
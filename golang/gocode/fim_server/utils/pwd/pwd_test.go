@@ -15,3 +15,39 @@ func TestCheckPwd(t *testing.T) {
 	fmt.Println(ok)
 
 }
+
+func TestNeedRehash(t *testing.T) {
+	hash := HashPwdWithCost("1234", 4)
+	if !NeedRehash(hash, 10) {
+		t.Error("cost 4 的hash应该需要重新hash")
+	}
+	if NeedRehash(hash, 4) {
+		t.Error("cost 相同不需要重新hash")
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{
+		MinLength:    8,
+		MaxLength:    32,
+		RequireLower: true,
+		RequireDigit: true,
+	}
+	list := []struct {
+		pwd string
+		ok  bool
+	}{
+		{"abc12", false},
+		{"abcdefgh", false},
+		{"12345678", false},
+		{"password123", false}, // 泄露的密码
+		{"abc 12345", false},
+		{"fengfeng1234", true},
+	}
+	for _, s := range list {
+		err := policy.Check(s.pwd)
+		if (err == nil) != s.ok {
+			t.Errorf("%s 期望 %v, 结果 %v", s.pwd, s.ok, err)
+		}
+	}
+}
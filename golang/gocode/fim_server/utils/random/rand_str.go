@@ -1,6 +1,10 @@
 package random
 
-import "math/rand"
+import (
+	crand "crypto/rand"
+	"math/big"
+	"math/rand"
+)
 
 var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
 
@@ -11,3 +15,17 @@ func RandStr(n int) string {
 	}
 	return string(b)
 }
+
+// SecureStr 用crypto/rand生成随机字符串 用在凭证这类不能被猜到的地方
+func SecureStr(n int) string {
+	b := make([]rune, n)
+	max := big.NewInt(int64(len(letters)))
+	for i := range b {
+		index, err := crand.Int(crand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b[i] = letters[index.Int64()]
+	}
+	return string(b)
+}
@@ -10,3 +10,11 @@ func TestRandStr(t *testing.T) {
 	fmt.Println(RandStr(4))
 	fmt.Println(RandStr(4))
 }
+
+func TestSecureStr(t *testing.T) {
+	s := SecureStr(32)
+	if len(s) != 32 {
+		t.Errorf("长度错误 %s", s)
+	}
+	fmt.Println(s)
+}
@@ -1,18 +1,288 @@
 package safe
 
 import (
+	"fmt"
+	"io"
 	"regexp"
 	"strings"
+
+	"golang.org/x/net/html"
 )
 
+// Policy html白名单 只有白名单里面的标签和属性才会保留
+type Policy struct {
+	tags     map[string]map[string]bool // 标签 -> 允许的属性
+	urlAttrs map[string]bool            // 值是url的属性 要校验协议
+	schemes  map[string]bool            // 允许的url协议 相对地址始终允许
+}
+
+// dropContentTags 这些标签连里面的内容一起丢掉
+var dropContentTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"template": true,
+	"textarea": true,
+	"xmp":      true,
+	"svg":      true,
+	"math":     true,
+}
+
+// classRegex class只能是普通的类名
+var classRegex = regexp.MustCompile(`^[a-zA-Z0-9_\- ]*$`)
+
+// schemeRegex url里面冒号前面的协议部分
+var schemeRegex = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.\-]*):`)
+
+func NewPolicy() *Policy {
+	return &Policy{
+		tags:     map[string]map[string]bool{},
+		urlAttrs: map[string]bool{"src": true, "href": true},
+		schemes:  map[string]bool{"http": true, "https": true},
+	}
+}
+
+// AllowTag 允许某个标签和它的属性
+func (p *Policy) AllowTag(tag string, attrs ...string) *Policy {
+	set, ok := p.tags[tag]
+	if !ok {
+		set = map[string]bool{}
+		p.tags[tag] = set
+	}
+	for _, attr := range attrs {
+		set[attr] = true
+	}
+	return p
+}
+
+// AllowSchemes 允许的url协议
+func (p *Policy) AllowSchemes(schemes ...string) *Policy {
+	for _, scheme := range schemes {
+		p.schemes[strings.ToLower(scheme)] = true
+	}
+	return p
+}
+
+// ImageTextPolicy 图文消息的白名单
+// <img src="xxx"/> 这是文本 <i class="iconfont xxx"></i>
+var ImageTextPolicy = NewPolicy().
+	AllowTag("img", "src").
+	AllowTag("i", "class").
+	AllowTag("p").
+	AllowTag("br").
+	AllowTag("span")
+
+// Sanitize 按白名单清洗html 返回清洗之后的html和不合法的地方
+func (p *Policy) Sanitize(inputHTML string) (string, []string) {
+	var out strings.Builder
+	var violations []string
+	var stack []string // 已经输出的开始标签 用来配对结束标签
+	skip := ""         // 正在丢弃内容的标签
+	skipDepth := 0
+
+	z := html.NewTokenizer(strings.NewReader(inputHTML))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				violations = append(violations, "html解析失败")
+			}
+			break
+		}
+		token := z.Token()
+
+		if skip != "" {
+			// 丢弃内容的标签里面只关心同名标签的嵌套
+			switch {
+			case tt == html.StartTagToken && token.Data == skip:
+				skipDepth++
+			case tt == html.EndTagToken && token.Data == skip:
+				skipDepth--
+				if skipDepth == 0 {
+					skip = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			out.WriteString(html.EscapeString(token.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			attrs, ok := p.tags[token.Data]
+			if !ok {
+				violations = append(violations, fmt.Sprintf("不允许的标签 <%s>", token.Data))
+				if tt == html.StartTagToken && dropContentTags[token.Data] {
+					skip = token.Data
+					skipDepth = 1
+				}
+				continue
+			}
+			out.WriteString("<" + token.Data)
+			for _, attr := range token.Attr {
+				if attr.Namespace != "" || !attrs[attr.Key] {
+					violations = append(violations, fmt.Sprintf("<%s> 不允许的属性 %s", token.Data, attr.Key))
+					continue
+				}
+				if msg := p.checkAttr(attr.Key, attr.Val); msg != "" {
+					violations = append(violations, fmt.Sprintf("<%s> %s", token.Data, msg))
+					continue
+				}
+				out.WriteString(fmt.Sprintf(` %s="%s"`, attr.Key, html.EscapeString(attr.Val)))
+			}
+			if tt == html.SelfClosingTagToken || isVoid(token.Data) {
+				out.WriteString("/>")
+				continue
+			}
+			out.WriteString(">")
+			stack = append(stack, token.Data)
+		case html.EndTagToken:
+			if _, ok := p.tags[token.Data]; !ok {
+				violations = append(violations, fmt.Sprintf("不允许的标签 </%s>", token.Data))
+				continue
+			}
+			if isVoid(token.Data) {
+				continue
+			}
+			// 找到最近的同名开始标签 中间没闭合的一起闭合
+			index := -1
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] == token.Data {
+					index = i
+					break
+				}
+			}
+			if index == -1 {
+				continue
+			}
+			for i := len(stack) - 1; i >= index; i-- {
+				out.WriteString("</" + stack[i] + ">")
+			}
+			stack = stack[:index]
+		case html.CommentToken:
+			violations = append(violations, "不允许html注释")
+		case html.DoctypeToken:
+			violations = append(violations, "不允许doctype")
+		}
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		out.WriteString("</" + stack[i] + ">")
+	}
+	return out.String(), violations
+}
+
+// Check 只校验不清洗
+func (p *Policy) Check(inputHTML string) []string {
+	_, violations := p.Sanitize(inputHTML)
+	return violations
+}
+
+func (p *Policy) checkAttr(key, val string) string {
+	if key == "class" && !classRegex.MatchString(val) {
+		return "class不合法"
+	}
+	if !p.urlAttrs[key] {
+		return ""
+	}
+	// 浏览器解析url的时候会忽略空白和控制字符 java\tscript: 也是javascript:
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, val)
+	match := schemeRegex.FindStringSubmatch(cleaned)
+	if match == nil {
+		// 没有协议就是相对地址
+		return ""
+	}
+	if !p.schemes[strings.ToLower(match[1])] {
+		return fmt.Sprintf("%s 不允许的协议 %s", key, match[1])
+	}
+	return ""
+}
+
+func isVoid(tag string) bool {
+	switch tag {
+	case "br", "img", "hr", "input", "meta", "link", "area", "base", "col", "embed", "source", "track", "wbr":
+		return true
+	}
+	return false
+}
+
+// SanitizeHTML 按图文消息的白名单清洗html
 func SanitizeHTML(inputHTML string) string {
-	re := regexp.MustCompile(`(?i)(</?script.*?>)|(</?iframe.*?>)|(onerror)|(</?meta.*?>)`)
-	ok := re.MatchString(inputHTML)
-	if ok {
-		s := strings.ReplaceAll(inputHTML, "<", "&lt;")
-		s = strings.ReplaceAll(s, ">", "&gt;")
-		s = strings.ReplaceAll(s, "\"", "&quot;")
-		return s
-	}
-	return inputHTML
+	s, _ := ImageTextPolicy.Sanitize(inputHTML)
+	return s
+}
+
+// StripHTML 去掉所有标签 只留下文本 搜索和预览用 结果不能直接当html输出
+func StripHTML(inputHTML string) string {
+	var out strings.Builder
+	skip := ""
+	skipDepth := 0
+	z := html.NewTokenizer(strings.NewReader(inputHTML))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := z.Token()
+		if skip != "" {
+			switch {
+			case tt == html.StartTagToken && token.Data == skip:
+				skipDepth++
+			case tt == html.EndTagToken && token.Data == skip:
+				skipDepth--
+				if skipDepth == 0 {
+					skip = ""
+				}
+			}
+			continue
+		}
+		switch tt {
+		case html.TextToken:
+			out.WriteString(token.Data)
+		case html.StartTagToken:
+			if dropContentTags[token.Data] {
+				skip = token.Data
+				skipDepth = 1
+				continue
+			}
+			if token.Data == "p" || token.Data == "br" {
+				out.WriteString(" ")
+			}
+		case html.SelfClosingTagToken:
+			if token.Data == "br" {
+				out.WriteString(" ")
+			}
+		}
+	}
+	return strings.TrimSpace(strings.Join(strings.Fields(out.String()), " "))
+}
+
+// ImgSrcList 图文消息里面所有图片的地址
+func ImgSrcList(inputHTML string) (list []string) {
+	z := html.NewTokenizer(strings.NewReader(inputHTML))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		token := z.Token()
+		if token.Data != "img" {
+			continue
+		}
+		for _, attr := range token.Attr {
+			if attr.Key == "src" && attr.Namespace == "" {
+				list = append(list, attr.Val)
+			}
+		}
+	}
 }
@@ -0,0 +1,110 @@
+package safe
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// assertSafe 清洗之后的html只能有白名单里面的标签和属性
+func assertSafe(t *testing.T, payload, clean string) {
+	z := html.NewTokenizer(strings.NewReader(clean))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				t.Errorf("%s 清洗之后无法解析 %s", payload, clean)
+			}
+			return
+		}
+		token := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			attrs, ok := ImageTextPolicy.tags[token.Data]
+			if !ok {
+				t.Errorf("%s 清洗之后还有标签 %s: %s", payload, token.Data, clean)
+			}
+			for _, attr := range token.Attr {
+				if !attrs[attr.Key] {
+					t.Errorf("%s 清洗之后还有属性 %s: %s", payload, attr.Key, clean)
+				}
+				if strings.Contains(strings.ToLower(attr.Val), "script:") {
+					t.Errorf("%s 清洗之后还有脚本地址: %s", payload, clean)
+				}
+			}
+		case html.CommentToken, html.DoctypeToken:
+			t.Errorf("%s 清洗之后还有注释: %s", payload, clean)
+		}
+	}
+}
+
+func TestXSSCorpus(t *testing.T) {
+	file, err := os.Open("testdata/xss_corpus.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		payload := scanner.Text()
+		if payload == "" || strings.HasPrefix(payload, "#") {
+			continue
+		}
+		clean, violations := ImageTextPolicy.Sanitize(payload)
+		if len(violations) == 0 {
+			t.Errorf("%s 没有检测到违规", payload)
+		}
+		assertSafe(t, payload, clean)
+	}
+}
+
+func TestSanitizeAllowed(t *testing.T) {
+	list := []string{
+		`<img src="/api/file/uploads/chat/1.png"/> 这是文本 <i class="iconfont icon-smile"></i>`,
+		`<p>第一段<br>第二行</p><span>x &lt;script&gt; y</span>`,
+		`<img src="https://www.fengfengzhidao.com/a.png">`,
+		`纯文本 1 < 2 && 3 > 2`,
+	}
+	for _, s := range list {
+		clean, violations := ImageTextPolicy.Sanitize(s)
+		if len(violations) != 0 {
+			t.Errorf("%s 不应该有违规 %v", s, violations)
+		}
+		assertSafe(t, s, clean)
+	}
+}
+
+func TestSanitizeOutput(t *testing.T) {
+	clean, _ := ImageTextPolicy.Sanitize(`<p onclick="alert(1)">a<script>alert(1)</script>b<i class="x">c`)
+	if clean != `<p>ab<i class="x">c</i></p>` {
+		t.Errorf("清洗结果错误 %s", clean)
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	cases := map[string]string{
+		`<img src="a.png"/> 这是文本 <i class="iconfont xxx"></i>`: "这是文本",
+		`<p>第一行</p><p>第二行<br>第三行</p>`:                          "第一行 第二行 第三行",
+		`前面<script>alert(1)</script>后面`:                        "前面后面",
+		`a &lt;b&gt; &amp; c`:                                  "a <b> & c",
+	}
+	for input, want := range cases {
+		got := StripHTML(input)
+		if got != want {
+			t.Errorf("%s 期望 %q 实际 %q", input, want, got)
+		}
+	}
+}
+
+func TestImgSrcList(t *testing.T) {
+	list := ImgSrcList(`<p>a<img src="/uploads/ab/1"/>b</p><img SRC="/uploads/cd/2">/uploads/ef/3<i class="x"></i>`)
+	if !reflect.DeepEqual(list, []string{"/uploads/ab/1", "/uploads/cd/2"}) {
+		t.Errorf("图片地址错误 %v", list)
+	}
+}
@@ -0,0 +1,46 @@
+package totp
+
+import (
+	"encoding/base32"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录B的测试向量 取后6位
+func TestCodeAt(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	list := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, s := range list {
+		code, err := CodeAt(secret, Step(time.Unix(s.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != s.code {
+			t.Errorf("%d 期望 %s, 结果 %s", s.unix, s.code, code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, _ := GenerateSecret()
+	fmt.Println(URI("fim", "1", secret))
+	now := time.Now()
+	code, _ := CodeAt(secret, Step(now.Add(-Period*time.Second)))
+	if _, ok := Validate(secret, code, now); !ok {
+		t.Error("上一个时间步的验证码应该可以通过")
+	}
+	code, _ = CodeAt(secret, Step(now.Add(-3*Period*time.Second)))
+	if _, ok := Validate(secret, code, now); ok {
+		t.Error("过期的验证码不应该通过")
+	}
+}